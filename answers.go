@@ -0,0 +1,276 @@
+package postal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// An Answer is the response given to a single question. Only the
+// field matching the question type is meaningful, and only when
+// Response is ResponseAnswered.
+type Answer struct {
+	Response UserResponseType
+	Boolean  bool
+	Choice   ChoiceIDPattern
+	Numeric  float64
+	String   string
+}
+
+// Answers holds the responses collected for a document, keyed by
+// question.
+type Answers map[QuestionIDPattern]Answer
+
+// Answers returns the responses recorded in the question results.
+// A result without a response attribute counts as answered.
+func (r *ResultsType) Answers() Answers {
+	a := make(Answers)
+	resp := func(r UserResponseType) UserResponseType {
+		if r == "" {
+			return ResponseAnswered
+		}
+		return r
+	}
+	qr := &r.Question_results
+	for _, b := range qr.Boolean_question_result {
+		a[b.Question_ref] = Answer{Response: resp(b.Response), Boolean: b.Answer}
+	}
+	for _, c := range qr.Choice_question_result {
+		a[c.Question_ref] = Answer{Response: resp(c.Response), Choice: c.Answer.Choice_ref}
+	}
+	for _, n := range qr.Numeric_question_result {
+		a[n.Question_ref] = Answer{Response: resp(n.Response), Numeric: n.Answer}
+	}
+	for _, s := range qr.String_question_result {
+		a[s.Question_ref] = Answer{Response: resp(s.Response), String: s.Answer}
+	}
+	return a
+}
+
+// A VariableSource supplies variable values by ID.
+type VariableSource interface {
+	Lookup(id VariableIDPattern) (string, bool)
+}
+
+// A Condition restricts the answer to a single question. A
+// condition on an exceptional response only sets Response; for
+// ResponseAnswered exactly one of Boolean, Choices, Equals, Range
+// and Pattern is set, according to the question type.
+type Condition struct {
+	Question QuestionIDPattern
+	Response UserResponseType
+	Boolean  *bool
+	Choices  []ChoiceIDPattern
+	Equals   *EqualsTestActionConditionType
+	Range    *RangeTestActionConditionType
+	Pattern  *PatternTestActionConditionType
+	// Unless lists conditions that exclude answers otherwise
+	// satisfying this one.
+	Unless []Condition
+}
+
+// Matches reports whether a satisfies the condition. Variable
+// references are resolved through vars.
+func (c Condition) Matches(a Answer, vars VariableSource) (bool, error) {
+	for _, u := range c.Unless {
+		if match, err := u.Matches(a, vars); match || err != nil {
+			return false, err
+		}
+	}
+	if a.Response != c.Response {
+		return false, nil
+	}
+	if c.Response != ResponseAnswered {
+		return true, nil
+	}
+	switch {
+	case c.Boolean != nil:
+		return a.Boolean == *c.Boolean, nil
+	case c.Choices != nil:
+		for _, id := range c.Choices {
+			if id == a.Choice {
+				return true, nil
+			}
+		}
+		return false, nil
+	case c.Equals != nil:
+		values := c.Equals.Value
+		if c.Equals.Var_ref != "" {
+			v, err := numericVar(vars, c.Equals.Var_ref)
+			if err != nil {
+				return false, err
+			}
+			values = append(values[:len(values):len(values)], v)
+		}
+		for _, v := range values {
+			if a.Numeric == v {
+				return true, nil
+			}
+		}
+		return false, nil
+	case c.Range != nil:
+		for _, r := range c.Range.Range {
+			ok, err := r.Contains(a.Numeric, vars)
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	case c.Pattern != nil:
+		for _, p := range c.Pattern.Pattern {
+			expr := p.Value
+			if p.Var_ref != "" {
+				v, ok := vars.Lookup(p.Var_ref)
+				if !ok {
+					return false, fmt.Errorf("no value for variable %s", p.Var_ref)
+				}
+				expr = v
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return false, fmt.Errorf("question %s: %v", c.Question, err)
+			}
+			if re.MatchString(a.String) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, nil
+}
+
+// Contains reports whether v lies within the range. A missing
+// bound is unbounded.
+func (r RangeType) Contains(v float64, vars VariableSource) (bool, error) {
+	if r.Min != nil {
+		min, err := r.Min.resolve(vars)
+		if err != nil {
+			return false, err
+		}
+		if v < min || (v == min && !r.Min.Inclusive) {
+			return false, nil
+		}
+	}
+	if r.Max != nil {
+		max, err := r.Max.resolve(vars)
+		if err != nil {
+			return false, err
+		}
+		if v > max || (v == max && !r.Max.Inclusive) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// MarshalXML writes inclusive="false" for an exclusive bound, which
+// the generated type would omit although the schema default is
+// true.
+func (r *RangeValueType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !r.Inclusive {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "inclusive"}, Value: "false"})
+	}
+	if r.Var_ref != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "var_ref"}, Value: string(r.Var_ref)})
+	}
+	return e.EncodeElement(strconv.FormatFloat(r.Value, 'g', -1, 64), start)
+}
+
+func (r *RangeValueType) resolve(vars VariableSource) (float64, error) {
+	if r.Var_ref == "" {
+		return r.Value, nil
+	}
+	return numericVar(vars, r.Var_ref)
+}
+
+func numericVar(vars VariableSource, id VariableIDPattern) (float64, error) {
+	s, ok := vars.Lookup(id)
+	if !ok {
+		return 0, fmt.Errorf("no value for variable %s", id)
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("variable %s: %q is not numeric", id, s)
+	}
+	return f, nil
+}
+
+// String describes the condition in terms of the answer it
+// requires, for example "= true" or "in [1, 5)".
+func (c Condition) String() string {
+	s := c.describe()
+	for i, u := range c.Unless {
+		if i == 0 {
+			s += " unless "
+		} else {
+			s += " or "
+		}
+		s += u.describe()
+	}
+	return s
+}
+
+func (c Condition) describe() string {
+	if c.Response != ResponseAnswered {
+		return "response " + string(c.Response)
+	}
+	switch {
+	case c.Boolean != nil:
+		return "= " + strconv.FormatBool(*c.Boolean)
+	case c.Choices != nil:
+		ids := make([]string, len(c.Choices))
+		for i, id := range c.Choices {
+			ids[i] = string(id)
+		}
+		return "one of " + strings.Join(ids, ", ")
+	case c.Equals != nil:
+		var vals []string
+		for _, v := range c.Equals.Value {
+			vals = append(vals, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		if c.Equals.Var_ref != "" {
+			vals = append(vals, "$"+string(c.Equals.Var_ref))
+		}
+		return "= " + strings.Join(vals, " or ")
+	case c.Range != nil:
+		var rs []string
+		for _, r := range c.Range.Range {
+			rs = append(rs, r.String())
+		}
+		return "in " + strings.Join(rs, " or ")
+	case c.Pattern != nil:
+		var ps []string
+		for _, p := range c.Pattern.Pattern {
+			if p.Var_ref != "" {
+				ps = append(ps, "$"+string(p.Var_ref))
+			} else {
+				ps = append(ps, "/"+p.Value+"/")
+			}
+		}
+		return "matches " + strings.Join(ps, " or ")
+	}
+	return "any answer"
+}
+
+// String formats the range in interval notation.
+func (r RangeType) String() string {
+	bound := func(b *RangeValueType, inf string) string {
+		switch {
+		case b == nil:
+			return inf
+		case b.Var_ref != "":
+			return "$" + string(b.Var_ref)
+		}
+		return strconv.FormatFloat(b.Value, 'g', -1, 64)
+	}
+	lo, hi := "(", ")"
+	if r.Min != nil && r.Min.Inclusive {
+		lo = "["
+	}
+	if r.Max != nil && r.Max.Inclusive {
+		hi = "]"
+	}
+	return lo + bound(r.Min, "-inf") + ", " + bound(r.Max, "+inf") + hi
+}
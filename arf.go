@@ -0,0 +1,138 @@
+package postal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Namespaces of the Asset Reporting Format 1.1 and the
+// specifications it builds on.
+const (
+	ARFNamespace      = "http://scap.nist.gov/schema/asset-reporting-format/1.1"
+	coreNamespace     = "http://scap.nist.gov/schema/reporting-core/1.1"
+	aiNamespace       = "http://scap.nist.gov/schema/asset-identification/1.1"
+	xnlNamespace      = "urn:oasis:names:tc:ciq:xnl:3"
+	arfVocabNamespace = "http://scap.nist.gov/specifications/arf/vocabulary/relationships/1.0#"
+)
+
+// WriteARF writes an ARF 1.1 asset-report-collection holding one
+// report per results document. The source document, without
+// results, is the content of the single report request. Each
+// report is related to the request it was created for and to an
+// asset for each target of its results: a computing device for a
+// system target and a person for a user target. Targets with the
+// same name and kind share an asset.
+func WriteARF(w io.Writer, source *OCILType, results []*OCILType) error {
+	src := *source
+	src.Results = ResultsType{}
+	srcTree, err := elementTree(&src, "ocil")
+	if err != nil {
+		return err
+	}
+	const requestID = "ocil-request-1"
+
+	rels := arfElem(coreNamespace, "relationships")
+	assets := arfElem(ARFNamespace, "assets")
+	reports := arfElem(ARFNamespace, "reports")
+	assetIDs := make(map[string]string)
+	for i, doc := range results {
+		reportID := fmt.Sprintf("ocil-report-%d", i+1)
+		tree, err := elementTree(doc, "ocil")
+		if err != nil {
+			return err
+		}
+		report := arfElem(ARFNamespace, "report", arfElem(ARFNamespace, "content", tree))
+		report.attr = []xml.Attr{{Name: xml.Name{Local: "id"}, Value: reportID}}
+		reports.kids = append(reports.kids, report)
+		rels.kids = append(rels.kids, arfRelationship("createdFor", reportID, requestID))
+
+		for _, t := range doc.Results.Targets.All() {
+			a := arfAsset(t)
+			if a == nil {
+				continue
+			}
+			key := fmt.Sprintf("%T %s", t, t.TargetName())
+			id, ok := assetIDs[key]
+			if !ok {
+				id = fmt.Sprintf("ocil-asset-%d", len(assetIDs)+1)
+				assetIDs[key] = id
+				asset := arfElem(ARFNamespace, "asset", a)
+				asset.attr = []xml.Attr{{Name: xml.Name{Local: "id"}, Value: id}}
+				assets.kids = append(assets.kids, asset)
+			}
+			rels.kids = append(rels.kids, arfRelationship("isAbout", reportID, id))
+		}
+	}
+
+	request := arfElem(ARFNamespace, "report-request", arfElem(ARFNamespace, "content", srcTree))
+	request.attr = []xml.Attr{{Name: xml.Name{Local: "id"}, Value: requestID}}
+	root := arfElem(ARFNamespace, "asset-report-collection")
+	if len(rels.kids) > 0 {
+		root.kids = append(root.kids, rels)
+	}
+	root.kids = append(root.kids, arfElem(ARFNamespace, "report-requests", request))
+	if len(assets.kids) > 0 {
+		root.kids = append(root.kids, assets)
+	}
+	root.kids = append(root.kids, reports)
+
+	p := newTreePrinter(w, "  ")
+	p.prefixes[ARFNamespace] = "arf"
+	p.prefixes[coreNamespace] = "core"
+	p.prefixes[aiNamespace] = "ai"
+	p.prefixes[xnlNamespace] = "xnl"
+	p.prefixes[arfVocabNamespace] = "arfvocab"
+	p.values = []string{arfVocabNamespace}
+	return p.print(root)
+}
+
+func arfElem(space, local string, kids ...*node) *node {
+	return &node{name: xml.Name{Space: space, Local: local}, kids: kids}
+}
+
+func arfText(space, local, text string) *node {
+	return arfElem(space, local, &node{text: text})
+}
+
+func arfRelationship(typ, subject, ref string) *node {
+	n := arfElem(coreNamespace, "relationship", arfText(coreNamespace, "ref", ref))
+	n.attr = []xml.Attr{
+		{Name: xml.Name{Local: "type"}, Value: "arfvocab:" + typ},
+		{Name: xml.Name{Local: "subject"}, Value: subject},
+	}
+	return n
+}
+
+// arfAsset describes a target as an Asset Identification asset.
+func arfAsset(t Target) *node {
+	switch t := t.(type) {
+	case *UserType:
+		person := arfElem(aiNamespace, "person",
+			arfElem(xnlNamespace, "PersonName", arfText(xnlNamespace, "NameElement", t.Name)))
+		for _, e := range t.Email {
+			person.kids = append(person.kids, arfText(aiNamespace, "email-address", e))
+		}
+		return person
+	case *SystemTargetType:
+		device := arfElem(aiNamespace, "computing-device")
+		conns := arfElem(aiNamespace, "connections")
+		for _, a := range t.Ipaddress {
+			version := "ip-v4"
+			if ip := net.ParseIP(a); ip != nil && ip.To4() == nil {
+				version = "ip-v6"
+			}
+			conns.kids = append(conns.kids, arfElem(aiNamespace, "connection",
+				arfElem(aiNamespace, "ip-address", arfText(aiNamespace, version, a))))
+		}
+		if len(conns.kids) > 0 {
+			device.kids = append(device.kids, conns)
+		}
+		if t.Name != "" {
+			device.kids = append(device.kids, arfText(aiNamespace, "hostname", t.Name))
+		}
+		return device
+	}
+	return nil
+}
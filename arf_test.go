@@ -0,0 +1,148 @@
+package postal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// arfCollection is the part of an asset-report-collection that
+// TestWriteARF checks.
+type arfCollection struct {
+	XMLName       xml.Name
+	Relationships []struct {
+		Type    string `xml:"type,attr"`
+		Subject string `xml:"subject,attr"`
+		Ref     string `xml:"ref"`
+	} `xml:"relationships>relationship"`
+	Requests []arfContent `xml:"report-requests>report-request"`
+	Assets   []struct {
+		ID     string `xml:"id,attr"`
+		Device *struct {
+			Hostname    string `xml:"hostname"`
+			Connections []struct {
+				V4 string `xml:"ip-address>ip-v4"`
+				V6 string `xml:"ip-address>ip-v6"`
+			} `xml:"connections>connection"`
+		} `xml:"computing-device"`
+		Person *struct {
+			Name  string   `xml:"PersonName>NameElement"`
+			Email []string `xml:"email-address"`
+		} `xml:"person"`
+	} `xml:"assets>asset"`
+	Reports []arfContent `xml:"reports>report"`
+}
+
+type arfContent struct {
+	ID   string   `xml:"id,attr"`
+	OCIL OCILType `xml:"content>ocil"`
+}
+
+func TestWriteARF(t *testing.T) {
+	source, err := ReadFile("testdata/sample.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []*OCILType
+	for _, name := range []string{"answers-a.xml", "answers-b.xml", "answers-a.xml"} {
+		doc, err := ReadFile("testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, doc)
+	}
+	// The third report shares its system with the first and adds a
+	// user and a system known only by its addresses.
+	results[2].Results.Targets.Add(NewUserTarget("alice", "alice@example.org"))
+	results[2].Results.Targets.Add(NewSystemTarget("", "192.0.2.1", "2001:db8::1"))
+
+	var buf bytes.Buffer
+	if err := WriteARF(&buf, source, results); err != nil {
+		t.Fatal(err)
+	}
+	var c arfCollection
+	if err := xml.Unmarshal(buf.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.XMLName != (xml.Name{Space: ARFNamespace, Local: "asset-report-collection"}) {
+		t.Errorf("root element %v", c.XMLName)
+	}
+
+	var rels []string
+	for _, r := range c.Relationships {
+		rels = append(rels, r.Subject+" "+r.Type+" "+r.Ref)
+	}
+	wantRels := []string{
+		"ocil-report-1 arfvocab:createdFor ocil-request-1",
+		"ocil-report-1 arfvocab:isAbout ocil-asset-1",
+		"ocil-report-2 arfvocab:createdFor ocil-request-1",
+		"ocil-report-2 arfvocab:isAbout ocil-asset-2",
+		"ocil-report-3 arfvocab:createdFor ocil-request-1",
+		"ocil-report-3 arfvocab:isAbout ocil-asset-1",
+		"ocil-report-3 arfvocab:isAbout ocil-asset-3",
+		"ocil-report-3 arfvocab:isAbout ocil-asset-4",
+	}
+	if !reflect.DeepEqual(rels, wantRels) {
+		t.Errorf("relationships\n%s\nwant\n%s", strings.Join(rels, "\n"), strings.Join(wantRels, "\n"))
+	}
+
+	var assets []string
+	for _, a := range c.Assets {
+		s := []string{a.ID}
+		if d := a.Device; d != nil {
+			s = append(s, "computing-device", d.Hostname)
+			for _, conn := range d.Connections {
+				s = append(s, "ip-v4:"+conn.V4, "ip-v6:"+conn.V6)
+			}
+		}
+		if p := a.Person; p != nil {
+			s = append(s, "person", p.Name)
+			s = append(s, p.Email...)
+		}
+		assets = append(assets, strings.Join(s, " "))
+	}
+	wantAssets := []string{
+		"ocil-asset-1 computing-device web1",
+		"ocil-asset-2 computing-device web2",
+		"ocil-asset-3 person alice alice@example.org",
+		"ocil-asset-4 computing-device  ip-v4:192.0.2.1 ip-v6: ip-v4: ip-v6:2001:db8::1",
+	}
+	if !reflect.DeepEqual(assets, wantAssets) {
+		t.Errorf("assets\n%s\nwant\n%s", strings.Join(assets, "\n"), strings.Join(wantAssets, "\n"))
+	}
+
+	if len(c.Requests) != 1 || c.Requests[0].ID != "ocil-request-1" {
+		t.Fatalf("report requests %+v", c.Requests)
+	}
+	if got := c.Requests[0].OCIL.Results.Answers(); len(got) != 0 {
+		t.Errorf("report request has answers %v", got)
+	}
+	if _, err := NewIndex(&c.Requests[0].OCIL); err != nil {
+		t.Errorf("report request: %v", err)
+	}
+	if len(c.Reports) != len(results) {
+		t.Fatalf("%d reports, want %d", len(c.Reports), len(results))
+	}
+	for i, r := range c.Reports {
+		if want := "ocil-report-" + fmt.Sprint(i+1); r.ID != want {
+			t.Errorf("report %d: id %s, want %s", i, r.ID, want)
+		}
+		var targets []string
+		for _, tg := range r.OCIL.Results.Targets.All() {
+			targets = append(targets, tg.TargetName())
+		}
+		var want []string
+		for _, tg := range results[i].Results.Targets.All() {
+			want = append(want, tg.TargetName())
+		}
+		if !reflect.DeepEqual(targets, want) {
+			t.Errorf("report %s: targets %q, want %q", r.ID, targets, want)
+		}
+		if got, want := r.OCIL.Results.Answers(), results[i].Results.Answers(); !reflect.DeepEqual(got, want) {
+			t.Errorf("report %s: answers %v, want %v", r.ID, got, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package postal
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+)
+
+// An Assessment pairs a target with the answers collected for it.
+// When Platforms is set, questionnaires that do not apply to those
+// CPE names are NOT_APPLICABLE.
+type Assessment struct {
+	Target    Target
+	Answers   AnswerSource
+	Platforms []string
+}
+
+// An AssessmentResult is the outcome of evaluating one
+// Assessment. Results is nil when Err is set.
+type AssessmentResult struct {
+	Target  Target
+	Results *ResultsType
+	Err     error
+}
+
+// Assess evaluates the document once for each assessment, running
+// up to workers evaluations concurrently. The results are in the
+// order of as, and each lists its assessment's target. The
+// AnswerSource of every assessment must be distinct.
+func (x *Index) Assess(as []Assessment, vars VariableSource, workers int) []AssessmentResult {
+	if workers < 1 {
+		workers = 1
+	}
+	out := make([]AssessmentResult, len(as))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				a := as[i]
+				r, err := x.evaluate(a.Answers, vars, a.Platforms)
+				if err == nil {
+					r.Targets.Add(a.Target)
+				}
+				out[i] = AssessmentResult{Target: a.Target, Results: r, Err: err}
+			}
+		}()
+	}
+	for i := range as {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+// summaryResults are the columns of the summary, in table order.
+var summaryResults = []ResultType{
+	ResultPass, ResultFail, ResultError, ResultUnknown, ResultNotTested, ResultNotApplicable,
+}
+
+// WriteSummary writes a combined plain-text report of the
+// assessments: per target, the number of top-level questionnaires
+// with each result, followed by per questionnaire the number of
+// targets with each result.
+func WriteSummary(w io.Writer, x *Index, rs []AssessmentResult) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	header := func(first string) {
+		fmt.Fprint(tw, first)
+		for _, r := range summaryResults {
+			fmt.Fprintf(tw, "\t%s", r)
+		}
+		fmt.Fprintln(tw)
+	}
+	row := func(name string, n map[ResultType]int) {
+		fmt.Fprint(tw, name)
+		for _, r := range summaryResults {
+			fmt.Fprintf(tw, "\t%d", n[r])
+		}
+		fmt.Fprintln(tw)
+	}
+
+	byQuestionnaire := make(map[QuestionnaireIDPattern]map[ResultType]int)
+	header("TARGET")
+	for _, a := range rs {
+		if a.Err != nil {
+			fmt.Fprintf(tw, "%s\terror: %v\n", a.Target.TargetName(), a.Err)
+			continue
+		}
+		n := make(map[ResultType]int)
+		for _, q := range a.Results.Questionnaire_results.Questionnaire_result {
+			if qt := x.Questionnaires[q.Questionnaire_ref]; qt == nil || qt.Child_only {
+				continue
+			}
+			n[q.Result]++
+			if byQuestionnaire[q.Questionnaire_ref] == nil {
+				byQuestionnaire[q.Questionnaire_ref] = make(map[ResultType]int)
+			}
+			byQuestionnaire[q.Questionnaire_ref][q.Result]++
+		}
+		row(a.Target.TargetName(), n)
+	}
+	fmt.Fprintln(tw)
+	header("QUESTIONNAIRE")
+	for _, q := range x.Doc.Questionnaires.Questionnaire {
+		if q.Child_only {
+			continue
+		}
+		row(string(q.Id), byQuestionnaire[q.Id])
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,368 @@
+// Package builder constructs OCIL documents without writing IDs by
+// hand. A Document allocates a unique, pattern-valid ID to every
+// item it creates, and items refer to each other by Go value:
+//
+//	d := builder.New("org.example", "Password checks")
+//	q := d.BooleanQuestion("Is a password policy configured?")
+//	d.Questionnaire("Password policy").Action(
+//		q.Test().WhenTrue(builder.Result(ocil.ResultPass)).WhenFalse(builder.Result(ocil.ResultFail)))
+//	doc, err := d.Build()
+package builder
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+var namespaceRE = regexp.MustCompile(`^[A-Za-z0-9_\-.]+$`)
+
+// A Document collects the items of an OCIL document under
+// construction. Mistakes such as referring to an item of another
+// Document are recorded and reported by Build.
+type Document struct {
+	ns    string
+	doc   ocil.OCILType
+	next  map[string]int
+	err   error
+	items []item
+}
+
+// An item is added to the document by Build, in creation order:
+// questions and test actions of different kinds stay interleaved as
+// created, so that renumbering the document keeps that order.
+type item interface {
+	build(doc *ocil.OCILType)
+}
+
+// New starts a document whose IDs are in namespace ns, such as
+// "org.example", which may contain letters, digits, '_', '-' and
+// '.'.
+func New(ns, title string) *Document {
+	d := &Document{ns: ns, next: make(map[string]int)}
+	if !namespaceRE.MatchString(ns) {
+		d.fail(fmt.Errorf("invalid ID namespace %q", ns))
+	}
+	d.doc.Generator = ocil.GeneratorType{
+		Product_name:   "goscap builder",
+		Schema_version: 2.0,
+		Timestamp:      time.Now().UTC().Truncate(time.Second),
+	}
+	d.doc.Document.Title = title
+	return d
+}
+
+// id allocates the next ID of the given kind.
+func (d *Document) id(kind string) string {
+	d.next[kind]++
+	return fmt.Sprintf("ocil:%s:%s:%d", d.ns, kind, d.next[kind])
+}
+
+func (d *Document) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// own records an error unless the item belongs to d.
+func (d *Document) own(owner *Document, what string) {
+	if owner != d {
+		d.fail(fmt.Errorf("%s belongs to another document", what))
+	}
+}
+
+// Description adds a paragraph to the document description.
+func (d *Document) Description(s string) *Document {
+	d.doc.Document.Description = append(d.doc.Document.Description, s)
+	return d
+}
+
+// Notice adds a notice, such as a copyright statement.
+func (d *Document) Notice(s string) *Document {
+	d.doc.Document.Notice = append(d.doc.Document.Notice, s)
+	return d
+}
+
+// Generator sets the product recorded as having generated the
+// document.
+func (d *Document) Generator(name, version string) *Document {
+	d.doc.Generator.Product_name = name
+	d.doc.Generator.Product_version = version
+	return d
+}
+
+// Build returns the document, or the first mistake made while
+// constructing it. The document is checked for duplicate IDs and
+// for questionnaires without actions.
+func (d *Document) Build() (*ocil.OCILType, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	doc := d.doc
+	for _, it := range d.items {
+		it.build(&doc)
+	}
+	for _, q := range doc.Questionnaires.Questionnaire {
+		if len(q.Actions.Test_action_ref) == 0 {
+			return nil, fmt.Errorf("questionnaire %s has no actions", q.Id)
+		}
+	}
+	if _, err := ocil.NewIndex(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// An Action is a test action or questionnaire that questionnaires
+// and handlers can refer to.
+type Action interface {
+	ActionID() ocil.TestActionRefValuePattern
+	owner() *Document
+}
+
+// A Questionnaire is a questionnaire under construction.
+type Questionnaire struct {
+	d *Document
+	q ocil.QuestionnaireType
+}
+
+// Questionnaire adds a questionnaire with the given title.
+func (d *Document) Questionnaire(title string) *Questionnaire {
+	q := &Questionnaire{d: d}
+	q.q.Id = ocil.QuestionnaireIDPattern(d.id("questionnaire"))
+	q.q.Title.Value = title
+	d.items = append(d.items, q)
+	return q
+}
+
+func (q *Questionnaire) build(doc *ocil.OCILType) {
+	doc.Questionnaires.Questionnaire = append(doc.Questionnaires.Questionnaire, q.q)
+}
+
+func (q *Questionnaire) owner() *Document { return q.d }
+
+// ID returns the questionnaire's ID.
+func (q *Questionnaire) ID() ocil.QuestionnaireIDPattern { return q.q.Id }
+
+// ActionID returns the questionnaire's ID as a test action
+// reference.
+func (q *Questionnaire) ActionID() ocil.TestActionRefValuePattern {
+	return ocil.TestActionRefValuePattern(q.q.Id)
+}
+
+// Description sets the questionnaire description.
+func (q *Questionnaire) Description(s string) *Questionnaire {
+	q.q.Description.Value = s
+	return q
+}
+
+// Reference adds a reference, such as a CCE or a control, with the
+// namespace href of its identifier.
+func (q *Questionnaire) Reference(value, href string) *Questionnaire {
+	q.q.References.Reference = append(q.q.References.Reference, ocil.ReferenceType{Value: value, Href: href})
+	return q
+}
+
+// Note adds a note.
+func (q *Questionnaire) Note(s string) *Questionnaire {
+	q.q.Notes = append(q.q.Notes, s)
+	return q
+}
+
+// ChildOnly marks the questionnaire as evaluated only when another
+// refers to it.
+func (q *Questionnaire) ChildOnly() *Questionnaire {
+	q.q.Child_only = true
+	return q
+}
+
+// Or combines the results of the actions with OR instead of AND.
+func (q *Questionnaire) Or() *Questionnaire {
+	q.q.Actions.Operation = ocil.OperatorOr
+	return q
+}
+
+// Negate negates the combined result of the actions.
+func (q *Questionnaire) Negate() *Questionnaire {
+	q.q.Actions.Negate = true
+	return q
+}
+
+// Action adds actions to evaluate.
+func (q *Questionnaire) Action(as ...Action) *Questionnaire {
+	for _, a := range as {
+		q.d.own(a.owner(), "action "+string(a.ActionID()))
+		q.q.Actions.Test_action_ref = append(q.q.Actions.Test_action_ref, ocil.TestActionRefType{TestActionRefValuePattern: a.ActionID()})
+	}
+	return q
+}
+
+// NotAction adds an action whose result is negated.
+func (q *Questionnaire) NotAction(a Action) *Questionnaire {
+	q.d.own(a.owner(), "action "+string(a.ActionID()))
+	q.q.Actions.Test_action_ref = append(q.q.Actions.Test_action_ref, ocil.TestActionRefType{TestActionRefValuePattern: a.ActionID(), Negate: true})
+	return q
+}
+
+// An Outcome is what a handler of a test action leads to: a result
+// or a further action, optionally requiring artifacts.
+type Outcome struct {
+	cond      ocil.TestActionConditionType
+	action    Action
+	artifacts []*Artifact
+}
+
+// Result is the outcome giving r.
+func Result(r ocil.ResultType) Outcome {
+	return Outcome{cond: ocil.TestActionConditionType{Result: r}}
+}
+
+// Then is the outcome continuing with a.
+func Then(a Action) Outcome {
+	return Outcome{action: a, cond: ocil.TestActionConditionType{
+		Test_action_ref: ocil.TestActionRefType{TestActionRefValuePattern: a.ActionID()},
+	}}
+}
+
+// ThenNot is the outcome continuing with a, negating its result.
+func ThenNot(a Action) Outcome {
+	o := Then(a)
+	o.cond.Test_action_ref.Negate = true
+	return o
+}
+
+// Artifact returns the outcome also requesting artifact a.
+func (o Outcome) Artifact(a *Artifact, required bool) Outcome {
+	refs := append([]ocil.ArtifactRefType(nil), o.cond.Artifact_refs.Artifact_ref...)
+	o.cond.Artifact_refs.Artifact_ref = append(refs, ocil.ArtifactRefType{Idref: a.a.Id, Required: required})
+	o.artifacts = append(append([]*Artifact(nil), o.artifacts...), a)
+	return o
+}
+
+// condition returns the handler for o, recording an error unless
+// the items it refers to belong to d.
+func (o Outcome) condition(d *Document) ocil.TestActionConditionType {
+	if o.action != nil {
+		d.own(o.action.owner(), "action "+string(o.action.ActionID()))
+	}
+	for _, a := range o.artifacts {
+		d.own(a.d, "artifact "+string(a.a.Id))
+	}
+	return o.cond
+}
+
+// A Variable is a constant, external or local variable.
+type Variable struct {
+	d  *Document
+	id ocil.VariableIDPattern
+}
+
+// ID returns the variable's ID.
+func (v *Variable) ID() ocil.VariableIDPattern { return v.id }
+
+type variableItem struct {
+	constant *ocil.ConstantVariableType
+	external *ocil.ExternalVariableType
+	local    *ocil.LocalVariableType
+}
+
+func (v variableItem) build(doc *ocil.OCILType) {
+	switch {
+	case v.constant != nil:
+		doc.Variables.Constant_variable = append(doc.Variables.Constant_variable, *v.constant)
+	case v.external != nil:
+		doc.Variables.External_variable = append(doc.Variables.External_variable, *v.external)
+	case v.local != nil:
+		doc.Variables.Local_variable = append(doc.Variables.Local_variable, *v.local)
+	}
+}
+
+// Constant adds a constant variable.
+func (d *Document) Constant(value string, datatype ocil.VariableDataType, description string) *Variable {
+	v := &Variable{d: d, id: ocil.VariableIDPattern(d.id("variable"))}
+	d.items = append(d.items, variableItem{constant: &ocil.ConstantVariableType{
+		Id: v.id, Value: value, Datatype: datatype, Description: ocil.TextType{Value: description},
+	}})
+	return v
+}
+
+// External adds a variable whose value is supplied from outside the
+// document.
+func (d *Document) External(datatype ocil.VariableDataType, description string) *Variable {
+	v := &Variable{d: d, id: ocil.VariableIDPattern(d.id("variable"))}
+	d.items = append(d.items, variableItem{external: &ocil.ExternalVariableType{
+		Id: v.id, Datatype: datatype, Description: ocil.TextType{Value: description},
+	}})
+	return v
+}
+
+// Local adds a variable holding the answer to q.
+func (d *Document) Local(q Question, datatype ocil.VariableDataType, description string) *Variable {
+	d.own(q.owner(), "question "+string(q.QuestionID()))
+	v := &Variable{d: d, id: ocil.VariableIDPattern(d.id("variable"))}
+	d.items = append(d.items, variableItem{local: &ocil.LocalVariableType{
+		Id: v.id, Question_ref: q.QuestionID(), Datatype: datatype, Description: ocil.TextType{Value: description},
+	}})
+	return v
+}
+
+// An Artifact is evidence a handler can ask for.
+type Artifact struct {
+	d *Document
+	a ocil.ArtifactType
+}
+
+// Artifact adds an artifact definition.
+func (d *Document) Artifact(title, description string, persistent bool) *Artifact {
+	a := &Artifact{d: d, a: ocil.ArtifactType{
+		Id:          ocil.ArtifactIDPattern(d.id("artifact")),
+		Title:       ocil.TextType{Value: title},
+		Description: ocil.TextType{Value: description},
+		Persistent:  persistent,
+	}}
+	d.items = append(d.items, a)
+	return a
+}
+
+func (a *Artifact) build(doc *ocil.OCILType) {
+	doc.Artifacts.Artifact = append(doc.Artifacts.Artifact, a.a)
+}
+
+// ID returns the artifact's ID.
+func (a *Artifact) ID() ocil.ArtifactIDPattern { return a.a.Id }
+
+// A Step is an instruction step, possibly with sub-steps.
+type Step struct {
+	s ocil.StepType
+}
+
+// NewStep returns a step with the given description and sub-steps.
+func NewStep(description string, steps ...*Step) *Step {
+	s := &Step{s: ocil.StepType{Description: ocil.TextType{Value: description}}}
+	for _, sub := range steps {
+		s.s.Step = append(s.s.Step, sub.s)
+	}
+	return s
+}
+
+// Required marks the step as required.
+func (s *Step) Required() *Step {
+	s.s.Is_required = true
+	return s
+}
+
+// Reference adds a reference to the step.
+func (s *Step) Reference(value, href string) *Step {
+	s.s.Reference = append(s.s.Reference, ocil.ReferenceType{Value: value, Href: href})
+	return s
+}
+
+func instructions(title string, steps []*Step) ocil.InstructionsType {
+	in := ocil.InstructionsType{Title: ocil.TextType{Value: title}}
+	for _, s := range steps {
+		in.Step = append(in.Step, s.s)
+	}
+	return in
+}
@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// interleaved builds a document whose questions and test actions of
+// different kinds are created alternately.
+func interleaved(t *testing.T) *ocil.OCILType {
+	t.Helper()
+	d := New("org.example", "Interleaved")
+	b1 := d.BooleanQuestion("First?")
+	s := d.StringQuestion("Second?")
+	b2 := d.BooleanQuestion("Third?")
+	d.Questionnaire("Checks").Action(
+		b1.Test().WhenTrue(Result(ocil.ResultPass)).WhenFalse(Result(ocil.ResultFail)),
+		s.Test().WhenPattern(Result(ocil.ResultPass), ".*"),
+		b2.Test().WhenTrue(Result(ocil.ResultPass)).WhenFalse(Result(ocil.ResultFail)))
+	doc, err := d.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestBuildOrder(t *testing.T) {
+	doc := interleaved(t)
+	var questions, actions []string
+	for _, q := range doc.Questions.All() {
+		questions = append(questions, string(q.QuestionID()))
+	}
+	for _, ta := range doc.Test_actions.All() {
+		actions = append(actions, string(ta.QuestionRef()))
+	}
+	want := []string{"ocil:org.example:question:1", "ocil:org.example:question:2", "ocil:org.example:question:3"}
+	if !reflect.DeepEqual(questions, want) {
+		t.Errorf("questions %v, want %v", questions, want)
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("test actions refer to %v, want %v", actions, want)
+	}
+
+	var buf bytes.Buffer
+	if err := ocil.WriteDocument(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "<schema_version>2.0</schema_version>") {
+		t.Errorf("schema version not written as 2.0:\n%s", out)
+	}
+	first := strings.Index(out, `<boolean_question id="ocil:org.example:question:1"`)
+	second := strings.Index(out, `<string_question id="ocil:org.example:question:2"`)
+	third := strings.Index(out, `<boolean_question id="ocil:org.example:question:3"`)
+	if first < 0 || !(first < second && second < third) {
+		t.Errorf("questions not written in creation order:\n%s", out)
+	}
+
+	// Renumbering sees the creation order as the document order, so
+	// the IDs stay as they are.
+	m, err := ocil.Renumber(doc, "org.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for old, to := range m {
+		if old != to {
+			t.Errorf("renumber moved %s to %s", old, to)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package builder
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// CSV columns read by ImportCSV. Column names in the header row are
+// matched ignoring case, spaces, '_' and '-'.
+const (
+	ColumnQuestionnaire = "questionnaire" // questionnaire title; rows with the same title share a questionnaire
+	ColumnDescription   = "description"   // questionnaire description, taken from its first row that has one
+	ColumnQuestion      = "question"      // question text
+	ColumnType          = "type"          // boolean (the default), choice, numeric or string
+	ColumnChoices       = "choices"       // the choices of a choice question
+	ColumnPass          = "pass"          // the answer that passes; any other answer fails
+	ColumnReferences    = "references"    // questionnaire references, such as CCE or control identifiers
+	ColumnInstructions  = "instructions"  // instruction steps of the question
+	ColumnNotes         = "notes"         // notes on the question
+)
+
+var csvColumns = []string{
+	ColumnQuestionnaire, ColumnDescription, ColumnQuestion, ColumnType, ColumnChoices,
+	ColumnPass, ColumnReferences, ColumnInstructions, ColumnNotes,
+}
+
+var (
+	columnNameRE = regexp.MustCompile(`[\s_\-]+`)
+	listSepRE    = regexp.MustCompile(`[|\n]`)
+)
+
+// A RowError is a mistake in one row of a CSV file. Rows are
+// numbered from 1, the header row, as in a spreadsheet.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+// RowErrors are the mistakes found in a CSV file, in row order.
+type RowErrors []*RowError
+
+func (es RowErrors) Error() string {
+	s := make([]string, len(es))
+	for i, e := range es {
+		s[i] = e.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// ImportCSV converts a CSV file with one question per row into an
+// OCIL document with IDs in namespace ns. The first row names the
+// columns; the Column constants list those recognized, and the
+// questionnaire and question columns are required.
+//
+// List cells (choices, pass for choice questions, references and
+// instructions) separate their items with '|' or line breaks. The
+// pass cell holds, by question type:
+//
+//	boolean  yes, no, true or false
+//	choice   the passing choices
+//	numeric  a number, or an inclusive range such as 8..64, 8.. or ..90
+//	string   a regular expression the answer must match
+//
+// Each question gets a test action giving PASS for the passing
+// answer and FAIL otherwise, and each questionnaire requires all of
+// its questions to pass. All rows are checked; the error is
+// RowErrors when any row is wrong.
+func ImportCSV(r io.Reader, ns, title string, comma rune) (*ocil.OCILType, error) {
+	cr := csv.NewReader(r)
+	if comma != 0 {
+		cr.Comma = comma
+	}
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var errs RowErrors
+	cols := make(map[string]int)
+	for i, h := range header {
+		name := strings.ToLower(columnNameRE.ReplaceAllString(strings.TrimSpace(h), ""))
+		if !containsString(csvColumns, name) {
+			errs = append(errs, &RowError{1, fmt.Errorf("unknown column %q", h)})
+			continue
+		}
+		if _, ok := cols[name]; ok {
+			errs = append(errs, &RowError{1, fmt.Errorf("duplicate column %q", h)})
+		}
+		cols[name] = i
+	}
+	for _, c := range []string{ColumnQuestionnaire, ColumnQuestion} {
+		if _, ok := cols[c]; !ok {
+			errs = append(errs, &RowError{1, fmt.Errorf("missing column %q", c)})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	d := New(ns, title).Generator("ocil3 import csv", "")
+	questionnaires := make(map[string]*Questionnaire)
+	for row := 2; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return nil, err
+			}
+			errs = append(errs, &RowError{row, err})
+			continue
+		}
+		cell := func(c string) string {
+			if i, ok := cols[c]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		if strings.Join(rec, "") == "" {
+			continue
+		}
+		qtitle := cell(ColumnQuestionnaire)
+		if qtitle == "" {
+			errs = append(errs, &RowError{row, fmt.Errorf("no questionnaire")})
+			continue
+		}
+		test, err := csvQuestion(d, cell)
+		if err != nil {
+			errs = append(errs, &RowError{row, err})
+			continue
+		}
+		q, ok := questionnaires[qtitle]
+		if !ok {
+			q = d.Questionnaire(qtitle)
+			questionnaires[qtitle] = q
+		}
+		if s := cell(ColumnDescription); s != "" && q.q.Description.Value == "" {
+			q.Description(s)
+		}
+		for _, ref := range csvList(cell(ColumnReferences)) {
+			if !q.hasReference(ref) {
+				q.Reference(ref, "")
+			}
+		}
+		q.Action(test)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if len(questionnaires) == 0 {
+		return nil, fmt.Errorf("no questions")
+	}
+	return d.Build()
+}
+
+func containsString(list []string, s string) bool {
+	for _, it := range list {
+		if it == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Questionnaire) hasReference(value string) bool {
+	for _, r := range q.q.References.Reference {
+		if r.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// csvList splits a list cell into its non-empty items.
+func csvList(s string) []string {
+	var items []string
+	for _, it := range listSepRE.Split(s, -1) {
+		if it = strings.TrimSpace(it); it != "" {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// csvQuestion adds the question of a row and its test action.
+func csvQuestion(d *Document, cell func(string) string) (Action, error) {
+	text := cell(ColumnQuestion)
+	if text == "" {
+		return nil, fmt.Errorf("no question text")
+	}
+	pass := cell(ColumnPass)
+	if pass == "" {
+		return nil, fmt.Errorf("no passing answer")
+	}
+	var steps []*Step
+	for _, s := range csvList(cell(ColumnInstructions)) {
+		steps = append(steps, NewStep(s))
+	}
+	notes := csvList(cell(ColumnNotes))
+	typ := strings.ToLower(cell(ColumnType))
+	if typ == "" {
+		typ = "boolean"
+	}
+	if typ != "choice" && cell(ColumnChoices) != "" {
+		return nil, fmt.Errorf("choices given for a %s question", typ)
+	}
+	switch typ {
+	case "boolean":
+		var answer bool
+		switch strings.ToLower(pass) {
+		case "yes", "true":
+			answer = true
+		case "no", "false":
+		default:
+			return nil, fmt.Errorf("passing answer %q is not yes, no, true or false", pass)
+		}
+		q := d.BooleanQuestion(text)
+		if p := strings.ToLower(pass); p == "true" || p == "false" {
+			q.TrueFalse()
+		}
+		if len(steps) > 0 {
+			q.Instructions("Instructions", steps...)
+		}
+		for _, n := range notes {
+			q.Note(n)
+		}
+		t := q.Test()
+		if answer {
+			return t.WhenTrue(Result(ocil.ResultPass)).WhenFalse(Result(ocil.ResultFail)), nil
+		}
+		return t.WhenTrue(Result(ocil.ResultFail)).WhenFalse(Result(ocil.ResultPass)), nil
+
+	case "choice":
+		choices := csvList(cell(ColumnChoices))
+		if len(choices) == 0 {
+			return nil, fmt.Errorf("choice question without choices")
+		}
+		passing := make(map[string]bool)
+		for _, p := range csvList(pass) {
+			if !containsString(choices, p) {
+				return nil, fmt.Errorf("passing answer %q is not a choice", p)
+			}
+			passing[p] = true
+		}
+		q := d.ChoiceQuestion(text)
+		var pc, fc []*Choice
+		for _, c := range choices {
+			if passing[c] {
+				pc = append(pc, q.Choice(c))
+			} else {
+				fc = append(fc, q.Choice(c))
+			}
+		}
+		if len(steps) > 0 {
+			q.Instructions("Instructions", steps...)
+		}
+		for _, n := range notes {
+			q.Note(n)
+		}
+		t := q.Test().WhenChoice(Result(ocil.ResultPass), pc...)
+		if len(fc) > 0 {
+			t.WhenChoice(Result(ocil.ResultFail), fc...)
+		}
+		return t, nil
+
+	case "numeric":
+		min, max, err := csvRange(pass)
+		if err != nil {
+			return nil, err
+		}
+		q := d.NumericQuestion(text)
+		if len(steps) > 0 {
+			q.Instructions("Instructions", steps...)
+		}
+		for _, n := range notes {
+			q.Note(n)
+		}
+		t := q.Test()
+		if min != nil && max != nil && min.v.Value == max.v.Value {
+			t.WhenEquals(Result(ocil.ResultPass), min.v.Value)
+		} else {
+			t.WhenRange(Result(ocil.ResultPass), min, max)
+		}
+		if min != nil {
+			t.WhenRange(Result(ocil.ResultFail), nil, Exclusive(min.v.Value))
+		}
+		if max != nil {
+			t.WhenRange(Result(ocil.ResultFail), Exclusive(max.v.Value), nil)
+		}
+		return t, nil
+
+	case "string":
+		if _, err := regexp.Compile(pass); err != nil {
+			return nil, fmt.Errorf("passing answer: %v", err)
+		}
+		q := d.StringQuestion(text)
+		if len(steps) > 0 {
+			q.Instructions("Instructions", steps...)
+		}
+		for _, n := range notes {
+			q.Note(n)
+		}
+		return q.Test().WhenPattern(Result(ocil.ResultPass), pass).WhenPattern(Result(ocil.ResultFail), "^"), nil
+	}
+	return nil, fmt.Errorf("unknown question type %q", typ)
+}
+
+// csvRange parses the passing answer of a numeric question: a
+// number, or a range with either end omitted. Both ends are
+// inclusive; a nil bound is open.
+func csvRange(s string) (min, max *Bound, err error) {
+	lo, hi := s, s
+	if i := strings.Index(s, ".."); i >= 0 {
+		lo, hi = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+2:])
+		if lo == "" && hi == "" {
+			return nil, nil, fmt.Errorf("passing range %q has no bounds", s)
+		}
+	}
+	bound := func(s string) (*Bound, error) {
+		if s == "" {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("passing answer %q is not a number or range", s)
+		}
+		return Inclusive(v), nil
+	}
+	if min, err = bound(lo); err != nil {
+		return nil, nil, err
+	}
+	if max, err = bound(hi); err != nil {
+		return nil, nil, err
+	}
+	if min != nil && max != nil && min.v.Value > max.v.Value {
+		return nil, nil, fmt.Errorf("passing range %q is empty", s)
+	}
+	return min, max, nil
+}
@@ -0,0 +1,144 @@
+package builder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+const questionsCSV = `Questionnaire,Question,Type,Choices,Pass,References,Instructions
+Accounts,Is a password policy configured?,,,yes,CCE-1|AC-7,Open secpol.msc|Expand Account Policies
+Accounts,Minimum password length?,numeric,,8..64,CCE-1,
+Accounts,How are passwords stored?,choice,Hashed|Encrypted|Plain text,Hashed|Encrypted,,
+Network,Who owns the firewall?,string,,^sec-,,
+Network,Is telnet disabled?,boolean,,true,,
+`
+
+func TestImportCSV(t *testing.T) {
+	doc, err := ImportCSV(strings.NewReader(questionsCSV), "org.example", "Imported", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := ocil.NewIndex(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(doc.Questionnaires.Questionnaire); n != 2 {
+		t.Fatalf("%d questionnaires, want 2", n)
+	}
+	refs := doc.Questionnaires.Questionnaire[0].References.Reference
+	if len(refs) != 2 || refs[0].Value != "CCE-1" || refs[1].Value != "AC-7" {
+		t.Errorf("references %v, want CCE-1 and AC-7 once each", refs)
+	}
+	q1 := x.Questions["ocil:org.example:question:1"].(*ocil.BooleanQuestionType)
+	if n := len(q1.Instructions.Step); n != 2 {
+		t.Errorf("%d instruction steps, want 2", n)
+	}
+	if q5 := x.Questions["ocil:org.example:question:5"].(*ocil.BooleanQuestionType); q5.Model != ocil.ModelTrueFalse {
+		t.Errorf("question 5 model %s, want %s", q5.Model, ocil.ModelTrueFalse)
+	}
+
+	yes := ocil.Answer{Response: ocil.ResponseAnswered, Boolean: true}
+	no := ocil.Answer{Response: ocil.ResponseAnswered}
+	num := func(n float64) ocil.Answer { return ocil.Answer{Response: ocil.ResponseAnswered, Numeric: n} }
+	str := func(s string) ocil.Answer { return ocil.Answer{Response: ocil.ResponseAnswered, String: s} }
+	choices, err := x.Choices(x.Questions["ocil:org.example:question:3"].(*ocil.ChoiceQuestionType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	choice := func(text string) ocil.Answer {
+		for _, c := range choices {
+			if c.Value == text {
+				return ocil.Answer{Response: ocil.ResponseAnswered, Choice: c.Id}
+			}
+		}
+		t.Fatalf("no choice %q", text)
+		return ocil.Answer{}
+	}
+	tests := []struct {
+		name          string
+		answers       ocil.Answers
+		questionnaire ocil.QuestionnaireIDPattern
+		want          ocil.ResultType
+	}{
+		{"all pass", ocil.Answers{"ocil:org.example:question:1": yes, "ocil:org.example:question:2": num(12), "ocil:org.example:question:3": choice("Hashed")}, "ocil:org.example:questionnaire:1", ocil.ResultPass},
+		{"lower bound inclusive", ocil.Answers{"ocil:org.example:question:1": yes, "ocil:org.example:question:2": num(8), "ocil:org.example:question:3": choice("Encrypted")}, "ocil:org.example:questionnaire:1", ocil.ResultPass},
+		{"below range", ocil.Answers{"ocil:org.example:question:1": yes, "ocil:org.example:question:2": num(7), "ocil:org.example:question:3": choice("Hashed")}, "ocil:org.example:questionnaire:1", ocil.ResultFail},
+		{"above range", ocil.Answers{"ocil:org.example:question:1": yes, "ocil:org.example:question:2": num(65), "ocil:org.example:question:3": choice("Hashed")}, "ocil:org.example:questionnaire:1", ocil.ResultFail},
+		{"failing choice", ocil.Answers{"ocil:org.example:question:1": yes, "ocil:org.example:question:2": num(8), "ocil:org.example:question:3": choice("Plain text")}, "ocil:org.example:questionnaire:1", ocil.ResultFail},
+		{"boolean fails", ocil.Answers{"ocil:org.example:question:1": no, "ocil:org.example:question:2": num(8), "ocil:org.example:question:3": choice("Hashed")}, "ocil:org.example:questionnaire:1", ocil.ResultFail},
+		{"pattern matches", ocil.Answers{"ocil:org.example:question:4": str("sec-ops"), "ocil:org.example:question:5": yes}, "ocil:org.example:questionnaire:2", ocil.ResultPass},
+		{"pattern fails", ocil.Answers{"ocil:org.example:question:4": str("ops"), "ocil:org.example:question:5": yes}, "ocil:org.example:questionnaire:2", ocil.ResultFail},
+	}
+	for _, tt := range tests {
+		got, err := ocil.NewEvaluator(x, tt.answers, nil).Questionnaire(tt.questionnaire)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		// rows are the rows reported, or nil for an error that is
+		// not RowErrors.
+		rows []int
+	}{
+		{"empty", ``, nil},
+		{"unknown column", "Questionnaire,Question,Pass,Colour\n", []int{1}},
+		{"missing column", "Questionnaire,Pass\n", []int{1}},
+		{"duplicate column", "Questionnaire,Question,question,Pass\n", []int{1}},
+		{"no questions", "Questionnaire,Question,Pass\n", nil},
+		{
+			name: "bad rows",
+			csv: "Questionnaire,Question,Type,Choices,Pass\n" +
+				"A,Q1,,,maybe\n" +
+				",Q2,,,yes\n" +
+				"A,Q3,choice,X|Y,Z\n" +
+				"A,Q4,numeric,,..\n" +
+				"A,Q5,string,,(\n" +
+				"A,Q6,date,,x\n" +
+				"A,Q7,boolean,X|Y,yes\n" +
+				"A,,,,yes\n" +
+				"A,Q9,,,\n" +
+				"A,Q10,,,yes\n",
+			rows: []int{2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+	}
+	for _, tt := range tests {
+		_, err := ImportCSV(strings.NewReader(tt.csv), "org.example", "T", 0)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+			continue
+		}
+		var errs RowErrors
+		if !errors.As(err, &errs) {
+			if tt.rows != nil {
+				t.Errorf("%s: error %v, want row errors %v", tt.name, err, tt.rows)
+			}
+			continue
+		}
+		var rows []int
+		for _, e := range errs {
+			rows = append(rows, e.Row)
+		}
+		if len(rows) != len(tt.rows) {
+			t.Errorf("%s: rows %v, want %v (%v)", tt.name, rows, tt.rows, err)
+			continue
+		}
+		for i := range rows {
+			if rows[i] != tt.rows[i] {
+				t.Errorf("%s: rows %v, want %v (%v)", tt.name, rows, tt.rows, err)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package builder
+
+import (
+	"fmt"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// A Question is a question of any kind.
+type Question interface {
+	QuestionID() ocil.QuestionIDPattern
+	owner() *Document
+}
+
+// questionText builds question text from strings and the *Variable
+// values substituted between them.
+func (d *Document) questionText(parts []interface{}) ocil.QuestionTextType {
+	var t ocil.QuestionTextType
+	for _, p := range parts {
+		switch p := p.(type) {
+		case string:
+			t.Content = append(t.Content, ocil.QuestionTextPart{Text: p})
+		case *Variable:
+			d.own(p.d, "variable "+string(p.id))
+			t.Content = append(t.Content, ocil.QuestionTextPart{Sub: &ocil.SubstitutionTextType{Var_ref: p.id}})
+		default:
+			d.fail(fmt.Errorf("question text part of type %T", p))
+		}
+	}
+	return t
+}
+
+// A BooleanQuestion is a question answered yes or no, or true or
+// false.
+type BooleanQuestion struct {
+	d *Document
+	q ocil.BooleanQuestionType
+}
+
+// BooleanQuestion adds a yes/no question. The text is made of
+// strings and the *Variable values to substitute between them.
+func (d *Document) BooleanQuestion(text ...interface{}) *BooleanQuestion {
+	q := &BooleanQuestion{d: d, q: ocil.BooleanQuestionType{
+		Id:            ocil.QuestionIDPattern(d.id("question")),
+		Model:         ocil.ModelYesNo,
+		Question_text: []ocil.QuestionTextType{d.questionText(text)},
+	}}
+	d.items = append(d.items, q)
+	return q
+}
+
+func (q *BooleanQuestion) build(doc *ocil.OCILType) {
+	doc.Questions.Add(&q.q)
+}
+
+func (q *BooleanQuestion) owner() *Document { return q.d }
+
+// QuestionID returns the question's ID.
+func (q *BooleanQuestion) QuestionID() ocil.QuestionIDPattern { return q.q.Id }
+
+// TrueFalse asks for true or false instead of yes or no.
+func (q *BooleanQuestion) TrueFalse() *BooleanQuestion {
+	q.q.Model = ocil.ModelTrueFalse
+	return q
+}
+
+// Default sets the default answer.
+func (q *BooleanQuestion) Default(answer bool) *BooleanQuestion {
+	q.q.Default_answer = answer
+	return q
+}
+
+// Instructions sets the instructions for answering.
+func (q *BooleanQuestion) Instructions(title string, steps ...*Step) *BooleanQuestion {
+	q.q.Instructions = instructions(title, steps)
+	return q
+}
+
+// Note adds a note.
+func (q *BooleanQuestion) Note(s string) *BooleanQuestion {
+	q.q.Notes = append(q.q.Notes, s)
+	return q
+}
+
+// Test adds a test action evaluating the answer.
+func (q *BooleanQuestion) Test() *BooleanTest {
+	t := &BooleanTest{d: q.d, t: ocil.BooleanQuestionTestActionType{
+		Id:           ocil.QuestionTestActionIDPattern(q.d.id("testaction")),
+		Question_ref: q.q.Id,
+	}}
+	q.d.items = append(q.d.items, t)
+	return t
+}
+
+// A Choice is one answer to a choice question.
+type Choice struct {
+	d  *Document
+	id ocil.ChoiceIDPattern
+}
+
+// ID returns the choice's ID.
+func (c *Choice) ID() ocil.ChoiceIDPattern { return c.id }
+
+func (d *Document) choice(text string, v *Variable) (*Choice, ocil.ChoiceType) {
+	c := &Choice{d: d, id: ocil.ChoiceIDPattern(d.id("choice"))}
+	t := ocil.ChoiceType{Id: c.id, Value: text}
+	if v != nil {
+		d.own(v.d, "variable "+string(v.id))
+		t.Var_ref = v.id
+	}
+	return c, t
+}
+
+// A ChoiceGroup is a set of choices shared by choice questions.
+type ChoiceGroup struct {
+	d *Document
+	g ocil.ChoiceGroupType
+}
+
+// ChoiceGroup adds an empty choice group.
+func (d *Document) ChoiceGroup() *ChoiceGroup {
+	g := &ChoiceGroup{d: d, g: ocil.ChoiceGroupType{Id: ocil.ChoiceGroupIDPattern(d.id("choicegroup"))}}
+	d.items = append(d.items, g)
+	return g
+}
+
+func (g *ChoiceGroup) build(doc *ocil.OCILType) {
+	doc.Questions.Choice_group = append(doc.Questions.Choice_group, g.g)
+}
+
+// ID returns the choice group's ID.
+func (g *ChoiceGroup) ID() ocil.ChoiceGroupIDPattern { return g.g.Id }
+
+// Choice adds a choice with the given text.
+func (g *ChoiceGroup) Choice(text string) *Choice {
+	c, t := g.d.choice(text, nil)
+	g.g.Choice = append(g.g.Choice, t)
+	return c
+}
+
+// VarChoice adds a choice whose text is the value of v.
+func (g *ChoiceGroup) VarChoice(v *Variable) *Choice {
+	c, t := g.d.choice("", v)
+	g.g.Choice = append(g.g.Choice, t)
+	return c
+}
+
+// A ChoiceQuestion is a question answered by picking a choice.
+type ChoiceQuestion struct {
+	d *Document
+	q ocil.ChoiceQuestionType
+}
+
+// ChoiceQuestion adds a choice question. The text is made of
+// strings and the *Variable values to substitute between them.
+func (d *Document) ChoiceQuestion(text ...interface{}) *ChoiceQuestion {
+	q := &ChoiceQuestion{d: d, q: ocil.ChoiceQuestionType{
+		Id:            ocil.QuestionIDPattern(d.id("question")),
+		Question_text: []ocil.QuestionTextType{d.questionText(text)},
+	}}
+	d.items = append(d.items, q)
+	return q
+}
+
+func (q *ChoiceQuestion) build(doc *ocil.OCILType) {
+	doc.Questions.Add(&q.q)
+}
+
+func (q *ChoiceQuestion) owner() *Document { return q.d }
+
+// QuestionID returns the question's ID.
+func (q *ChoiceQuestion) QuestionID() ocil.QuestionIDPattern { return q.q.Id }
+
+// Choice adds a choice with the given text.
+func (q *ChoiceQuestion) Choice(text string) *Choice {
+	c, t := q.d.choice(text, nil)
+	q.q.Choices = append(q.q.Choices, ocil.ChoiceItem{Choice: &t})
+	return c
+}
+
+// VarChoice adds a choice whose text is the value of v.
+func (q *ChoiceQuestion) VarChoice(v *Variable) *Choice {
+	c, t := q.d.choice("", v)
+	q.q.Choices = append(q.q.Choices, ocil.ChoiceItem{Choice: &t})
+	return c
+}
+
+// Group adds the choices of shared choice groups.
+func (q *ChoiceQuestion) Group(gs ...*ChoiceGroup) *ChoiceQuestion {
+	for _, g := range gs {
+		q.d.own(g.d, "choice group "+string(g.g.Id))
+		q.q.Choices = append(q.q.Choices, ocil.ChoiceItem{Choice_group_ref: g.g.Id})
+	}
+	return q
+}
+
+// Default sets the default answer.
+func (q *ChoiceQuestion) Default(c *Choice) *ChoiceQuestion {
+	q.d.own(c.d, "choice "+string(c.id))
+	q.q.Default_answer_ref = c.id
+	return q
+}
+
+// Instructions sets the instructions for answering.
+func (q *ChoiceQuestion) Instructions(title string, steps ...*Step) *ChoiceQuestion {
+	q.q.Instructions = instructions(title, steps)
+	return q
+}
+
+// Note adds a note.
+func (q *ChoiceQuestion) Note(s string) *ChoiceQuestion {
+	q.q.Notes = append(q.q.Notes, s)
+	return q
+}
+
+// Test adds a test action evaluating the answer.
+func (q *ChoiceQuestion) Test() *ChoiceTest {
+	t := &ChoiceTest{d: q.d, t: ocil.ChoiceQuestionTestActionType{
+		Id:           ocil.QuestionTestActionIDPattern(q.d.id("testaction")),
+		Question_ref: q.q.Id,
+	}}
+	q.d.items = append(q.d.items, t)
+	return t
+}
+
+// A NumericQuestion is a question answered with a number.
+type NumericQuestion struct {
+	d *Document
+	q ocil.NumericQuestionType
+}
+
+// NumericQuestion adds a numeric question. The text is made of
+// strings and the *Variable values to substitute between them.
+func (d *Document) NumericQuestion(text ...interface{}) *NumericQuestion {
+	q := &NumericQuestion{d: d, q: ocil.NumericQuestionType{
+		Id:            ocil.QuestionIDPattern(d.id("question")),
+		Question_text: []ocil.QuestionTextType{d.questionText(text)},
+	}}
+	d.items = append(d.items, q)
+	return q
+}
+
+func (q *NumericQuestion) build(doc *ocil.OCILType) {
+	doc.Questions.Add(&q.q)
+}
+
+func (q *NumericQuestion) owner() *Document { return q.d }
+
+// QuestionID returns the question's ID.
+func (q *NumericQuestion) QuestionID() ocil.QuestionIDPattern { return q.q.Id }
+
+// Default sets the default answer.
+func (q *NumericQuestion) Default(answer float64) *NumericQuestion {
+	q.q.Default_answer = answer
+	return q
+}
+
+// Instructions sets the instructions for answering.
+func (q *NumericQuestion) Instructions(title string, steps ...*Step) *NumericQuestion {
+	q.q.Instructions = instructions(title, steps)
+	return q
+}
+
+// Note adds a note.
+func (q *NumericQuestion) Note(s string) *NumericQuestion {
+	q.q.Notes = append(q.q.Notes, s)
+	return q
+}
+
+// Test adds a test action evaluating the answer.
+func (q *NumericQuestion) Test() *NumericTest {
+	t := &NumericTest{d: q.d, t: ocil.NumericQuestionTestActionType{
+		Id:           ocil.QuestionTestActionIDPattern(q.d.id("testaction")),
+		Question_ref: q.q.Id,
+	}}
+	q.d.items = append(q.d.items, t)
+	return t
+}
+
+// A StringQuestion is a question answered with free text.
+type StringQuestion struct {
+	d *Document
+	q ocil.StringQuestionType
+}
+
+// StringQuestion adds a string question. The text is made of
+// strings and the *Variable values to substitute between them.
+func (d *Document) StringQuestion(text ...interface{}) *StringQuestion {
+	q := &StringQuestion{d: d, q: ocil.StringQuestionType{
+		Id:            ocil.QuestionIDPattern(d.id("question")),
+		Question_text: []ocil.QuestionTextType{d.questionText(text)},
+	}}
+	d.items = append(d.items, q)
+	return q
+}
+
+func (q *StringQuestion) build(doc *ocil.OCILType) {
+	doc.Questions.Add(&q.q)
+}
+
+func (q *StringQuestion) owner() *Document { return q.d }
+
+// QuestionID returns the question's ID.
+func (q *StringQuestion) QuestionID() ocil.QuestionIDPattern { return q.q.Id }
+
+// Default sets the default answer.
+func (q *StringQuestion) Default(answer string) *StringQuestion {
+	q.q.Default_answer = answer
+	return q
+}
+
+// Instructions sets the instructions for answering.
+func (q *StringQuestion) Instructions(title string, steps ...*Step) *StringQuestion {
+	q.q.Instructions = instructions(title, steps)
+	return q
+}
+
+// Note adds a note.
+func (q *StringQuestion) Note(s string) *StringQuestion {
+	q.q.Notes = append(q.q.Notes, s)
+	return q
+}
+
+// Test adds a test action evaluating the answer.
+func (q *StringQuestion) Test() *StringTest {
+	t := &StringTest{d: q.d, t: ocil.StringQuestionTestActionType{
+		Id:           ocil.QuestionTestActionIDPattern(q.d.id("testaction")),
+		Question_ref: q.q.Id,
+	}}
+	q.d.items = append(q.d.items, t)
+	return t
+}
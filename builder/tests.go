@@ -0,0 +1,274 @@
+package builder
+
+import (
+	"fmt"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// exceptional sets the handler for the exceptional response r.
+func (d *Document) exceptional(r ocil.UserResponseType, o Outcome, unknown, notTested, notApplicable, err *ocil.TestActionConditionType) {
+	cond := o.condition(d)
+	switch r {
+	case ocil.ResponseUnknown:
+		*unknown = cond
+	case ocil.ResponseNotTested:
+		*notTested = cond
+	case ocil.ResponseNotApplicable:
+		*notApplicable = cond
+	case ocil.ResponseError:
+		*err = cond
+	default:
+		d.fail(fmt.Errorf("no handler for response %q", r))
+	}
+}
+
+// A BooleanTest is a test action on a boolean question.
+type BooleanTest struct {
+	d *Document
+	t ocil.BooleanQuestionTestActionType
+}
+
+func (t *BooleanTest) build(doc *ocil.OCILType) {
+	doc.Test_actions.Add(&t.t)
+}
+
+func (t *BooleanTest) owner() *Document { return t.d }
+
+// ActionID returns the test action's ID.
+func (t *BooleanTest) ActionID() ocil.TestActionRefValuePattern {
+	return ocil.TestActionRefValuePattern(t.t.Id)
+}
+
+// Title sets the test action title.
+func (t *BooleanTest) Title(s string) *BooleanTest {
+	t.t.Title.Value = s
+	return t
+}
+
+// WhenTrue sets the outcome of a yes or true answer.
+func (t *BooleanTest) WhenTrue(o Outcome) *BooleanTest {
+	t.t.When_true = o.condition(t.d)
+	return t
+}
+
+// WhenFalse sets the outcome of a no or false answer.
+func (t *BooleanTest) WhenFalse(o Outcome) *BooleanTest {
+	t.t.When_false = o.condition(t.d)
+	return t
+}
+
+// WhenResponse sets the outcome of an exceptional response, such
+// as ocil.ResponseNotApplicable.
+func (t *BooleanTest) WhenResponse(r ocil.UserResponseType, o Outcome) *BooleanTest {
+	t.d.exceptional(r, o, &t.t.When_unknown, &t.t.When_not_tested, &t.t.When_not_applicable, &t.t.When_error)
+	return t
+}
+
+// A ChoiceTest is a test action on a choice question.
+type ChoiceTest struct {
+	d *Document
+	t ocil.ChoiceQuestionTestActionType
+}
+
+func (t *ChoiceTest) build(doc *ocil.OCILType) {
+	doc.Test_actions.Add(&t.t)
+}
+
+func (t *ChoiceTest) owner() *Document { return t.d }
+
+// ActionID returns the test action's ID.
+func (t *ChoiceTest) ActionID() ocil.TestActionRefValuePattern {
+	return ocil.TestActionRefValuePattern(t.t.Id)
+}
+
+// Title sets the test action title.
+func (t *ChoiceTest) Title(s string) *ChoiceTest {
+	t.t.Title.Value = s
+	return t
+}
+
+// WhenChoice adds the outcome of picking any of the choices.
+func (t *ChoiceTest) WhenChoice(o Outcome, choices ...*Choice) *ChoiceTest {
+	cond := o.condition(t.d)
+	h := ocil.ChoiceTestActionConditionType{
+		Result:          cond.Result,
+		Test_action_ref: cond.Test_action_ref,
+		Artifact_refs:   cond.Artifact_refs,
+	}
+	for _, c := range choices {
+		t.d.own(c.d, "choice "+string(c.id))
+		h.Choice_ref = append(h.Choice_ref, c.id)
+	}
+	t.t.When_choice = append(t.t.When_choice, h)
+	return t
+}
+
+// WhenResponse sets the outcome of an exceptional response, such
+// as ocil.ResponseNotApplicable.
+func (t *ChoiceTest) WhenResponse(r ocil.UserResponseType, o Outcome) *ChoiceTest {
+	t.d.exceptional(r, o, &t.t.When_unknown, &t.t.When_not_tested, &t.t.When_not_applicable, &t.t.When_error)
+	return t
+}
+
+// A Bound is one end of a numeric range: a number, or the value of
+// a variable.
+type Bound struct {
+	v ocil.RangeValueType
+	d *Document
+}
+
+// Inclusive is a bound that includes value.
+func Inclusive(value float64) *Bound {
+	return &Bound{v: ocil.RangeValueType{Value: value, Inclusive: true}}
+}
+
+// Exclusive is a bound that excludes value.
+func Exclusive(value float64) *Bound {
+	return &Bound{v: ocil.RangeValueType{Value: value}}
+}
+
+// VarBound is a bound at the value of v.
+func VarBound(v *Variable, inclusive bool) *Bound {
+	return &Bound{d: v.d, v: ocil.RangeValueType{Var_ref: v.id, Inclusive: inclusive}}
+}
+
+func (b *Bound) value(d *Document) *ocil.RangeValueType {
+	if b == nil {
+		return nil
+	}
+	if b.d != nil {
+		d.own(b.d, "variable "+string(b.v.Var_ref))
+	}
+	v := b.v
+	return &v
+}
+
+// A NumericTest is a test action on a numeric question.
+type NumericTest struct {
+	d *Document
+	t ocil.NumericQuestionTestActionType
+}
+
+func (t *NumericTest) build(doc *ocil.OCILType) {
+	doc.Test_actions.Add(&t.t)
+}
+
+func (t *NumericTest) owner() *Document { return t.d }
+
+// ActionID returns the test action's ID.
+func (t *NumericTest) ActionID() ocil.TestActionRefValuePattern {
+	return ocil.TestActionRefValuePattern(t.t.Id)
+}
+
+// Title sets the test action title.
+func (t *NumericTest) Title(s string) *NumericTest {
+	t.t.Title.Value = s
+	return t
+}
+
+// WhenEquals adds the outcome of an answer equal to any of values.
+func (t *NumericTest) WhenEquals(o Outcome, values ...float64) *NumericTest {
+	cond := o.condition(t.d)
+	t.t.When_equals = append(t.t.When_equals, ocil.EqualsTestActionConditionType{
+		Value:           values,
+		Result:          cond.Result,
+		Test_action_ref: cond.Test_action_ref,
+		Artifact_refs:   cond.Artifact_refs,
+	})
+	return t
+}
+
+// WhenEqualsVar adds the outcome of an answer equal to the value of
+// v.
+func (t *NumericTest) WhenEqualsVar(o Outcome, v *Variable) *NumericTest {
+	cond := o.condition(t.d)
+	t.d.own(v.d, "variable "+string(v.id))
+	t.t.When_equals = append(t.t.When_equals, ocil.EqualsTestActionConditionType{
+		Var_ref:         v.id,
+		Result:          cond.Result,
+		Test_action_ref: cond.Test_action_ref,
+		Artifact_refs:   cond.Artifact_refs,
+	})
+	return t
+}
+
+// WhenRange adds the outcome of an answer between min and max. A
+// nil bound leaves that end of the range open.
+func (t *NumericTest) WhenRange(o Outcome, min, max *Bound) *NumericTest {
+	cond := o.condition(t.d)
+	t.t.When_range = append(t.t.When_range, ocil.RangeTestActionConditionType{
+		Range:           []ocil.RangeType{{Min: min.value(t.d), Max: max.value(t.d)}},
+		Result:          cond.Result,
+		Test_action_ref: cond.Test_action_ref,
+		Artifact_refs:   cond.Artifact_refs,
+	})
+	return t
+}
+
+// WhenResponse sets the outcome of an exceptional response, such
+// as ocil.ResponseNotApplicable.
+func (t *NumericTest) WhenResponse(r ocil.UserResponseType, o Outcome) *NumericTest {
+	t.d.exceptional(r, o, &t.t.When_unknown, &t.t.When_not_tested, &t.t.When_not_applicable, &t.t.When_error)
+	return t
+}
+
+// A StringTest is a test action on a string question.
+type StringTest struct {
+	d *Document
+	t ocil.StringQuestionTestActionType
+}
+
+func (t *StringTest) build(doc *ocil.OCILType) {
+	doc.Test_actions.Add(&t.t)
+}
+
+func (t *StringTest) owner() *Document { return t.d }
+
+// ActionID returns the test action's ID.
+func (t *StringTest) ActionID() ocil.TestActionRefValuePattern {
+	return ocil.TestActionRefValuePattern(t.t.Id)
+}
+
+// Title sets the test action title.
+func (t *StringTest) Title(s string) *StringTest {
+	t.t.Title.Value = s
+	return t
+}
+
+// WhenPattern adds the outcome of an answer matching any of the
+// regular expressions.
+func (t *StringTest) WhenPattern(o Outcome, patterns ...string) *StringTest {
+	cond := o.condition(t.d)
+	h := ocil.PatternTestActionConditionType{
+		Result:          cond.Result,
+		Test_action_ref: cond.Test_action_ref,
+		Artifact_refs:   cond.Artifact_refs,
+	}
+	for _, p := range patterns {
+		h.Pattern = append(h.Pattern, ocil.PatternType{Value: p})
+	}
+	t.t.When_pattern = append(t.t.When_pattern, h)
+	return t
+}
+
+// WhenPatternVar adds the outcome of an answer matching the regular
+// expression held by v.
+func (t *StringTest) WhenPatternVar(o Outcome, v *Variable) *StringTest {
+	cond := o.condition(t.d)
+	t.d.own(v.d, "variable "+string(v.id))
+	t.t.When_pattern = append(t.t.When_pattern, ocil.PatternTestActionConditionType{
+		Pattern:         []ocil.PatternType{{Var_ref: v.id}},
+		Result:          cond.Result,
+		Test_action_ref: cond.Test_action_ref,
+		Artifact_refs:   cond.Artifact_refs,
+	})
+	return t
+}
+
+// WhenResponse sets the outcome of an exceptional response, such
+// as ocil.ResponseNotApplicable.
+func (t *StringTest) WhenResponse(r ocil.UserResponseType, o Outcome) *StringTest {
+	t.d.exceptional(r, o, &t.t.When_unknown, &t.t.When_not_tested, &t.t.When_not_applicable, &t.t.When_error)
+	return t
+}
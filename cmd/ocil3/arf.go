@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func arfCmd(args []string) error {
+	fs := flag.NewFlagSet("arf", flag.ExitOnError)
+	out := fs.String("o", "", "write the collection to `file` instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 arf [flags] document.xml results.xml...")
+		fmt.Fprintln(os.Stderr, "\nWraps results documents produced from document.xml into an ARF 1.1\nasset report collection.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	source, err := ocil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var results []*ocil.OCILType
+	for _, name := range fs.Args()[1:] {
+		doc, err := ocil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		results = append(results, doc)
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return ocil.WriteARF(w, source, results)
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func diffCmd(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "write the changes as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 diff [flags] old.xml new.xml")
+		fmt.Fprintln(os.Stderr, `
+Reports the questionnaires, test actions, questions, choices and
+variables added, removed or changed between two documents, matched
+by ID, with the fields that changed. Exits with status 1 when an
+item's content changed without an increase of its revision.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	old, err := ocil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := ocil.ReadFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	changes := ocil.Diff(old, new)
+
+	w := bufio.NewWriter(os.Stdout)
+	if *asJSON {
+		if changes == nil {
+			changes = []ocil.Change{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range changes {
+			mark := map[string]string{ocil.DiffAdded: "+", ocil.DiffRemoved: "-", ocil.DiffChanged: "~"}[c.Op]
+			fmt.Fprintf(w, "%s %s %s", mark, c.Kind, c.ID)
+			switch {
+			case c.NoRevisionBump:
+				fmt.Fprintf(w, " (changed without revision bump, revision %d)", c.NewRevision)
+			case c.Op == ocil.DiffChanged && c.OldRevision != c.NewRevision:
+				fmt.Fprintf(w, " (revision %d -> %d)", c.OldRevision, c.NewRevision)
+			}
+			fmt.Fprintln(w)
+			for _, f := range c.Fields {
+				fmt.Fprintf(w, "    %s: %q -> %q\n", f.Field, f.Old, f.New)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		if c.NoRevisionBump {
+			os.Exit(1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func dsCmd(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			return dsListCmd(args[1:])
+		case "extract":
+			return dsExtractCmd(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "usage: ocil3 ds list datastream.xml\n       ocil3 ds extract [flags] datastream.xml")
+	os.Exit(2)
+	return nil
+}
+
+func dsListCmd(args []string) error {
+	fs := flag.NewFlagSet("ds list", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 ds list datastream.xml")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	c, err := ocil.ReadDataStreamFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return listComponents(os.Stdout, c)
+}
+
+// listComponents writes a table of the OCIL components of c.
+func listComponents(w io.Writer, c *ocil.DataStreamCollection) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tREFS\tQUESTIONNAIRES\tTITLE")
+	for _, comp := range c.OCILComponents() {
+		var refs []string
+		for _, r := range c.Refs(comp.ID) {
+			refs = append(refs, r.ID)
+		}
+		doc, err := comp.Document()
+		if err != nil {
+			return fmt.Errorf("component %s: %v", comp.ID, err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", comp.ID, strings.Join(refs, ","),
+			len(doc.Questionnaires.Questionnaire), doc.Document.Title)
+	}
+	return tw.Flush()
+}
+
+func dsExtractCmd(args []string) error {
+	fs := flag.NewFlagSet("ds extract", flag.ExitOnError)
+	outDir := fs.String("o", ".", "write the documents into `dir`")
+	id := fs.String("component", "", "extract only the component or component-ref with this `id`")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 ds extract [flags] datastream.xml")
+		fmt.Fprintln(os.Stderr, "\nWrites each OCIL component to a file named after its ID.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	c, err := ocil.ReadDataStreamFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	comps, err := selectComponents(c, *id)
+	if err != nil {
+		return err
+	}
+	for _, comp := range comps {
+		name := filepath.Join(*outDir, fileName(comp.ID)+".xml")
+		if err := writeOutput(name, func(w io.Writer) error { return comp.Write(w) }); err != nil {
+			return err
+		}
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// selectComponents returns the OCIL components of c, or with an id
+// the component it names directly or through a component-ref.
+func selectComponents(c *ocil.DataStreamCollection, id string) ([]*ocil.Component, error) {
+	if id == "" {
+		return c.OCILComponents(), nil
+	}
+	comp, ok := c.Component(id)
+	if !ok {
+		r, ok := c.Ref(id)
+		if !ok {
+			return nil, fmt.Errorf("no component %s", id)
+		}
+		var err error
+		if comp, err = c.Resolve(r); err != nil {
+			return nil, err
+		}
+	}
+	if !comp.IsOCIL() {
+		return nil, fmt.Errorf("component %s is not an OCIL document", comp.ID)
+	}
+	return []*ocil.Component{comp}, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+const testDataStream = "../../testdata/datastream.xml"
+
+func TestListComponents(t *testing.T) {
+	c, err := ocil.ReadDataStreamFile(testDataStream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := listComponents(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	want := `COMPONENT                         REFS                              QUESTIONNAIRES  TITLE
+scap_org.example_comp_sample.xml  scap_org.example_cref_sample.xml  2               Sample
+scap_org.example_comp_other.xml   scap_org.example_cref_other.xml   1               Other
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", &buf, want)
+	}
+}
+
+func TestExtractComponents(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		// want maps the files written to the titles of their
+		// documents.
+		want map[string]string
+		err  bool
+	}{
+		{
+			name: "all",
+			want: map[string]string{
+				"scap_org.example_comp_sample.xml.xml": "Sample",
+				"scap_org.example_comp_other.xml.xml":  "Other",
+			},
+		},
+		{
+			name: "component",
+			id:   "scap_org.example_comp_sample.xml",
+			want: map[string]string{"scap_org.example_comp_sample.xml.xml": "Sample"},
+		},
+		{
+			name: "component-ref",
+			id:   "scap_org.example_cref_other.xml",
+			want: map[string]string{"scap_org.example_comp_other.xml.xml": "Other"},
+		},
+		{name: "not OCIL", id: "scap_org.example_comp_benchmark.xml", err: true},
+		{name: "unknown", id: "scap_org.example_comp_missing.xml", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			args := []string{"-o", dir}
+			if tt.id != "" {
+				args = append(args, "-component", tt.id)
+			}
+			err := dsExtractCmd(append(args, testDataStream))
+			if tt.err {
+				if err == nil {
+					t.Error("no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, e := range entries {
+				doc, err := ocil.ReadFile(filepath.Join(dir, e.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[e.Name()] = doc.Document.Title
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extracted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
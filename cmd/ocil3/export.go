@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func exportCmd(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "csv":
+			return exportCSVCmd(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "usage: ocil3 export csv [flags] results.xml...")
+	os.Exit(2)
+	return nil
+}
+
+func exportCSVCmd(args []string) error {
+	fs := flag.NewFlagSet("export csv", flag.ExitOnError)
+	table := fs.String("table", "", "write only the `table` questionnaires, questions or artifacts")
+	dir := fs.String("o", "", "write each table to table.csv in `dir` instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 export csv [flags] results.xml...")
+		fmt.Fprintln(os.Stderr, `
+Exports results documents as CSV tables, one row per questionnaire
+result, answered question or artifact, with a header row:
+
+  questionnaires  file, target, questionnaire_id, title, result,
+                  start_time, end_time
+  questions       file, target, question_id, question_text, answer,
+                  response, submitter
+  artifacts       file, target, artifact_ref, title, mime_type, size,
+                  href, provider, submitter, timestamp
+
+Without -o the questionnaires table, or the -table one, is written
+to standard output.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	tables := ocil.CSVTables
+	if *table != "" {
+		t := ocil.CSVTable(*table)
+		if t.Columns() == nil {
+			return fmt.Errorf("unknown table %q", *table)
+		}
+		tables = []ocil.CSVTable{t}
+	} else if *dir == "" {
+		tables = tables[:1]
+	}
+	var docs []*ocil.OCILType
+	for _, name := range fs.Args() {
+		doc, err := ocil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			return err
+		}
+	}
+	for _, t := range tables {
+		name := ""
+		if *dir != "" {
+			name = filepath.Join(*dir, string(t)+".csv")
+		}
+		err := writeOutput(name, func(w io.Writer) error {
+			return ocil.WriteResultsCSV(w, t, fs.Args(), docs)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
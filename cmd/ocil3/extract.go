@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func extractCmd(args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	var ids []ocil.QuestionnaireIDPattern
+	fs.Func("questionnaire", "extract the questionnaire with this `id` (repeatable)", func(s string) error {
+		ids = append(ids, ocil.QuestionnaireIDPattern(s))
+		return nil
+	})
+	out := fs.String("o", "", "write the document to `file` instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 extract -questionnaire id... [flags] document.xml")
+		fmt.Fprintln(os.Stderr, `
+Writes a document holding only the selected questionnaires and the
+items they refer to, with their IDs unchanged.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || len(ids) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	doc, err := ocil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	sub, err := ocil.Extract(doc, ids)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return ocil.WriteDocument(w, sub)
+	})
+}
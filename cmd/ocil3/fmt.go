@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func fmtCmd(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := fs.Bool("check", false, "list the files that are not formatted and exit with status 1 if there are any")
+	write := fs.Bool("w", false, "rewrite the files in place instead of writing to standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 fmt [flags] [document.xml...]")
+		fmt.Fprintln(os.Stderr, `
+Rewrites documents into a canonical form: standard namespace
+prefixes, the element order of the schema, sorted attributes,
+two-space indentation and prose wrapped at 80 columns. Without
+files, formats standard input.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *check && *write {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if fs.NArg() == 0 {
+		if *write {
+			fs.Usage()
+			os.Exit(2)
+		}
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		out, err := format(src)
+		if err != nil {
+			return fmt.Errorf("standard input: %v", err)
+		}
+		if *check {
+			if !bytes.Equal(src, out) {
+				fmt.Println("standard input")
+				os.Exit(1)
+			}
+			return nil
+		}
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	unformatted := false
+	for _, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		out, err := format(src)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		switch {
+		case *check:
+			if !bytes.Equal(src, out) {
+				fmt.Println(name)
+				unformatted = true
+			}
+		case *write:
+			if !bytes.Equal(src, out) {
+				if err := os.WriteFile(name, out, 0644); err != nil {
+					return err
+				}
+			}
+		default:
+			if _, err := os.Stdout.Write(out); err != nil {
+				return err
+			}
+		}
+	}
+	if unformatted {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func format(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ocil.Format(&buf, bytes.NewReader(src)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
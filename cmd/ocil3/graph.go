@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func graphCmd(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "dot", "output `format`: dot or mermaid")
+	var ids []ocil.QuestionnaireIDPattern
+	fs.Func("questionnaire", "graph the questionnaire with this `id` (repeatable; default all that are not child only)", func(s string) error {
+		ids = append(ids, ocil.QuestionnaireIDPattern(s))
+		return nil
+	})
+	results := fs.String("results", "", "color the graph with the results in `file`")
+	out := fs.String("o", "", "write the graph to `file` instead of standard output")
+	vf := addVarFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 graph [flags] document.xml")
+		fmt.Fprintln(os.Stderr, `
+Writes the logic of questionnaires as a Graphviz DOT or Mermaid
+graph: questionnaires, AND/OR operators, test actions and their
+questions, with an edge per handler leading to a result or to
+another test action. With -results, nodes are colored by result
+and the handlers the answers selected are drawn bold.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var f ocil.GraphFormat
+	switch *format {
+	case "dot":
+		f = ocil.GraphDOT
+	case "mermaid":
+		f = ocil.GraphMermaid
+	default:
+		return fmt.Errorf("unknown graph format %q", *format)
+	}
+	doc, err := ocil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	x, err := ocil.NewIndex(doc)
+	if err != nil {
+		return err
+	}
+	vars, err := vf.load(x, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var res *ocil.ResultsType
+	if *results != "" {
+		rdoc, err := ocil.ReadFile(*results)
+		if err != nil {
+			return err
+		}
+		res = &rdoc.Results
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return ocil.WriteGraph(w, doc, f, ids, res, vars)
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	ocil "github.com/redhatrises/goscap"
+	"github.com/redhatrises/goscap/builder"
+)
+
+func importCmd(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "stig":
+			return importSTIGCmd(args[1:])
+		case "csv":
+			return importCSVCmd(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "usage: ocil3 import stig [flags] benchmark.xml\n       ocil3 import csv [flags] file.csv")
+	os.Exit(2)
+	return nil
+}
+
+func importSTIGCmd(args []string) error {
+	fs := flag.NewFlagSet("import stig", flag.ExitOnError)
+	ns := fs.String("ns", "", "`namespace` of the generated IDs (default derived from the benchmark ID)")
+	out := fs.String("o", "", "write the document to `file` instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 import stig [flags] benchmark.xml")
+		fmt.Fprintln(os.Stderr, "\nConverts the manual checks of a DISA STIG XCCDF benchmark into an OCIL document.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	b, err := ocil.ReadBenchmarkFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *ns == "" {
+		*ns = ocil.IDNamespace(b.ID)
+	}
+	doc, err := ocil.ImportSTIG(b, *ns)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return ocil.WriteDocument(w, doc)
+	})
+}
+
+func importCSVCmd(args []string) error {
+	fs := flag.NewFlagSet("import csv", flag.ExitOnError)
+	ns := fs.String("ns", "", "`namespace` of the generated IDs (default derived from the file name)")
+	title := fs.String("title", "", "document `title` (default the file name)")
+	comma := fs.String("comma", ",", "field separator `character`, such as ; or \\t")
+	out := fs.String("o", "", "write the document to `file` instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 import csv [flags] file.csv")
+		fmt.Fprintln(os.Stderr, `
+Converts a CSV file with one question per row into an OCIL document.
+The header row names the columns, in any order:
+
+  questionnaire  questionnaire title (required); rows with the same
+                 title share a questionnaire
+  question       question text (required)
+  type           boolean (default), choice, numeric or string
+  choices        choices of a choice question
+  pass           the passing answer: yes/no/true/false, the passing
+                 choices, a number or range (8..64, 8.., ..90), or a
+                 regular expression; any other answer fails
+  references     questionnaire references (CCE, CCI, controls, ...)
+  instructions   instruction steps
+  description    questionnaire description
+  notes          question notes
+
+List cells separate items with | or line breaks.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	sep := strings.Replace(*comma, `\t`, "\t", 1)
+	if utf8.RuneCountInString(sep) != 1 {
+		return fmt.Errorf("-comma must be a single character")
+	}
+	c, _ := utf8.DecodeRuneInString(sep)
+	name := fs.Arg(0)
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	if *ns == "" {
+		*ns = ocil.IDNamespace(base)
+	}
+	if *title == "" {
+		*title = base
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	doc, err := builder.ImportCSV(f, *ns, *title, c)
+	if errs, ok := err.(builder.RowErrors); ok {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, e)
+		}
+		return fmt.Errorf("%s: %d errors", name, len(errs))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return ocil.WriteDocument(w, doc)
+	})
+}
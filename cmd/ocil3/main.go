@@ -0,0 +1,66 @@
+// Command ocil3 works with OCIL 2.0 documents.
+//
+// Usage:
+//
+//	ocil3 <command> [arguments]
+//
+// Run "ocil3 <command> -h" for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+var commands = map[string]struct {
+	run     func(args []string) error
+	summary string
+}{
+	"arf":      {arfCmd, "export results documents as an ARF asset report collection"},
+	"diff":     {diffCmd, "compare two documents item by item and check revision bumps"},
+	"ds":       {dsCmd, "list or extract the OCIL components of a SCAP source data stream"},
+	"export":   {exportCmd, "export results documents as CSV tables"},
+	"extract":  {extractCmd, "write the selected questionnaires and everything they refer to"},
+	"fmt":      {fmtCmd, "rewrite documents into canonical form, or check that they are"},
+	"graph":    {graphCmd, "draw the logic of questionnaires as a DOT or Mermaid graph"},
+	"import":   {importCmd, "convert other content, such as DISA STIG manual checks, into OCIL"},
+	"merge":    {mergeCmd, "combine several documents into one, resolving ID collisions"},
+	"refs":     {refsCmd, "find the questionnaires covering a CCE, CVE, control or STIG identifier"},
+	"renumber": {renumberCmd, "move every ID into a namespace and compact the numbers"},
+	"render":   {renderCmd, "render the questionnaires of a document as a readable checklist"},
+	"report":   {reportCmd, "render a results document as a report"},
+	"run":      {runCmd, "evaluate a document against the answers for one or more targets"},
+	"serve":    {serveCmd, "assess targets in the browser with a local web application"},
+	"stats":    {statsCmd, "count the items of a document and estimate assessment effort"},
+	"xccdf":    {xccdfCmd, "evaluate the OCIL checks of an XCCDF benchmark"},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ocil3 <command> [arguments]\n\ncommands:")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("ocil3: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
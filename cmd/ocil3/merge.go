@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func mergeCmd(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	title := fs.String("title", "", "`title` of the merged document (default the titles of the inputs)")
+	out := fs.String("o", "", "write the document to `file` instead of standard output")
+	rename := make(map[string]string)
+	fs.Func("ns", "rewrite the IDs of one input into a namespace, as `file.xml=namespace` (repeatable)", func(s string) error {
+		i := strings.LastIndex(s, "=")
+		if i < 0 {
+			return fmt.Errorf("want file.xml=namespace")
+		}
+		rename[s[:i]] = s[i+1:]
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 merge [flags] document.xml...")
+		fmt.Fprintln(os.Stderr, `
+Combines the items of several documents into one. Items found
+unchanged in several documents are kept once and choice groups with
+the same choices are merged; any other reuse of an ID is reported
+as a collision, which -ns can resolve.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var docs []*ocil.OCILType
+	var namespaces, titles []string
+	seen := make(map[string]bool)
+	for _, name := range fs.Args() {
+		doc, err := ocil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+		ns, ok := rename[name]
+		if ok {
+			delete(rename, name)
+		}
+		namespaces = append(namespaces, ns)
+		if t := doc.Document.Title; t != "" && !seen[t] {
+			seen[t] = true
+			titles = append(titles, t)
+		}
+	}
+	for name := range rename {
+		return fmt.Errorf("-ns: %s is not an input document", name)
+	}
+	if *title == "" {
+		*title = strings.Join(titles, "; ")
+	}
+	doc, err := ocil.Merge(docs, namespaces, *title)
+	if cerr, ok := err.(ocil.CollisionError); ok {
+		for _, c := range cerr {
+			fmt.Fprintf(os.Stderr, "%s: used by %s and %s\n", c.ID, fs.Arg(c.First), fs.Arg(c.Second))
+		}
+		return fmt.Errorf("%d ID collisions; use -ns to move a document to its own namespace", len(cerr))
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return ocil.WriteDocument(w, doc)
+	})
+}
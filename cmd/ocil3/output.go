@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// writeOutput calls write with the named file, or standard output
+// when name is empty.
+func writeOutput(name string, write func(io.Writer) error) error {
+	if name == "" {
+		w := bufio.NewWriter(os.Stdout)
+		if err := write(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
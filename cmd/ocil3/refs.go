@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func refsCmd(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "find":
+			return refsFindCmd(args[1:])
+		case "coverage":
+			return refsCoverageCmd(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "usage: ocil3 refs find id [path...]\n       ocil3 refs coverage [flags] [path...]")
+	os.Exit(2)
+	return nil
+}
+
+// readRefIndex indexes the named documents and directories, or the
+// current directory when none are named. It fails with the errors of
+// every document that could not be indexed.
+func readRefIndex(paths []string) (*ocil.RefIndex, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	ix := ocil.NewRefIndex()
+	var errs []error
+	for _, p := range paths {
+		if err := ix.AddPath(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+func refsFindCmd(args []string) error {
+	fs := flag.NewFlagSet("refs find", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 refs find id [path...]")
+		fmt.Fprintln(os.Stderr, "\nLists the questionnaires referencing id, such as CCE-27002-5, CVE-2021-44228,\nAC-2 or V-230221, in the named documents and directories.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	ix, err := readRefIndex(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	es := ix.Find(fs.Arg(0))
+	if len(es) == 0 {
+		return fmt.Errorf("no questionnaire references %s", fs.Arg(0))
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tQUESTIONNAIRE\tQUESTION\tSYSTEM")
+	for _, e := range es {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.File, e.Questionnaire, e.Question, e.System)
+	}
+	return tw.Flush()
+}
+
+func refsCoverageCmd(args []string) error {
+	fs := flag.NewFlagSet("refs coverage", flag.ExitOnError)
+	controls := fs.String("controls", "", "read the identifiers to check, one per line, from `file`")
+	system := fs.String("system", "", "without -controls, list the referenced identifiers of this `system` (CCE, CVE, CPE, CCI, STIG, \"NIST 800-53\")")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 refs coverage [flags] [path...]")
+		fmt.Fprintln(os.Stderr, "\nWith -controls, lists the identifiers that no questionnaire references.\nOtherwise lists the identifiers referenced.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	ix, err := readRefIndex(fs.Args())
+	if err != nil {
+		return err
+	}
+	if *controls == "" {
+		for _, id := range ix.IDs(*system) {
+			fmt.Printf("%s\t%d\n", id, len(ix.Questionnaires(id)))
+		}
+		return nil
+	}
+	f, err := os.Open(*controls)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var ids []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			ids = append(ids, line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	uncovered := ix.Uncovered(ids)
+	for _, id := range uncovered {
+		fmt.Println(id)
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d identifiers have no manual check\n", len(uncovered), len(ids))
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func renderCmd(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	format := fs.String("format", "markdown", "output `format`: markdown or text")
+	out := fs.String("o", "", "write the checklist to `file` instead of standard output")
+	vf := addVarFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 render [flags] document.xml")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var f ocil.ChecklistFormat
+	switch *format {
+	case "markdown", "md":
+		f = ocil.ChecklistMarkdown
+	case "text":
+		f = ocil.ChecklistText
+	default:
+		return fmt.Errorf("unknown render format %q", *format)
+	}
+	doc, err := ocil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	x, err := ocil.NewIndex(doc)
+	if err != nil {
+		return err
+	}
+	vars, err := vf.load(x, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return ocil.WriteChecklist(w, doc, f, vars)
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func renumberCmd(args []string) error {
+	fs := flag.NewFlagSet("renumber", flag.ExitOnError)
+	ns := fs.String("ns", "", "move every ID into `namespace` (default keep the namespace of each ID)")
+	mapFile := fs.String("map", "", "write the old=new ID mapping to `file`")
+	apply := fs.String("apply", "", "instead of renumbering, rewrite the IDs of a mapping `file` written by -map")
+	out := fs.String("o", "", "write the document to `file` instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 renumber -map file [flags] document.xml\n       ocil3 renumber -apply file [-o file] document.xml")
+		fmt.Fprintln(os.Stderr, `
+Gives every questionnaire, test action, question, choice, choice
+group, variable and artifact a new ID, numbering the items of each
+kind from 1 in document order, and rewrites every reference to them.
+The mapping from old to new IDs is written to the -map file; -apply
+uses it to migrate other documents, such as results recorded
+against the old IDs.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || (*apply == "") == (*mapFile == "") || (*apply != "" && *ns != "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+	doc, err := ocil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *apply != "" {
+		m, err := ocil.ReadIDMapFile(*apply)
+		if err != nil {
+			return err
+		}
+		m.Apply(doc)
+	} else {
+		m, err := ocil.Renumber(doc, *ns)
+		if err != nil {
+			return err
+		}
+		if err := writeOutput(*mapFile, m.Write); err != nil {
+			return err
+		}
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return ocil.WriteDocument(w, doc)
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func runCmd(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	outDir := fs.String("o", ".", "write one results document per target into `dir`")
+	summary := fs.Bool("summary", false, "print a combined report instead of writing results documents")
+	workers := fs.Int("j", runtime.NumCPU(), "evaluate up to `n` targets concurrently")
+	local := fs.Bool("local", false, "record the local host as the system target of every assessment")
+	org := fs.String("org", "", "`organization` of the local host target")
+	var platforms []string
+	fs.Func("cpe", "declare a CPE `name` as a platform of every target (repeatable)", func(s string) error {
+		platforms = append(platforms, s)
+		return nil
+	})
+	cpeTargets := fs.String("cpe-targets", "", "read the CPE names of the platforms of each target from `file`")
+	cpeMap := fs.String("cpe-map", "", "read questionnaire applicability expressions from `file`")
+	vf := addVarFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 run [flags] document.xml answers.xml...")
+		fmt.Fprintln(os.Stderr, "\nEach answers file is an OCIL document whose results hold the question\nresults and target of one assessment.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	doc, err := ocil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	x, err := ocil.NewIndex(doc)
+	if err != nil {
+		return err
+	}
+	vars, err := vf.load(x, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *cpeMap != "" {
+		f, err := os.Open(*cpeMap)
+		if err != nil {
+			return err
+		}
+		m, err := ocil.ReadApplicability(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", *cpeMap, err)
+		}
+		for id, t := range m {
+			x.Applicability[id] = t
+		}
+	}
+	var tp ocil.TargetPlatforms
+	if *cpeTargets != "" {
+		f, err := os.Open(*cpeTargets)
+		if err != nil {
+			return err
+		}
+		tp, err = ocil.ReadTargetPlatforms(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", *cpeTargets, err)
+		}
+	}
+	var host *ocil.SystemTargetType
+	if *local {
+		if host, err = ocil.LocalSystemTarget(); err != nil {
+			return err
+		}
+		host.Organization = *org
+	}
+	var as []ocil.Assessment
+	for _, name := range fs.Args()[1:] {
+		a, err := readAssessment(name)
+		if err != nil {
+			return err
+		}
+		// The platforms are those of the target recorded in the
+		// answers file, even when -local stands in for it.
+		if p := tp[a.Target.TargetName()]; len(p) > 0 || len(platforms) > 0 {
+			a.Platforms = append(append([]string(nil), platforms...), p...)
+		}
+		if host != nil {
+			a.Target = host
+		}
+		as = append(as, a)
+	}
+
+	rs := x.Assess(as, vars, *workers)
+	if *summary {
+		return ocil.WriteSummary(os.Stdout, x, rs)
+	}
+	sources := fs.Args()[1:]
+	names := make([]string, len(rs))
+	for i, r := range rs {
+		names[i] = r.Target.TargetName()
+	}
+	names = outputNames(names, sources)
+	var failed bool
+	for i, r := range rs {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "ocil3: %s: %s: %v\n", sources[i], r.Target.TargetName(), r.Err)
+			failed = true
+			continue
+		}
+		if err := ocil.WriteFile(filepath.Join(*outDir, names[i]), ocil.WithResults(doc, r.Results)); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return fmt.Errorf("some targets could not be evaluated")
+	}
+	return nil
+}
+
+// readAssessment reads the answers and target recorded in the
+// results of an OCIL document. Without a recorded target, a system
+// target named after the file stands in for it.
+func readAssessment(name string) (ocil.Assessment, error) {
+	doc, err := ocil.ReadFile(name)
+	if err != nil {
+		return ocil.Assessment{}, err
+	}
+	a := ocil.Assessment{Answers: doc.Results.Answers()}
+	if ts := doc.Results.Targets.All(); len(ts) > 0 {
+		a.Target = ts[0]
+	} else {
+		a.Target = ocil.NewSystemTarget(strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)))
+	}
+	return a, nil
+}
+
+// outputNames returns the results file name of each assessment
+// from the names of its target and answers file. A file is named
+// after its target; targets sharing a name, such as every target of
+// a -local run, add the name of their answers file, and a number if
+// that is not enough, so that no results overwrite others. Names
+// differing only in case are taken as the same.
+func outputNames(targets, sources []string) []string {
+	count := func(names []string) map[string]int {
+		n := make(map[string]int)
+		for _, name := range names {
+			n[strings.ToLower(name)]++
+		}
+		return n
+	}
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = fileName(t)
+	}
+	n := count(names)
+	for i, name := range names {
+		if n[strings.ToLower(name)] > 1 {
+			base := filepath.Base(sources[i])
+			names[i] = name + "-" + fileName(strings.TrimSuffix(base, filepath.Ext(base)))
+		}
+	}
+	n = count(names)
+	used := make(map[string]bool)
+	for _, name := range names {
+		used[strings.ToLower(name)] = true
+	}
+	for i, name := range names {
+		if n[strings.ToLower(name)] > 1 {
+			for k := 1; ; k++ {
+				numbered := fmt.Sprintf("%s-%d", name, k)
+				if !used[strings.ToLower(numbered)] {
+					used[strings.ToLower(numbered)] = true
+					names[i] = numbered
+					break
+				}
+			}
+		}
+		names[i] += ".xml"
+	}
+	return names
+}
+
+// fileName makes a target name safe to use as a file name.
+func fileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, s)
+}
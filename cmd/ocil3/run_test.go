@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func TestOutputNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+		sources []string
+		want    []string
+	}{
+		{
+			name:    "distinct targets",
+			targets: []string{"web1", "web2"},
+			sources: []string{"a.xml", "b.xml"},
+			want:    []string{"web1.xml", "web2.xml"},
+		},
+		{
+			name:    "shared target",
+			targets: []string{"host", "host", "other"},
+			sources: []string{"in/a.xml", "in/b.xml", "in/c.xml"},
+			want:    []string{"host-a.xml", "host-b.xml", "other.xml"},
+		},
+		{
+			name:    "names differing in case",
+			targets: []string{"Host", "host"},
+			sources: []string{"a.xml", "b.xml"},
+			want:    []string{"Host-a.xml", "host-b.xml"},
+		},
+		{
+			name:    "shared target and answers file name",
+			targets: []string{"host", "host", "host-a"},
+			sources: []string{"x/a.xml", "y/a.xml", "z.xml"},
+			want:    []string{"host-a-1.xml", "host-a-2.xml", "host-a-3.xml"},
+		},
+		{
+			name:    "unsafe characters",
+			targets: []string{"dom\\user", "cpe:/o:x"},
+			sources: []string{"a.xml", "b.xml"},
+			want:    []string{"dom_user.xml", "cpe__o_x.xml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputNames(tt.targets, tt.sources); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outputNames(%q, %q) = %q, want %q", tt.targets, tt.sources, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunLocal runs a batch of answers files against the local host,
+// which gives every assessment the same target, and checks that
+// each keeps its own results.
+func TestRunLocal(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	dir := t.TempDir()
+	err = runCmd([]string{
+		"-local", "-o", dir, "-var", "ocil:org.example:variable:1=8",
+		"../../testdata/sample.xml", "../../testdata/answers-a.xml", "../../testdata/answers-b.xml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ocil.ResultType{
+		fileName(host) + "-answers-a.xml": ocil.ResultPass,
+		fileName(host) + "-answers-b.xml": ocil.ResultFail,
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("wrote %d files, want %d", len(entries), len(want))
+	}
+	for name, result := range want {
+		doc, err := ocil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		ts := doc.Results.Targets.All()
+		if len(ts) != 1 || ts[0].TargetName() != host {
+			t.Errorf("%s: targets %v, want %s", name, ts, host)
+		}
+		qrs := doc.Results.Questionnaire_results.Questionnaire_result
+		if len(qrs) == 0 || qrs[0].Result != result {
+			t.Errorf("%s: questionnaire results %v, want %s", name, qrs, result)
+		}
+	}
+}
+
+// TestRunCPETargets checks that each target is assessed against its
+// own platforms.
+func TestRunCPETargets(t *testing.T) {
+	dir := t.TempDir()
+	cpes := filepath.Join(dir, "targets.cpe")
+	err := os.WriteFile(cpes, []byte("# target platforms\nweb1 cpe:/o:microsoft:windows_2000\nweb2 cpe:/o:redhat:enterprise_linux\n"), 0o666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	if err := os.Mkdir(out, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	err = runCmd([]string{
+		"-cpe-targets", cpes, "-o", out, "-var", "ocil:org.example:variable:1=8",
+		"../../testdata/sample.xml", "../../testdata/answers-a.xml", "../../testdata/answers-b.xml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, result := range map[string]ocil.ResultType{
+		"web1.xml": ocil.ResultPass,
+		"web2.xml": ocil.ResultNotApplicable,
+	} {
+		doc, err := ocil.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		qrs := doc.Results.Questionnaire_results.Questionnaire_result
+		if len(qrs) == 0 || qrs[0].Result != result {
+			t.Errorf("%s: questionnaire results %v, want %s", name, qrs, result)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+	"github.com/redhatrises/goscap/server"
+	"github.com/redhatrises/goscap/store"
+)
+
+func serveCmd(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "listen on `address`")
+	db := fs.String("db", "", "keep documents, sessions, answers, artifacts and results in the database `file` so that they survive a restart")
+	vf := addVarFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 serve [flags] [document.xml...]")
+		fmt.Fprintln(os.Stderr, `
+Serves a web application for assessing targets against the documents,
+and against those uploaded later. Each assessment shows the
+questionnaires as a form, evaluates the answers as they are given,
+records the instruction steps done and the artifacts uploaded, and
+offers the results document for download. The same sessions are
+available through a JSON API under /api, described by
+/api/openapi.json. The server needs no network access beyond the
+connections of its clients.
+
+Without -db, everything is kept in memory and lost on exit. With it,
+the documents imported are kept as revisions numbered per title,
+and every answer is kept with its submitter and time.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 && *db == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var st store.Store = store.NewMemory()
+	if *db != "" {
+		b, err := store.OpenBolt(*db)
+		if err != nil {
+			return err
+		}
+		defer b.Close()
+		st = b
+	}
+	srv, err := newServer(st, vf, fs.Args())
+	if err != nil {
+		return err
+	}
+	log.Printf("serving on http://%s/", *addr)
+	return http.ListenAndServe(*addr, srv)
+}
+
+// newServer returns a server on st holding the named documents. The
+// variables given by flags apply to every document and must be
+// valid for each; those a benchmark exports to a document are
+// collected for all of them.
+func newServer(st store.Store, vf *varFlags, names []string) (*server.Server, error) {
+	var docs []*ocil.OCILType
+	var layers []ocil.Variables
+	for _, name := range names {
+		doc, err := ocil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		x, err := ocil.NewIndex(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		vars, err := vf.load(x, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		docs = append(docs, doc)
+		layers = append(layers, vars)
+	}
+	srv, err := server.New(st, ocil.MergeVariables(layers...))
+	if err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		if _, err := srv.AddDocument(doc); err != nil {
+			return nil, fmt.Errorf("%s: %v", names[i], err)
+		}
+	}
+	return srv, nil
+}
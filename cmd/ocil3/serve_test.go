@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/redhatrises/goscap/server"
+	"github.com/redhatrises/goscap/store"
+)
+
+// TestServeBenchmark serves two documents with -benchmark and checks
+// that the questions of each show the value exported to it.
+func TestServeBenchmark(t *testing.T) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	vf := addVarFlags(fs)
+	if err := fs.Parse([]string{"-benchmark", "../../testdata/benchmark.xml"}); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := newServer(store.NewMemory(), vf, []string{"../../testdata/sample.xml", "../../testdata/other.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []struct{ ID, Title string }
+	if err := json.NewDecoder(request(t, srv, "GET", "/api/documents", "").Body).Decode(&docs); err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]string)
+	for _, d := range docs {
+		ids[d.Title] = d.ID
+	}
+	tests := []struct {
+		title string
+		// answers are given, in order, before the question asked
+		// next is checked.
+		answers []string
+		text    string
+	}{
+		{"Sample", []string{`{"question": "ocil:org.example:question:1", "value": true}`}, "What is the minimum password length? Policy requires 8."},
+		{"Other", nil, "How many failed logins lock an account? Policy allows 8."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			rec := request(t, srv, "POST", "/api/sessions", `{"document": "`+ids[tt.title]+`", "target": {"name": "web1"}}`)
+			var s struct{ ID string }
+			if err := json.NewDecoder(rec.Body).Decode(&s); err != nil {
+				t.Fatal(err)
+			}
+			path := "/api/sessions/" + s.ID
+			for _, a := range tt.answers {
+				request(t, srv, "POST", path+"/answers", a)
+			}
+			var q struct{ Text string }
+			if err := json.NewDecoder(request(t, srv, "GET", path+"/next", "").Body).Decode(&q); err != nil {
+				t.Fatal(err)
+			}
+			if q.Text != tt.text {
+				t.Errorf("question text %q, want %q", q.Text, tt.text)
+			}
+		})
+	}
+}
+
+// request sends a request with the JSON body to srv and fails the
+// test unless it succeeds.
+func request(t *testing.T, srv *server.Server, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+	if rec.Code >= 300 {
+		t.Fatalf("%s %s: %d %s", method, path, rec.Code, rec.Body)
+	}
+	return rec
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func statsCmd(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "write the statistics as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 stats [flags] document.xml")
+		fmt.Fprintln(os.Stderr, `
+Reports the questionnaires, questions, test actions, variables and
+artifacts of a document, the reference systems its top-level
+questionnaires cover, and for each of them the depth of its logic
+and the number of questions an assessor faces in the worst case and
+typically, when every answer is equally likely.`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	doc, err := ocil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	s, err := ocil.DocumentStats(doc)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	} else {
+		writeStats(w, s)
+	}
+	return w.Flush()
+}
+
+func writeStats(w io.Writer, s *ocil.Stats) {
+	counts := func(m map[string]int, keys ...string) string {
+		total := 0
+		var list string
+		for _, k := range keys {
+			total += m[k]
+			list += fmt.Sprintf(", %d %s", m[k], k)
+		}
+		return fmt.Sprintf("%d (%s)", total, list[2:])
+	}
+	fmt.Fprintf(w, "questionnaires  %d (%d top-level, %d child only)\n", s.Questionnaires, s.TopLevel, s.ChildOnly)
+	fmt.Fprintf(w, "questions       %s\n", counts(s.Questions, "boolean", "choice", "numeric", "string"))
+	fmt.Fprintf(w, "test actions    %s\n", counts(s.TestActions, "boolean", "choice", "numeric", "string"))
+	fmt.Fprintf(w, "variables       %s\n", counts(s.Variables, "constant", "external", "local"))
+	fmt.Fprintf(w, "artifacts       %d (%d required)\n", s.Artifacts, s.RequiredArtifacts)
+	fmt.Fprintf(w, "max depth       %d\n", s.MaxDepth)
+
+	n := len(s.PerQuestionnaire)
+	percent := func(k int) float64 {
+		if n == 0 {
+			return 0
+		}
+		return 100 * float64(k) / float64(n)
+	}
+	fmt.Fprintf(w, "\nreference coverage of %d questionnaires:\n", n)
+	for _, c := range s.Coverage {
+		fmt.Fprintf(w, "  %-12s %5d %6.1f%%  %d identifiers\n", c.System, c.Questionnaires, percent(c.Questionnaires), c.Identifiers)
+	}
+	fmt.Fprintf(w, "  %-12s %5d %6.1f%%\n", "none", s.Unreferenced, percent(s.Unreferenced))
+
+	fmt.Fprintf(w, "\n  %5s %5s %7s  %s\n", "depth", "worst", "typical", "questionnaire")
+	for _, q := range s.PerQuestionnaire {
+		name := string(q.ID)
+		if q.Title != "" {
+			name += " " + q.Title
+		}
+		fmt.Fprintf(w, "  %5d %5d %7.1f  %s\n", q.Depth, q.WorstCase, q.Typical, name)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// varFlags are the flags supplying values of external variables.
+type varFlags struct {
+	files     []string
+	assigns   []string
+	benchmark string
+	tailoring string
+	profile   string
+}
+
+func addVarFlags(fs *flag.FlagSet) *varFlags {
+	f := new(varFlags)
+	fs.Func("vars", "read external variable values, one id=value per line, from `file` (repeatable)", func(s string) error {
+		f.files = append(f.files, s)
+		return nil
+	})
+	fs.Func("var", "set an external variable: `id=value` (repeatable)", func(s string) error {
+		if _, _, err := ocil.ParseVariable(s); err != nil {
+			return err
+		}
+		f.assigns = append(f.assigns, s)
+		return nil
+	})
+	fs.StringVar(&f.benchmark, "benchmark", "", "take external variable values from the check-exports of an XCCDF `benchmark`")
+	fs.StringVar(&f.tailoring, "tailoring", "", "XCCDF tailoring `file` holding the profile given by -profile")
+	fs.StringVar(&f.profile, "profile", "", "select benchmark rules and values with the XCCDF profile `id`")
+	return f
+}
+
+// load reads the values in order of increasing precedence:
+// benchmark, files, assignments. The benchmark gives the exports of
+// the checks that refer to the document indexed by x, read from the
+// named file. The values are checked against the external variables
+// of the document.
+func (f *varFlags) load(x *ocil.Index, name string) (ocil.Variables, error) {
+	var layers []ocil.Variables
+	if f.benchmark != "" {
+		b, err := ocil.ReadBenchmarkFile(f.benchmark)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.useProfile(b); err != nil {
+			return nil, err
+		}
+		vars, err := b.ExternalVariables(x, name)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, vars)
+	}
+	for _, name := range f.files {
+		vars, err := ocil.ReadVariablesFile(name)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, vars)
+	}
+	cmdline := make(ocil.Variables)
+	for _, s := range f.assigns {
+		id, v, _ := ocil.ParseVariable(s)
+		cmdline[id] = v
+	}
+	vars := ocil.MergeVariables(append(layers, cmdline)...)
+	if err := x.CheckExternals(vars); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// useProfile selects the profile given by the flags, if any.
+func (f *varFlags) useProfile(b *ocil.Benchmark) error {
+	if f.profile == "" {
+		return nil
+	}
+	var tailoring []ocil.XCCDFProfile
+	if f.tailoring != "" {
+		var err error
+		if tailoring, err = ocil.ReadTailoringFile(f.tailoring); err != nil {
+			return err
+		}
+	}
+	return b.UseProfile(f.profile, tailoring)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+func xccdfCmd(args []string) error {
+	fs := flag.NewFlagSet("xccdf", flag.ExitOnError)
+	tailoring := fs.String("tailoring", "", "XCCDF tailoring `file` holding the profile given by -profile")
+	profile := fs.String("profile", "", "select benchmark rules and values with the XCCDF profile `id`")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ocil3 xccdf [flags] benchmark.xml document.xml answers.xml")
+		fmt.Fprintln(os.Stderr, "\nEvaluates the OCIL checks of the selected rules of an XCCDF 1.2 benchmark\nand prints the XCCDF result of each rule.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 3 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	b, err := ocil.ReadBenchmarkFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	vf := varFlags{tailoring: *tailoring, profile: *profile}
+	if err := vf.useProfile(b); err != nil {
+		return err
+	}
+	doc, err := ocil.ReadFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	x, err := ocil.NewIndex(doc)
+	if err != nil {
+		return err
+	}
+	a, err := readAssessment(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tRESULT\tQUESTIONNAIRE")
+	for _, r := range b.EvaluateRules(x, fs.Arg(1), a.Answers) {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "ocil3: %v\n", r.Err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.RuleID, r.Result, r.Questionnaire)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,297 @@
+package postal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// cpeNamespaces are the reference hrefs that mark a questionnaire
+// reference as a CPE name the questionnaire applies to.
+var cpeNamespaces = map[string]bool{
+	"http://cpe.mitre.org/dictionary/2.0":           true,
+	"http://cpe.mitre.org/language/2.0":             true,
+	"http://scap.nist.gov/schema/cpe-extension/2.3": true,
+}
+
+// A PlatformTest is a CPE applicability language logical test: a
+// single CPE name, or the AND or OR of further tests, optionally
+// negated.
+type PlatformTest struct {
+	Name     string
+	Operator OperatorType
+	Tests    []*PlatformTest
+	Negate   bool
+}
+
+// Matches reports whether a target with the given platforms
+// satisfies the test. A name is satisfied by any platform it
+// matches under CPE name matching.
+func (t *PlatformTest) Matches(platforms []string) bool {
+	var ok bool
+	switch {
+	case t.Name != "":
+		for _, p := range platforms {
+			if MatchCPE(t.Name, p) {
+				ok = true
+				break
+			}
+		}
+	case t.Operator == OperatorOr:
+		for _, c := range t.Tests {
+			if c.Matches(platforms) {
+				ok = true
+				break
+			}
+		}
+	default:
+		ok = true
+		for _, c := range t.Tests {
+			if !c.Matches(platforms) {
+				ok = false
+				break
+			}
+		}
+	}
+	return ok != t.Negate
+}
+
+// String formats the test in the syntax read by ParsePlatformTest.
+func (t *PlatformTest) String() string {
+	var s string
+	if t.Name != "" {
+		s = t.Name
+	} else {
+		op := " AND "
+		if t.Operator == OperatorOr {
+			op = " OR "
+		}
+		parts := make([]string, len(t.Tests))
+		for i, c := range t.Tests {
+			parts[i] = c.String()
+		}
+		s = "(" + strings.Join(parts, op) + ")"
+	}
+	if t.Negate {
+		s = "NOT " + s
+	}
+	return s
+}
+
+// ParsePlatformTest parses an applicability expression: CPE names
+// combined with AND, OR and NOT, grouped with parentheses. AND
+// binds tighter than OR.
+func ParsePlatformTest(s string) (*PlatformTest, error) {
+	p := &platformParser{toks: strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s))}
+	t, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if len(p.toks) > 0 {
+		return nil, fmt.Errorf("unexpected %q in applicability expression", p.toks[0])
+	}
+	return t, nil
+}
+
+type platformParser struct {
+	toks []string
+}
+
+func (p *platformParser) peek(keyword string) bool {
+	return len(p.toks) > 0 && strings.EqualFold(p.toks[0], keyword)
+}
+
+func (p *platformParser) or() (*PlatformTest, error) {
+	return p.list(OperatorOr, "OR", p.and)
+}
+
+func (p *platformParser) and() (*PlatformTest, error) {
+	return p.list(OperatorAnd, "AND", p.not)
+}
+
+func (p *platformParser) list(op OperatorType, keyword string, next func() (*PlatformTest, error)) (*PlatformTest, error) {
+	t, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peek(keyword) {
+		return t, nil
+	}
+	t = &PlatformTest{Operator: op, Tests: []*PlatformTest{t}}
+	for p.peek(keyword) {
+		p.toks = p.toks[1:]
+		c, err := next()
+		if err != nil {
+			return nil, err
+		}
+		t.Tests = append(t.Tests, c)
+	}
+	return t, nil
+}
+
+func (p *platformParser) not() (*PlatformTest, error) {
+	if len(p.toks) == 0 {
+		return nil, fmt.Errorf("applicability expression ends early")
+	}
+	tok := p.toks[0]
+	p.toks = p.toks[1:]
+	switch {
+	case strings.EqualFold(tok, "NOT"):
+		t, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		t.Negate = !t.Negate
+		return t, nil
+	case tok == "(":
+		t, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing ) in applicability expression")
+		}
+		p.toks = p.toks[1:]
+		return t, nil
+	case strings.HasPrefix(strings.ToLower(tok), "cpe:"):
+		return &PlatformTest{Name: tok}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in applicability expression", tok)
+}
+
+// cpeComponents splits a CPE 2.2 URI or 2.3 formatted string into
+// its components, starting with the part. An empty component
+// matches anything.
+func cpeComponents(name string) []string {
+	var comps []string
+	switch lower := strings.ToLower(name); {
+	case strings.HasPrefix(lower, "cpe:2.3:"):
+		var cur strings.Builder
+		rest := lower[len("cpe:2.3:"):]
+		for i := 0; i < len(rest); i++ {
+			switch {
+			case rest[i] == '\\' && i+1 < len(rest):
+				i++
+				cur.WriteByte(rest[i])
+			case rest[i] == ':':
+				comps = append(comps, cur.String())
+				cur.Reset()
+			default:
+				cur.WriteByte(rest[i])
+			}
+		}
+		comps = append(comps, cur.String())
+		for i, c := range comps {
+			if c == "*" {
+				comps[i] = ""
+			}
+		}
+	case strings.HasPrefix(lower, "cpe:/"):
+		comps = strings.Split(lower[len("cpe:/"):], ":")
+	}
+	return comps
+}
+
+// MatchCPE reports whether the CPE name pattern matches platform:
+// every component given in pattern must equal, ignoring case, the
+// same component of platform. Both CPE 2.2 URIs and CPE 2.3
+// formatted strings are accepted.
+func MatchCPE(pattern, platform string) bool {
+	pc, tc := cpeComponents(pattern), cpeComponents(platform)
+	if pc == nil || tc == nil {
+		return false
+	}
+	for i, c := range pc {
+		if c == "" {
+			continue
+		}
+		if i >= len(tc) || tc[i] != c {
+			return false
+		}
+	}
+	return true
+}
+
+// An Applicability maps questionnaires to the platforms they apply
+// to.
+type Applicability map[QuestionnaireIDPattern]*PlatformTest
+
+// Applies reports whether the questionnaire applies to a target
+// with the given platforms. Questionnaires without an entry apply
+// to every target.
+func (a Applicability) Applies(id QuestionnaireIDPattern, platforms []string) bool {
+	t, ok := a[id]
+	return !ok || t.Matches(platforms)
+}
+
+// referenceApplicability collects the CPE names among the
+// references of each questionnaire. A questionnaire applies to
+// any of the platforms it names.
+func referenceApplicability(doc *OCILType) Applicability {
+	a := make(Applicability)
+	for _, q := range doc.Questionnaires.Questionnaire {
+		var tests []*PlatformTest
+		for _, r := range q.References.Reference {
+			if cpeNamespaces[r.Href] {
+				tests = append(tests, &PlatformTest{Name: strings.TrimSpace(r.Value)})
+			}
+		}
+		if len(tests) == 1 {
+			a[q.Id] = tests[0]
+		} else if len(tests) > 1 {
+			a[q.Id] = &PlatformTest{Operator: OperatorOr, Tests: tests}
+		}
+	}
+	return a
+}
+
+// ReadApplicability reads a mapping of questionnaires to
+// applicability expressions. Each line holds a questionnaire ID
+// followed by an expression in the syntax of ParsePlatformTest.
+// Blank lines and lines starting with # are ignored.
+func ReadApplicability(r io.Reader) (Applicability, error) {
+	a := make(Applicability)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing applicability expression", n)
+		}
+		t, err := ParsePlatformTest(line[i:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		a[QuestionnaireIDPattern(line[:i])] = t
+	}
+	return a, s.Err()
+}
+
+// TargetPlatforms maps target names to the CPE names of their
+// platforms.
+type TargetPlatforms map[string][]string
+
+// ReadTargetPlatforms reads the platforms of targets. Each line
+// holds a target name followed by the CPE names of its platforms; a
+// target named on several lines has the platforms of all of them.
+// Blank lines and lines starting with # are ignored.
+func ReadTargetPlatforms(r io.Reader) (TargetPlatforms, error) {
+	tp := make(TargetPlatforms)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) < 2 {
+			return nil, fmt.Errorf("line %d: missing platforms of target %s", n, f[0])
+		}
+		tp[f[0]] = append(tp[f[0]], f[1:]...)
+	}
+	return tp, s.Err()
+}
@@ -0,0 +1,30 @@
+package postal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadTargetPlatforms(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TargetPlatforms
+		wantErr bool
+	}{
+		{"", TargetPlatforms{}, false},
+		{"# comment\n\nweb1 cpe:/o:a:b\n", TargetPlatforms{"web1": {"cpe:/o:a:b"}}, false},
+		{"web1 cpe:/o:a:b cpe:/a:c:d\nweb1\tcpe:/a:e:f\n", TargetPlatforms{"web1": {"cpe:/o:a:b", "cpe:/a:c:d", "cpe:/a:e:f"}}, false},
+		{"web1\n", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ReadTargetPlatforms(strings.NewReader(tt.in))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: error %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
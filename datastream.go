@@ -0,0 +1,246 @@
+package postal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DataStreamNamespace is the namespace of SCAP 1.2 and 1.3 source
+// data streams.
+const DataStreamNamespace = "http://scap.nist.gov/schema/scap/source/1.2"
+
+// A DataStreamCollection is a SCAP source data stream collection:
+// the data streams it declares and the components they refer to.
+type DataStreamCollection struct {
+	ID         string
+	Streams    []DataStream
+	Components []*Component
+}
+
+// A DataStream lists the components making up one stream, by role.
+type DataStream struct {
+	ID           string         `xml:"id,attr"`
+	Dictionaries []ComponentRef `xml:"dictionaries>component-ref"`
+	Checklists   []ComponentRef `xml:"checklists>component-ref"`
+	Checks       []ComponentRef `xml:"checks>component-ref"`
+}
+
+// Refs returns the component references of the stream, in the
+// order dictionaries, checklists, checks.
+func (s *DataStream) Refs() []ComponentRef {
+	var refs []ComponentRef
+	refs = append(refs, s.Dictionaries...)
+	refs = append(refs, s.Checklists...)
+	return append(refs, s.Checks...)
+}
+
+// A ComponentRef points to a component of the collection. Its
+// catalog maps the names the component uses for other content,
+// such as the href of an XCCDF check, to further component
+// references.
+type ComponentRef struct {
+	ID      string       `xml:"id,attr"`
+	Href    string       `xml:"http://www.w3.org/1999/xlink href,attr"`
+	Catalog []CatalogURI `xml:"urn:oasis:names:tc:entity:xmlns:xml:catalog catalog>uri"`
+}
+
+// A CatalogURI maps a name to a URI, normally "#" followed by the
+// ID of a component reference.
+type CatalogURI struct {
+	Name string `xml:"name,attr"`
+	URI  string `xml:"uri,attr"`
+}
+
+// A Component is one piece of SCAP content embedded in a
+// collection, such as an XCCDF benchmark or an OCIL document.
+type Component struct {
+	ID        string
+	Timestamp string
+	root      *node
+}
+
+// Name returns the name of the component's root element.
+func (c *Component) Name() xml.Name {
+	return c.root.name
+}
+
+// IsOCIL reports whether the component is an OCIL 2.0 document.
+func (c *Component) IsOCIL() bool {
+	return c.root.name == xml.Name{Space: Namespace, Local: "ocil"}
+}
+
+// Write writes the component's content as a standalone XML
+// document. Element-only content is indented, but the text of the
+// component is written as it is in the collection.
+func (c *Component) Write(w io.Writer) error {
+	return newTreePrinter(w, "  ").print(c.root)
+}
+
+// Document decodes the component as an OCIL document.
+func (c *Component) Document() (*OCILType, error) {
+	if !c.IsOCIL() {
+		return nil, fmt.Errorf("component %s is {%s}%s, not an OCIL document", c.ID, c.root.name.Space, c.root.name.Local)
+	}
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		return nil, err
+	}
+	return ReadDocument(&buf)
+}
+
+// ReadDataStream reads a source data stream collection.
+func ReadDataStream(r io.Reader) (*DataStreamCollection, error) {
+	d := xml.NewDecoder(r)
+	var c *DataStreamCollection
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if c == nil {
+			if start.Name != (xml.Name{Space: DataStreamNamespace, Local: "data-stream-collection"}) {
+				return nil, fmt.Errorf("root element is {%s}%s, want {%s}data-stream-collection", start.Name.Space, start.Name.Local, DataStreamNamespace)
+			}
+			c = &DataStreamCollection{ID: attr(start, "id")}
+			continue
+		}
+		switch {
+		case start.Name == xml.Name{Space: DataStreamNamespace, Local: "data-stream"}:
+			var s DataStream
+			if err := d.DecodeElement(&s, &start); err != nil {
+				return nil, err
+			}
+			c.Streams = append(c.Streams, s)
+		case start.Name == xml.Name{Space: DataStreamNamespace, Local: "component"}:
+			comp := &Component{ID: attr(start, "id"), Timestamp: attr(start, "timestamp")}
+			if comp.root, err = parseTree(d); err != nil {
+				return nil, fmt.Errorf("component %s: %v", comp.ID, err)
+			}
+			c.Components = append(c.Components, comp)
+			if err := d.Skip(); err != nil {
+				return nil, err
+			}
+		default:
+			if err := d.Skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c == nil {
+		return nil, fmt.Errorf("no data-stream-collection element")
+	}
+	return c, nil
+}
+
+// ReadDataStreamFile reads the source data stream collection in the
+// named file.
+func ReadDataStreamFile(name string) (*DataStreamCollection, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := ReadDataStream(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return c, nil
+}
+
+func attr(start xml.StartElement, local string) string {
+	for _, a := range start.Attr {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Component returns the component with the given ID.
+func (c *DataStreamCollection) Component(id string) (*Component, bool) {
+	for _, comp := range c.Components {
+		if comp.ID == id {
+			return comp, true
+		}
+	}
+	return nil, false
+}
+
+// OCILComponents returns the components that are OCIL documents.
+func (c *DataStreamCollection) OCILComponents() []*Component {
+	var out []*Component
+	for _, comp := range c.Components {
+		if comp.IsOCIL() {
+			out = append(out, comp)
+		}
+	}
+	return out
+}
+
+// Refs returns the component references, across all streams, that
+// point to the component with the given ID.
+func (c *DataStreamCollection) Refs(id string) []ComponentRef {
+	var out []ComponentRef
+	for i := range c.Streams {
+		for _, r := range c.Streams[i].Refs() {
+			if r.Href == "#"+id {
+				out = append(out, r)
+			}
+		}
+	}
+	return out
+}
+
+// Ref returns the component reference with the given ID.
+func (c *DataStreamCollection) Ref(id string) (ComponentRef, bool) {
+	for i := range c.Streams {
+		for _, r := range c.Streams[i].Refs() {
+			if r.ID == id {
+				return r, true
+			}
+		}
+	}
+	return ComponentRef{}, false
+}
+
+// Resolve returns the component a reference points to. Only
+// references within the collection are supported.
+func (c *DataStreamCollection) Resolve(ref ComponentRef) (*Component, error) {
+	if !strings.HasPrefix(ref.Href, "#") {
+		return nil, fmt.Errorf("component-ref %s: external reference %q not supported", ref.ID, ref.Href)
+	}
+	comp, ok := c.Component(ref.Href[1:])
+	if !ok {
+		return nil, fmt.Errorf("component-ref %s: no component %s", ref.ID, ref.Href[1:])
+	}
+	return comp, nil
+}
+
+// ResolveCatalog returns the component that the catalog of ref
+// maps name to, such as the OCIL document named by the href of an
+// XCCDF check within the benchmark ref points to.
+func (c *DataStreamCollection) ResolveCatalog(ref ComponentRef, name string) (*Component, error) {
+	for _, u := range ref.Catalog {
+		if u.Name != name {
+			continue
+		}
+		id := strings.TrimPrefix(u.URI, "#")
+		r, ok := c.Ref(id)
+		if !ok {
+			return nil, fmt.Errorf("catalog of %s: no component-ref %s", ref.ID, id)
+		}
+		return c.Resolve(r)
+	}
+	return nil, fmt.Errorf("catalog of %s has no entry for %s", ref.ID, name)
+}
@@ -0,0 +1,54 @@
+package postal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestComponentWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"short", "Check the policy."},
+		{"long line", strings.Repeat("The text of a description longer than one line of output. ", 3)},
+		{"line breaks", "First paragraph,\n   indented.\n\n  Second   paragraph."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var src bytes.Buffer
+			src.WriteString(`<ds:data-stream-collection xmlns:ds="http://scap.nist.gov/schema/scap/source/1.2" id="scap_t_collection_1">
+<ds:data-stream id="scap_t_datastream_1"/>
+<ds:component id="scap_t_comp_ocil.xml" timestamp="2020-01-01T00:00:00">
+<ocil xmlns="http://scap.nist.gov/schema/ocil/2.0"><document><title>T</title><description>`)
+			textEscaper.WriteString(&src, tt.text)
+			src.WriteString(`</description></document></ocil>
+</ds:component>
+</ds:data-stream-collection>`)
+			c, err := ReadDataStream(&src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(c.Components) != 1 {
+				t.Fatalf("%d components, want 1", len(c.Components))
+			}
+			var out bytes.Buffer
+			if err := c.Components[0].Write(&out); err != nil {
+				t.Fatal(err)
+			}
+			var want strings.Builder
+			textEscaper.WriteString(&want, tt.text)
+			if !strings.Contains(out.String(), "<description>"+want.String()+"</description>") {
+				t.Errorf("description rewritten:\n%s", out.String())
+			}
+			doc, err := c.Components[0].Document()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := doc.Document.Description; len(got) != 1 || got[0] != tt.text {
+				t.Errorf("description %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package postal
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of item compared by Diff, in the order changes are
+// reported.
+const (
+	KindQuestionnaire = "questionnaire"
+	KindTestAction    = "test_action"
+	KindQuestion      = "question"
+	KindChoice        = "choice"
+	KindVariable      = "variable"
+)
+
+var diffKinds = []string{KindQuestionnaire, KindTestAction, KindQuestion, KindChoice, KindVariable}
+
+// Operations of a Change.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// A Change records an item added, removed or changed between two
+// revisions of a document. Items are matched by ID.
+type Change struct {
+	Kind   string        `json:"kind"`
+	ID     string        `json:"id"`
+	Op     string        `json:"op"`
+	Fields []FieldChange `json:"fields,omitempty"`
+	// OldRevision and NewRevision are the revision attributes of
+	// a changed item; NoRevisionBump is set when its content
+	// changed but its revision did not increase. Choices have no
+	// revision.
+	OldRevision    int  `json:"old_revision,omitempty"`
+	NewRevision    int  `json:"new_revision,omitempty"`
+	NoRevisionBump bool `json:"no_revision_bump,omitempty"`
+}
+
+// A FieldChange is a difference in one field of an item. Fields
+// are named by their XML element or attribute path, such as
+// "when_true.result" or "actions.test_action_ref[1]"; an empty Old
+// or New means the field is absent.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// diffItem is an item flattened for comparison.
+type diffItem struct {
+	revision int
+	// hasRevision is false for choices.
+	hasRevision bool
+	fields      []FieldChange
+}
+
+// Diff compares the questionnaires, test actions, questions,
+// choices and variables of two documents. Changes are ordered by
+// kind and ID.
+func Diff(old, new *OCILType) []Change {
+	oldItems, newItems := diffItems(old), diffItems(new)
+	var changes []Change
+	for _, kind := range diffKinds {
+		var ids []string
+		for id := range oldItems[kind] {
+			ids = append(ids, id)
+		}
+		for id := range newItems[kind] {
+			if _, ok := oldItems[kind][id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			o, inOld := oldItems[kind][id]
+			n, inNew := newItems[kind][id]
+			switch {
+			case !inOld:
+				changes = append(changes, Change{Kind: kind, ID: id, Op: DiffAdded})
+			case !inNew:
+				changes = append(changes, Change{Kind: kind, ID: id, Op: DiffRemoved})
+			default:
+				fields := diffFields(o.fields, n.fields)
+				if len(fields) == 0 && o.revision == n.revision {
+					continue
+				}
+				c := Change{Kind: kind, ID: id, Op: DiffChanged, Fields: fields}
+				if o.hasRevision {
+					c.OldRevision, c.NewRevision = o.revision, n.revision
+					c.NoRevisionBump = len(fields) > 0 && n.revision <= o.revision
+				}
+				changes = append(changes, c)
+			}
+		}
+	}
+	return changes
+}
+
+// diffFields lists the fields whose values differ, in the order of
+// old and then of the fields only new has.
+func diffFields(old, new []FieldChange) []FieldChange {
+	newVals := make(map[string]string)
+	for _, f := range new {
+		newVals[f.Field] = f.New
+	}
+	seen := make(map[string]bool)
+	var out []FieldChange
+	for _, f := range old {
+		seen[f.Field] = true
+		if nv := newVals[f.Field]; nv != f.New {
+			out = append(out, FieldChange{Field: f.Field, Old: f.New, New: nv})
+		}
+	}
+	for _, f := range new {
+		if !seen[f.Field] {
+			out = append(out, FieldChange{Field: f.Field, New: f.New})
+		}
+	}
+	return out
+}
+
+func diffItems(doc *OCILType) map[string]map[string]diffItem {
+	items := make(map[string]map[string]diffItem)
+	for _, k := range diffKinds {
+		items[k] = make(map[string]diffItem)
+	}
+	add := func(kind, id string, v interface{}, hasRevision bool) {
+		it := diffItem{hasRevision: hasRevision}
+		rv := reflect.Indirect(reflect.ValueOf(v))
+		if hasRevision {
+			it.revision = int(rv.FieldByName("Revision").Int())
+		}
+		flattenFields(rv, "", &it.fields)
+		items[kind][id] = it
+	}
+	for i := range doc.Questionnaires.Questionnaire {
+		q := &doc.Questionnaires.Questionnaire[i]
+		add(KindQuestionnaire, string(q.Id), q, true)
+	}
+	for _, ta := range doc.Test_actions.All() {
+		add(KindTestAction, string(ta.TestActionID()), ta, true)
+	}
+	for _, q := range doc.Questions.All() {
+		add(KindQuestion, string(q.QuestionID()), q, true)
+		if cq, ok := q.(*ChoiceQuestionType); ok {
+			for _, c := range cq.InlineChoices() {
+				add(KindChoice, string(c.Id), c, false)
+			}
+		}
+	}
+	for _, g := range doc.Questions.Choice_group {
+		for i := range g.Choice {
+			add(KindChoice, string(g.Choice[i].Id), &g.Choice[i], false)
+		}
+	}
+	for i := range doc.Variables.Constant_variable {
+		v := &doc.Variables.Constant_variable[i]
+		add(KindVariable, string(v.Id), struct {
+			Kind string `xml:"kind"`
+			*ConstantVariableType
+		}{"constant", v}, true)
+	}
+	for i := range doc.Variables.External_variable {
+		v := &doc.Variables.External_variable[i]
+		add(KindVariable, string(v.Id), struct {
+			Kind string `xml:"kind"`
+			*ExternalVariableType
+		}{"external", v}, true)
+	}
+	for i := range doc.Variables.Local_variable {
+		v := &doc.Variables.Local_variable[i]
+		add(KindVariable, string(v.Id), struct {
+			Kind string `xml:"kind"`
+			*LocalVariableType
+		}{"local", v}, true)
+	}
+	return items
+}
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	questionTextType = reflect.TypeOf(QuestionTextType{})
+)
+
+// flattenFields appends the non-empty leaf values of v, named by
+// their XML paths below path, with each value in New. The revision
+// attribute of the item itself is left out.
+func flattenFields(v reflect.Value, path string, out *[]FieldChange) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			flattenFields(v.Elem(), path, out)
+		}
+		return
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Len() > 0 {
+				*out = append(*out, FieldChange{Field: path, New: fmt.Sprintf("%d bytes", v.Len())})
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			flattenFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), out)
+		}
+		return
+	case reflect.Struct:
+		switch v.Type() {
+		case timeType:
+			if t := v.Interface().(time.Time); !t.IsZero() {
+				*out = append(*out, FieldChange{Field: path, New: t.Format(time.RFC3339)})
+			}
+			return
+		case questionTextType:
+			*out = append(*out, FieldChange{Field: path, New: v.Interface().(QuestionTextType).String()})
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" || f.Name == "XMLName" || (path == "" && f.Name == "Revision") {
+				continue
+			}
+			p := path
+			if f.Anonymous {
+				flattenFields(v.Field(i), p, out)
+				continue
+			}
+			tag := f.Tag.Get("xml")
+			name := strings.Split(tag, ",")[0]
+			if i := strings.LastIndex(name, " "); i >= 0 {
+				name = name[i+1:]
+			}
+			switch {
+			case name == "-":
+				continue
+			case name != "":
+				p = joinPath(path, name)
+			case !strings.Contains(tag, ",chardata"):
+				p = joinPath(path, strings.ToLower(f.Name))
+			case path == "":
+				p = "value"
+			}
+			flattenFields(v.Field(i), p, out)
+		}
+		return
+	}
+	if v.IsZero() {
+		return
+	}
+	*out = append(*out, FieldChange{Field: path, New: fmt.Sprint(v.Interface())})
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,106 @@
+package postal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(doc *OCILType)
+		want   []Change
+	}{
+		{
+			name:   "identical",
+			change: func(doc *OCILType) {},
+		},
+		{
+			name: "question text without revision bump",
+			change: func(doc *OCILType) {
+				doc.Questions.String_question[0].Question_text = []QuestionTextType{PlainText("Who owns it?")}
+			},
+			want: []Change{{
+				Kind: KindQuestion, ID: "ocil:org.example:question:4", Op: DiffChanged,
+				Fields:         []FieldChange{{Field: "question_text[0]", Old: "Who owns the password store?", New: "Who owns it?"}},
+				NoRevisionBump: true,
+			}},
+		},
+		{
+			name: "result with revision bump",
+			change: func(doc *OCILType) {
+				ta := &doc.Test_actions.Boolean_question_test_action[1]
+				ta.When_false.Result = ResultError
+				ta.Revision = 1
+			},
+			want: []Change{{
+				Kind: KindTestAction, ID: "ocil:org.example:testaction:5", Op: DiffChanged,
+				Fields:      []FieldChange{{Field: "when_false.result", Old: "FAIL", New: "ERROR"}},
+				NewRevision: 1,
+			}},
+		},
+		{
+			name: "revision bump alone",
+			change: func(doc *OCILType) {
+				doc.Questionnaires.Questionnaire[1].Revision = 2
+			},
+			want: []Change{{Kind: KindQuestionnaire, ID: "ocil:org.example:questionnaire:2", Op: DiffChanged, NewRevision: 2}},
+		},
+		{
+			name: "choice text",
+			change: func(doc *OCILType) {
+				doc.Questions.Choice_group[0].Choice[1].Value = "Clear text"
+			},
+			want: []Change{{
+				Kind: KindChoice, ID: "ocil:org.example:choice:3", Op: DiffChanged,
+				Fields: []FieldChange{{Field: "value", Old: "Plain text", New: "Clear text"}},
+			}},
+		},
+		{
+			name: "added and removed",
+			change: func(doc *OCILType) {
+				doc.Variables.External_variable = nil
+				doc.Variables.Constant_variable = append(doc.Variables.Constant_variable, ConstantVariableType{
+					Id: "ocil:org.example:variable:2", Datatype: DatatypeNumeric, Value: "14",
+				})
+			},
+			want: []Change{
+				{Kind: KindVariable, ID: "ocil:org.example:variable:1", Op: DiffRemoved},
+				{Kind: KindVariable, ID: "ocil:org.example:variable:2", Op: DiffAdded},
+			},
+		},
+		{
+			name: "kinds in order",
+			change: func(doc *OCILType) {
+				doc.Questions.Boolean_question[1].Notes = []string{"New note"}
+				doc.Questionnaires.Questionnaire[0].Title.Value = "Passwords"
+			},
+			want: []Change{
+				{
+					Kind: KindQuestionnaire, ID: "ocil:org.example:questionnaire:1", Op: DiffChanged,
+					Fields:         []FieldChange{{Field: "title", Old: "Password policy", New: "Passwords"}},
+					NoRevisionBump: true,
+				},
+				{
+					Kind: KindQuestion, ID: "ocil:org.example:question:5", Op: DiffChanged,
+					Fields:         []FieldChange{{Field: "notes[0]", New: "New note"}},
+					NoRevisionBump: true,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		old, err := ReadFile("testdata/sample.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		new, err := ReadFile("testdata/sample.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.change(new)
+		if got := Diff(old, new); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package postal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNotOCIL is returned, wrapped, for a document whose root element
+// is not ocil in the OCIL 2.0 namespace.
+var ErrNotOCIL = errors.New("not an OCIL 2.0 document")
+
+// ReadDocument decodes an OCIL 2.0 document. It rejects documents
+// whose root element is not ocil in the OCIL 2.0 namespace, such as
+// OCIL 1.x content.
+func ReadDocument(r io.Reader) (*OCILType, error) {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Space != Namespace || start.Name.Local != "ocil" {
+			return nil, fmt.Errorf("%w: root element is {%s}%s", ErrNotOCIL, start.Name.Space, start.Name.Local)
+		}
+		var doc OCILType
+		if err := d.DecodeElement(&doc, &start); err != nil {
+			return nil, err
+		}
+		return &doc, nil
+	}
+}
+
+// ReadFile decodes the OCIL document in the named file.
+func ReadFile(name string) (*OCILType, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	doc, err := ReadDocument(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return doc, nil
+}
+
+// WriteDocument encodes doc as an indented ocil element. Empty
+// optional elements, which the generated types cannot omit, are
+// left out. Text, including its line breaks and indentation, is
+// written as it is.
+func WriteDocument(w io.Writer, doc *OCILType) error {
+	return writeElement(w, doc, "ocil")
+}
+
+// WriteFile encodes doc into the named file.
+func WriteFile(name string, doc *OCILType) error {
+	var buf bytes.Buffer
+	if err := WriteDocument(&buf, doc); err != nil {
+		return err
+	}
+	return os.WriteFile(name, buf.Bytes(), 0644)
+}
+
+// writeElement marshals v as an element named local in the OCIL
+// namespace and tidies the output.
+func writeElement(w io.Writer, v interface{}, local string) error {
+	root, err := elementTree(v, local)
+	if err != nil {
+		return err
+	}
+	return newTreePrinter(w, "  ").print(root)
+}
+
+// elementTree marshals v as an element named local in the OCIL
+// namespace and returns it as a tree without empty optional
+// elements, its children in schema order.
+func elementTree(v interface{}, local string) (*node, error) {
+	var buf bytes.Buffer
+	start := xml.StartElement{Name: xml.Name{Space: Namespace, Local: local}}
+	if err := xml.NewEncoder(&buf).EncodeElement(v, start); err != nil {
+		return nil, err
+	}
+	root, err := parseTree(xml.NewDecoder(&buf))
+	if err != nil {
+		return nil, err
+	}
+	pruneOptional(root)
+	schemaOrder(root)
+	return root, nil
+}
@@ -0,0 +1,122 @@
+package postal
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteDocumentText(t *testing.T) {
+	const head = `<ocil xmlns="http://scap.nist.gov/schema/ocil/2.0"><generator><schema_version>2.0</schema_version><timestamp>2020-01-01T00:00:00</timestamp></generator>`
+	tests := []struct {
+		name string
+		in   string
+		// text is written back exactly as it appears in in.
+		text string
+	}{
+		{
+			name: "command listing",
+			in:   head + `<questions><boolean_question id="ocil:a:question:1"><question_text>%s</question_text></boolean_question></questions></ocil>`,
+			text: "Run:\n    grep PASS_MIN_LEN /etc/login.defs\nIs a value set?",
+		},
+		{
+			name: "long line",
+			in:   head + `<questions><boolean_question id="ocil:a:question:1"><question_text>%s</question_text></boolean_question></questions></ocil>`,
+			text: strings.Repeat("word ", 30) + "end",
+		},
+		{
+			name: "paragraphs with substitution",
+			in:   head + `<questions><numeric_question id="ocil:a:question:1"><question_text>%s <sub var_ref="ocil:a:variable:1"/>.</question_text></numeric_question></questions></ocil>`,
+			text: "First paragraph\n  indented.\n\nMinimum:",
+		},
+		{
+			name: "description",
+			in:   head + `<document><title>T</title><description>%s</description></document></ocil>`,
+			text: "Check content:\n\n1. Open the file.\n2. Look for the line\n\n    PASS_MIN_LEN 14",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := strings.Replace(tt.in, "%s", tt.text, 1)
+			doc, err := ReadDocument(strings.NewReader(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := WriteDocument(&buf, doc); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), ">"+tt.text) {
+				t.Errorf("text not kept:\n%s", buf.String())
+			}
+			again, err := ReadDocument(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(again.Questions, doc.Questions) || !reflect.DeepEqual(again.Document, doc.Document) {
+				t.Errorf("document changed by writing it:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteDocumentEmptyElements(t *testing.T) {
+	const head = `<ocil xmlns="http://scap.nist.gov/schema/ocil/2.0"><generator><schema_version>2.0</schema_version><timestamp>2020-01-01T00:00:00</timestamp></generator>`
+	tests := []struct {
+		name string
+		in   string
+		// want are fragments expected in the output, and omit
+		// fragments not expected.
+		want []string
+		omit []string
+	}{
+		{
+			name: "empty string answer",
+			in:   head + `<results><question_results><string_question_result question_ref="ocil:a:question:1" response="ANSWERED"><answer></answer></string_question_result></question_results></results></ocil>`,
+			want: []string{"response=\"ANSWERED\">\n        <answer/>\n      </string_question_result>"},
+		},
+		{
+			name: "unset handlers",
+			in:   head + `<test_actions><boolean_question_test_action id="ocil:a:testaction:1" question_ref="ocil:a:question:1"><when_true><result>PASS</result></when_true><when_false><result>FAIL</result></when_false></boolean_question_test_action></test_actions></ocil>`,
+			want: []string{"<when_true>\n        <result>PASS</result>\n      </when_true>"},
+			omit: []string{"<when_unknown", "<test_action_ref", "<when_error"},
+		},
+		{
+			name: "question without instructions",
+			in:   head + `<questions><boolean_question id="ocil:a:question:1"><question_text>Q</question_text></boolean_question></questions></ocil>`,
+			want: []string{"<question_text>Q</question_text>"},
+			omit: []string{"<instructions", "<title"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ReadDocument(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := WriteDocument(&buf, doc); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("output lacks %q:\n%s", w, out)
+				}
+			}
+			for _, o := range tt.omit {
+				if strings.Contains(out, o) {
+					t.Errorf("output has %q:\n%s", o, out)
+				}
+			}
+			again, err := ReadDocument(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := again.Results.Answers(), doc.Results.Answers(); !reflect.DeepEqual(got, want) {
+				t.Errorf("answers %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package postal
+
+// Values of ResultType.
+const (
+	ResultPass          ResultType = "PASS"
+	ResultFail          ResultType = "FAIL"
+	ResultError         ResultType = "ERROR"
+	ResultUnknown       ResultType = "UNKNOWN"
+	ResultNotTested     ResultType = "NOT_TESTED"
+	ResultNotApplicable ResultType = "NOT_APPLICABLE"
+)
+
+// Values of UserResponseType. Every value other than
+// ResponseAnswered is also an ExceptionalResultType.
+const (
+	ResponseAnswered      UserResponseType = "ANSWERED"
+	ResponseUnknown       UserResponseType = "UNKNOWN"
+	ResponseError         UserResponseType = "ERROR"
+	ResponseNotTested     UserResponseType = "NOT_TESTED"
+	ResponseNotApplicable UserResponseType = "NOT_APPLICABLE"
+)
+
+// Values of OperatorType. An empty OperatorType is treated as
+// OperatorAnd, the schema default.
+const (
+	OperatorAnd OperatorType = "AND"
+	OperatorOr  OperatorType = "OR"
+)
+
+// Values of VariableDataType.
+const (
+	DatatypeText    VariableDataType = "TEXT"
+	DatatypeNumeric VariableDataType = "NUMERIC"
+)
+
+// Values of BooleanQuestionModelType.
+const (
+	ModelYesNo     BooleanQuestionModelType = "MODEL_YES_NO"
+	ModelTrueFalse BooleanQuestionModelType = "MODEL_TRUE_FALSE"
+)
+
+// Negate swaps PASS and FAIL. Other results are returned
+// unchanged.
+func (r ResultType) Negate() ResultType {
+	switch r {
+	case ResultPass:
+		return ResultFail
+	case ResultFail:
+		return ResultPass
+	}
+	return r
+}
@@ -0,0 +1,292 @@
+package postal
+
+import (
+	"fmt"
+	"time"
+)
+
+// An AnswerSource supplies the answer to a question the first time
+// an evaluation needs it.
+type AnswerSource interface {
+	Answer(q Question) (Answer, error)
+}
+
+// Answer implements AnswerSource. Questions without a recorded
+// answer are NOT_TESTED.
+func (a Answers) Answer(q Question) (Answer, error) {
+	if ans, ok := a[q.QuestionID()]; ok {
+		return ans, nil
+	}
+	return Answer{Response: ResponseNotTested}, nil
+}
+
+// variableChain looks a variable up in each source in turn.
+type variableChain []VariableSource
+
+func (c variableChain) Lookup(id VariableIDPattern) (string, bool) {
+	for _, s := range c {
+		if s == nil {
+			continue
+		}
+		if v, ok := s.Lookup(id); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// An Evaluator computes the results of questionnaires from
+// answers. A question is requested from the AnswerSource at most
+// once, and only when a test action on the evaluation path refers
+// to it.
+type Evaluator struct {
+	x    *Index
+	src  AnswerSource
+	vars VariableSource
+
+	platforms []string
+	answers   Answers
+	questions []QuestionIDPattern
+	results   map[TestActionRefValuePattern]ResultType
+	evaluated []TestActionRefValuePattern
+	visiting  map[TestActionRefValuePattern]bool
+}
+
+// NewEvaluator returns an evaluator over the indexed document.
+// Variables are resolved from the document's constants first and
+// then from vars, which may be nil.
+func NewEvaluator(x *Index, src AnswerSource, vars VariableSource) *Evaluator {
+	return &Evaluator{
+		x:        x,
+		src:      src,
+		vars:     variableChain{x, vars},
+		answers:  make(Answers),
+		results:  make(map[TestActionRefValuePattern]ResultType),
+		visiting: make(map[TestActionRefValuePattern]bool),
+	}
+}
+
+// SetPlatforms declares the platforms of the target. Questionnaires
+// that do not apply to them, according to the index's
+// Applicability, are NOT_APPLICABLE and none of their questions
+// are asked. Without declared platforms every questionnaire
+// applies.
+func (e *Evaluator) SetPlatforms(platforms []string) {
+	e.platforms = platforms
+}
+
+// Questionnaire evaluates the questionnaire with the given ID.
+func (e *Evaluator) Questionnaire(id QuestionnaireIDPattern) (ResultType, error) {
+	if _, ok := e.x.Questionnaires[id]; !ok {
+		return "", fmt.Errorf("unknown questionnaire %s", id)
+	}
+	return e.item(TestActionRefValuePattern(id))
+}
+
+func (e *Evaluator) ref(r TestActionRefType) (ResultType, error) {
+	res, err := e.item(r.TestActionRefValuePattern)
+	if r.Negate {
+		res = res.Negate()
+	}
+	return res, err
+}
+
+func (e *Evaluator) item(id TestActionRefValuePattern) (ResultType, error) {
+	if res, ok := e.results[id]; ok {
+		return res, nil
+	}
+	if e.visiting[id] {
+		return "", fmt.Errorf("test action cycle through %s", id)
+	}
+	e.visiting[id] = true
+	defer delete(e.visiting, id)
+
+	var res ResultType
+	var err error
+	if q, ok := e.x.Questionnaires[QuestionnaireIDPattern(id)]; ok {
+		if e.platforms != nil && !e.x.Applicability.Applies(q.Id, e.platforms) {
+			res = ResultNotApplicable
+		} else {
+			res, err = e.compound(q.Actions)
+		}
+	} else if ta, ok := e.x.TestActions[QuestionTestActionIDPattern(id)]; ok {
+		res, err = e.testAction(ta)
+	} else {
+		err = fmt.Errorf("unknown test action %s", id)
+	}
+	if err != nil {
+		return "", err
+	}
+	e.results[id] = res
+	e.evaluated = append(e.evaluated, id)
+	return res, nil
+}
+
+func (e *Evaluator) compound(op OperationType) (ResultType, error) {
+	var rs []ResultType
+	for _, r := range op.Test_action_ref {
+		res, err := e.ref(r)
+		if err != nil {
+			return "", err
+		}
+		rs = append(rs, res)
+	}
+	res := Combine(op.Operation, rs)
+	if op.Negate {
+		res = res.Negate()
+	}
+	return res, nil
+}
+
+func (e *Evaluator) testAction(ta TestAction) (ResultType, error) {
+	ans, err := e.answer(ta.QuestionRef())
+	if err != nil {
+		return "", err
+	}
+	for _, h := range ta.Handlers() {
+		ok, err := h.Condition.Matches(ans, e.vars)
+		if err != nil {
+			return "", fmt.Errorf("test action %s: %v", ta.TestActionID(), err)
+		}
+		if !ok {
+			continue
+		}
+		if h.Ref() != "" {
+			return e.ref(h.Test_action_ref)
+		}
+		return h.Result, nil
+	}
+	if ans.Response != ResponseAnswered {
+		// Without a handler an exceptional response becomes
+		// the result of the test action.
+		return ResultType(ans.Response), nil
+	}
+	return ResultError, nil
+}
+
+func (e *Evaluator) answer(id QuestionIDPattern) (Answer, error) {
+	if ans, ok := e.answers[id]; ok {
+		return ans, nil
+	}
+	q, ok := e.x.Questions[id]
+	if !ok {
+		return Answer{}, fmt.Errorf("unknown question %s", id)
+	}
+	ans, err := e.src.Answer(q)
+	if err != nil {
+		return Answer{}, err
+	}
+	if ans.Response == "" {
+		ans.Response = ResponseAnswered
+	}
+	e.answers[id] = ans
+	e.questions = append(e.questions, id)
+	return ans, nil
+}
+
+// Answers returns the answers obtained so far.
+func (e *Evaluator) Answers() Answers {
+	return e.answers
+}
+
+// Results returns the questionnaire, test action and question
+// results gathered so far, in evaluation order.
+func (e *Evaluator) Results() ResultsType {
+	var r ResultsType
+	for _, id := range e.evaluated {
+		res := e.results[id]
+		if _, ok := e.x.Questionnaires[QuestionnaireIDPattern(id)]; ok {
+			r.Questionnaire_results.Questionnaire_result = append(r.Questionnaire_results.Questionnaire_result,
+				QuestionnaireResultType{Questionnaire_ref: QuestionnaireIDPattern(id), Result: res})
+			continue
+		}
+		r.Test_action_results.Test_action_result = append(r.Test_action_results.Test_action_result,
+			TestActionResultType{Test_action_ref: id, Result: res})
+	}
+	qr := &r.Question_results
+	for _, id := range e.questions {
+		ans := e.answers[id]
+		switch e.x.Questions[id].(type) {
+		case *BooleanQuestionType:
+			qr.Boolean_question_result = append(qr.Boolean_question_result,
+				BooleanQuestionResultType{Question_ref: id, Response: ans.Response, Answer: ans.Boolean})
+		case *ChoiceQuestionType:
+			qr.Choice_question_result = append(qr.Choice_question_result,
+				ChoiceQuestionResultType{Question_ref: id, Response: ans.Response, Answer: ChoiceAnswerType{Choice_ref: ans.Choice}})
+		case *NumericQuestionType:
+			qr.Numeric_question_result = append(qr.Numeric_question_result,
+				NumericQuestionResultType{Question_ref: id, Response: ans.Response, Answer: ans.Numeric})
+		case *StringQuestionType:
+			qr.String_question_result = append(qr.String_question_result,
+				StringQuestionResultType{Question_ref: id, Response: ans.Response, Answer: ans.String})
+		}
+	}
+	return r
+}
+
+// Evaluate evaluates every questionnaire of the document that is
+// not child_only and returns the results, stamped with the start
+// and end time of the evaluation.
+func (x *Index) Evaluate(src AnswerSource, vars VariableSource) (*ResultsType, error) {
+	return x.evaluate(src, vars, nil)
+}
+
+func (x *Index) evaluate(src AnswerSource, vars VariableSource, platforms []string) (*ResultsType, error) {
+	start := time.Now()
+	e := NewEvaluator(x, src, vars)
+	e.SetPlatforms(platforms)
+	for _, q := range x.Doc.Questionnaires.Questionnaire {
+		if q.Child_only {
+			continue
+		}
+		if _, err := e.Questionnaire(q.Id); err != nil {
+			return nil, err
+		}
+	}
+	r := e.Results()
+	r.Start_time = start
+	r.End_time = time.Now()
+	return &r, nil
+}
+
+// Combine merges the results of the children of a compound test
+// action using the AND and OR tables documented on ResultType. An
+// empty operator is AND.
+func Combine(op OperatorType, rs []ResultType) ResultType {
+	n := make(map[ResultType]int)
+	for _, r := range rs {
+		n[r]++
+	}
+	if op == OperatorOr {
+		switch {
+		case n[ResultPass] > 0:
+			return ResultPass
+		case n[ResultError] > 0:
+			return ResultError
+		case n[ResultUnknown] > 0:
+			return ResultUnknown
+		case n[ResultNotTested] > 0:
+			return ResultNotTested
+		case n[ResultFail] > 0:
+			return ResultFail
+		case n[ResultNotApplicable] > 0:
+			return ResultNotApplicable
+		}
+		return ResultNotTested
+	}
+	switch {
+	case n[ResultFail] > 0:
+		return ResultFail
+	case n[ResultError] > 0:
+		return ResultError
+	case n[ResultUnknown] > 0:
+		return ResultUnknown
+	case n[ResultNotTested] > 0:
+		return ResultNotTested
+	case n[ResultPass] > 0:
+		return ResultPass
+	case n[ResultNotApplicable] > 0:
+		return ResultNotApplicable
+	}
+	return ResultNotTested
+}
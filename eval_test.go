@@ -0,0 +1,148 @@
+package postal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCombine(t *testing.T) {
+	const (
+		P  = ResultPass
+		F  = ResultFail
+		E  = ResultError
+		U  = ResultUnknown
+		NT = ResultNotTested
+		NA = ResultNotApplicable
+	)
+	tests := []struct {
+		rs      []ResultType
+		and, or ResultType
+	}{
+		{nil, NT, NT},
+		{[]ResultType{P}, P, P},
+		{[]ResultType{F}, F, F},
+		{[]ResultType{NA}, NA, NA},
+		{[]ResultType{P, P}, P, P},
+		{[]ResultType{P, F}, F, P},
+		{[]ResultType{P, E}, E, P},
+		{[]ResultType{P, U}, U, P},
+		{[]ResultType{P, NT}, NT, P},
+		{[]ResultType{P, NA}, P, P},
+		{[]ResultType{F, E}, F, E},
+		{[]ResultType{F, U}, F, U},
+		{[]ResultType{F, NT}, F, NT},
+		{[]ResultType{F, NA}, F, F},
+		{[]ResultType{E, U}, E, E},
+		{[]ResultType{E, NT}, E, E},
+		{[]ResultType{E, NA}, E, E},
+		{[]ResultType{U, NT}, U, U},
+		{[]ResultType{U, NA}, U, U},
+		{[]ResultType{NT, NA}, NT, NT},
+		{[]ResultType{NA, NA}, NA, NA},
+		{[]ResultType{NA, F, P, U}, F, P},
+	}
+	for _, tt := range tests {
+		for _, c := range []struct {
+			op   OperatorType
+			want ResultType
+		}{{"", tt.and}, {OperatorAnd, tt.and}, {OperatorOr, tt.or}} {
+			if got := Combine(c.op, tt.rs); got != c.want {
+				t.Errorf("Combine(%q, %v) = %s, want %s", c.op, tt.rs, got, c.want)
+			}
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	x := sampleIndex(t)
+	const (
+		q1 = "ocil:org.example:question:1"
+		q2 = "ocil:org.example:question:2"
+		q3 = "ocil:org.example:question:3"
+		q4 = "ocil:org.example:question:4"
+		q5 = "ocil:org.example:question:5"
+	)
+	yes := Answer{Response: ResponseAnswered, Boolean: true}
+	no := Answer{Response: ResponseAnswered, Boolean: false}
+	num := func(n float64) Answer { return Answer{Response: ResponseAnswered, Numeric: n} }
+	choice := func(n string) Answer {
+		return Answer{Response: ResponseAnswered, Choice: ChoiceIDPattern("ocil:org.example:choice:" + n)}
+	}
+	str := func(s string) Answer { return Answer{Response: ResponseAnswered, String: s} }
+	tests := []struct {
+		name    string
+		answers Answers
+		want    ResultType
+		asked   []QuestionIDPattern
+	}{
+		{
+			name:    "pass",
+			answers: Answers{q1: yes, q2: num(8), q3: choice("1")},
+			want:    ResultPass,
+			asked:   []QuestionIDPattern{q1, q2, q3},
+		},
+		{
+			name:    "length below the variable",
+			answers: Answers{q1: yes, q2: num(7), q3: choice("1")},
+			want:    ResultFail,
+			asked:   []QuestionIDPattern{q1, q2, q3},
+		},
+		{
+			name:    "no policy",
+			answers: Answers{q1: no, q3: choice("2")},
+			want:    ResultFail,
+			asked:   []QuestionIDPattern{q1, q3},
+		},
+		{
+			name:    "child questionnaire passes on the string",
+			answers: Answers{q1: yes, q2: num(12), q3: choice("3"), q4: str("root"), q5: yes},
+			want:    ResultPass,
+			asked:   []QuestionIDPattern{q1, q2, q3, q4, q5},
+		},
+		{
+			name:    "child questionnaire passes on the negated boolean",
+			answers: Answers{q1: yes, q2: num(12), q3: choice("3"), q4: str("admin"), q5: no},
+			want:    ResultPass,
+			asked:   []QuestionIDPattern{q1, q2, q3, q4, q5},
+		},
+		{
+			name:    "child questionnaire fails",
+			answers: Answers{q1: yes, q2: num(12), q3: choice("3"), q4: str("admin"), q5: yes},
+			want:    ResultFail,
+			asked:   []QuestionIDPattern{q1, q2, q3, q4, q5},
+		},
+		{
+			name:    "unanswered",
+			answers: Answers{q1: yes, q2: num(8)},
+			want:    ResultNotTested,
+			asked:   []QuestionIDPattern{q1, q2, q3},
+		},
+		{
+			name:    "unknown response",
+			answers: Answers{q1: {Response: ResponseUnknown}, q3: choice("1")},
+			want:    ResultUnknown,
+			asked:   []QuestionIDPattern{q1, q3},
+		},
+		{
+			name:    "not applicable response",
+			answers: Answers{q1: {Response: ResponseNotApplicable}, q3: choice("1")},
+			want:    ResultPass,
+			asked:   []QuestionIDPattern{q1, q3},
+		},
+	}
+	vars := Variables{"ocil:org.example:variable:1": "8"}
+	for _, tt := range tests {
+		e := NewEvaluator(x, tt.answers, vars)
+		got, err := e.Questionnaire("ocil:org.example:questionnaire:1")
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: result %s, want %s", tt.name, got, tt.want)
+		}
+		if asked := e.questions; !reflect.DeepEqual(asked, tt.asked) {
+			t.Errorf("%s: asked %v, want %v", tt.name, asked, tt.asked)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package postal
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Extract returns a document holding the questionnaires ids and
+// everything they refer to, directly or indirectly: child
+// questionnaires, test actions, questions, choice groups, variables
+// and artifacts. IDs are preserved, so results for the extract are
+// results for doc. The generator and document header are copied;
+// results are not.
+func Extract(doc *OCILType, ids []QuestionnaireIDPattern) (*OCILType, error) {
+	x, err := NewIndex(doc)
+	if err != nil {
+		return nil, err
+	}
+	// items maps the ID of each item to the item, and owner maps a
+	// choice to the question or choice group holding it.
+	items := make(map[string]interface{})
+	owner := make(map[string]string)
+	for id, q := range x.Questionnaires {
+		items[string(id)] = q
+	}
+	for id, ta := range x.TestActions {
+		items[string(id)] = ta
+	}
+	for id, q := range x.Questions {
+		items[string(id)] = q
+		if cq, ok := q.(*ChoiceQuestionType); ok {
+			for _, c := range cq.InlineChoices() {
+				owner[string(c.Id)] = string(id)
+			}
+		}
+	}
+	for id, g := range x.ChoiceGroups {
+		items[string(id)] = g
+		for _, c := range g.Choice {
+			owner[string(c.Id)] = string(id)
+		}
+	}
+	for i := range doc.Artifacts.Artifact {
+		a := &doc.Artifacts.Artifact[i]
+		items[string(a.Id)] = a
+	}
+	for id, v := range x.Constants {
+		items[string(id)] = v
+	}
+	for id, v := range x.Externals {
+		items[string(id)] = v
+	}
+	for id, v := range x.Locals {
+		items[string(id)] = v
+	}
+
+	need := make(map[string]bool)
+	var queue []string
+	visit := func(id string) string {
+		if o, ok := owner[id]; ok {
+			id = o
+		}
+		if !need[id] {
+			need[id] = true
+			queue = append(queue, id)
+		}
+		return id
+	}
+	for _, id := range ids {
+		if _, ok := x.Questionnaires[id]; !ok {
+			return nil, fmt.Errorf("unknown questionnaire %s", id)
+		}
+		visit(string(id))
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if it, ok := items[id]; ok {
+			mapIDs(reflect.ValueOf(it), func(ref string) string {
+				visit(ref)
+				return ref
+			})
+		}
+	}
+
+	out := CloneDocument(&OCILType{
+		Generator:      doc.Generator,
+		Document:       doc.Document,
+		Questionnaires: doc.Questionnaires,
+		Test_actions:   doc.Test_actions,
+		Questions:      doc.Questions,
+		Artifacts:      doc.Artifacts,
+		Variables:      doc.Variables,
+	})
+	for _, c := range []interface{}{&out.Questionnaires, &out.Test_actions, &out.Questions, &out.Artifacts, &out.Variables} {
+		keepItems(reflect.ValueOf(c).Elem(), need)
+	}
+	return out, nil
+}
+
+// keepItems removes from each list of items in the container c the
+// items whose IDs are not in keep, along with their place in the
+// order of the container.
+func keepItems(c reflect.Value, keep map[string]bool) {
+	if o, ok := c.Addr().Interface().(documentOrdered); ok {
+		var kept []item
+		for _, it := range o.documentItems() {
+			if keep[reflect.ValueOf(it.v).Elem().FieldByName("Id").String()] {
+				kept = append(kept, it)
+			}
+		}
+		o.setOrder(kept)
+	}
+	t := c.Type()
+	for i := 0; i < c.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Type.Kind() != reflect.Slice || f.Type.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		list := c.Field(i)
+		kept := reflect.Zero(list.Type())
+		for j := 0; j < list.Len(); j++ {
+			if it := list.Index(j); keep[it.FieldByName("Id").String()] {
+				kept = reflect.Append(kept, it)
+			}
+		}
+		list.Set(kept)
+	}
+}
@@ -0,0 +1,96 @@
+package postal
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []QuestionnaireIDPattern
+		// want lists the IDs of the items kept, in document order.
+		want []string
+		err  bool
+	}{
+		{
+			name: "questionnaire with child",
+			ids:  []QuestionnaireIDPattern{"ocil:org.example:questionnaire:1"},
+			want: []string{
+				"ocil:org.example:questionnaire:1", "ocil:org.example:questionnaire:2",
+				"ocil:org.example:testaction:1", "ocil:org.example:testaction:2", "ocil:org.example:testaction:3",
+				"ocil:org.example:testaction:4", "ocil:org.example:testaction:5",
+				"ocil:org.example:question:1", "ocil:org.example:question:2", "ocil:org.example:question:3",
+				"ocil:org.example:question:4", "ocil:org.example:question:5",
+				"ocil:org.example:choicegroup:1",
+				"ocil:org.example:artifact:1",
+				"ocil:org.example:variable:1",
+			},
+		},
+		{
+			name: "child questionnaire",
+			ids:  []QuestionnaireIDPattern{"ocil:org.example:questionnaire:2"},
+			want: []string{
+				"ocil:org.example:questionnaire:2",
+				"ocil:org.example:testaction:4", "ocil:org.example:testaction:5",
+				"ocil:org.example:question:4", "ocil:org.example:question:5",
+			},
+		},
+		{
+			name: "unknown questionnaire",
+			ids:  []QuestionnaireIDPattern{"ocil:org.example:questionnaire:9"},
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ReadFile("testdata/sample.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := Extract(doc, tt.ids)
+			if tt.err {
+				if err == nil {
+					t.Error("no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := WriteDocument(&buf, out); err != nil {
+				t.Fatal(err)
+			}
+			if out, err = ReadDocument(&buf); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, q := range out.Questionnaires.Questionnaire {
+				got = append(got, string(q.Id))
+			}
+			for _, ta := range out.Test_actions.All() {
+				got = append(got, string(ta.TestActionID()))
+			}
+			for _, q := range out.Questions.All() {
+				got = append(got, string(q.QuestionID()))
+			}
+			for _, g := range out.Questions.Choice_group {
+				got = append(got, string(g.Id))
+			}
+			for _, a := range out.Artifacts.Artifact {
+				got = append(got, string(a.Id))
+			}
+			for _, it := range out.Variables.documentItems() {
+				got = append(got, reflect.ValueOf(it.v).Elem().FieldByName("Id").String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %q, want %q", got, tt.want)
+			}
+			if _, err := NewIndex(out); err != nil {
+				t.Errorf("extract: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package postal
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Format rewrites the OCIL 2.0 document read from r into the
+// canonical form of WriteDocument: the OCIL namespace is the default
+// namespace and other namespaces have standard prefixes, child
+// elements are in the order the schema defines, attributes are
+// sorted by name and element-only content is indented by two spaces.
+// In addition, the text of descriptions, notices, notes and question
+// text is wrapped at 80 columns, which WriteDocument leaves as it is.
+//
+// Unlike a ReadDocument and WriteDocument round trip, Format keeps
+// content the generated types do not model, such as extension
+// elements, and comments; a comment moves with the element after
+// it. Elements the schema allows in any order, such as the members
+// of a substitution group, keep their relative order, and mixed
+// content is left as it is.
+func Format(w io.Writer, r io.Reader) error {
+	root, err := parseTree(xml.NewDecoder(r))
+	if err != nil {
+		return err
+	}
+	if root.name.Space != Namespace || root.name.Local != "ocil" {
+		return fmt.Errorf("root element is {%s}%s, want {%s}ocil", root.name.Space, root.name.Local, Namespace)
+	}
+	schemaOrder(root)
+	p := newTreePrinter(w, "  ")
+	p.wrap = true
+	return p.print(root)
+}
+
+//go:embed ocil-2.0.xsd
+var schemaXSD []byte
+
+const xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+
+// A contentModel gives the position of each child element of a
+// complex type, with the members of a substitution group sharing
+// the position of the reference to its head.
+type contentModel struct {
+	pos   map[string]int
+	types map[string]*node
+	// optional holds the elements that may be left out.
+	optional map[string]bool
+	// any is the position of elements of other namespaces, or -1.
+	any int
+	n   int
+}
+
+// xsdSchema holds the declarations of the OCIL schema needed to
+// order elements.
+type xsdSchema struct {
+	elements map[string]*node
+	types    map[string]*node
+	members  map[string][]string
+	models   map[*node]*contentModel
+}
+
+var (
+	schemaOnce sync.Once
+	schema     *xsdSchema
+)
+
+func loadSchema() {
+	root, err := parseTree(xml.NewDecoder(bytes.NewReader(schemaXSD)))
+	if err != nil {
+		panic("ocil: embedded schema: " + err.Error())
+	}
+	schema = &xsdSchema{
+		elements: make(map[string]*node),
+		types:    make(map[string]*node),
+		members:  make(map[string][]string),
+		models:   make(map[*node]*contentModel),
+	}
+	for _, k := range root.kids {
+		switch k.name.Local {
+		case "element":
+			name := k.attrValue("name")
+			schema.elements[name] = k
+			if head := k.attrValue("substitutionGroup"); head != "" {
+				head = localPart(head)
+				schema.members[head] = append(schema.members[head], name)
+			}
+		case "complexType":
+			schema.types[k.attrValue("name")] = k
+		}
+	}
+	// Build every content model now, so that they are only read
+	// afterwards, from any goroutine.
+	var build func(*node)
+	build = func(n *node) {
+		if n.name.Space == xsdNamespace && n.name.Local == "complexType" {
+			schema.model(n)
+		}
+		for _, k := range n.kids {
+			build(k)
+		}
+	}
+	build(root)
+}
+
+func (n *node) attrValue(local string) string {
+	for _, a := range n.attr {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func localPart(qname string) string {
+	return qname[strings.LastIndex(qname, ":")+1:]
+}
+
+// typeOf returns the complex type of an element declaration, or nil
+// for a simple type.
+func (s *xsdSchema) typeOf(decl *node) *node {
+	if t := decl.attrValue("type"); t != "" {
+		return s.types[localPart(t)]
+	}
+	for _, k := range decl.kids {
+		if k.name.Space == xsdNamespace && k.name.Local == "complexType" {
+			return k
+		}
+	}
+	return nil
+}
+
+func (s *xsdSchema) model(t *node) *contentModel {
+	if t == nil {
+		return nil
+	}
+	if m, ok := s.models[t]; ok {
+		return m
+	}
+	m := &contentModel{pos: make(map[string]int), types: make(map[string]*node), optional: make(map[string]bool), any: -1}
+	s.models[t] = m
+	s.walk(t, m, false)
+	return m
+}
+
+// walk adds the element particles below n to m in order, those of
+// the base type of an extension first. The particles of a repeated
+// choice or sequence, which may interleave, share one position.
+// Particles with minOccurs 0, the alternatives of a choice and the
+// particles inside either are optional, as are all of them when
+// optional is set.
+func (s *xsdSchema) walk(n *node, m *contentModel, optional bool) {
+	for _, k := range n.kids {
+		if k.name.Space != xsdNamespace {
+			continue
+		}
+		opt := optional || k.attrValue("minOccurs") == "0"
+		switch k.name.Local {
+		case "complexContent", "all":
+			s.walk(k, m, opt)
+		case "sequence", "choice":
+			start := m.n
+			s.walk(k, m, opt || k.name.Local == "choice")
+			if max := k.attrValue("maxOccurs"); max != "" && max != "1" && m.n > start {
+				for name, pos := range m.pos {
+					if pos >= start {
+						m.pos[name] = start
+					}
+				}
+				if m.any >= start {
+					m.any = start
+				}
+				m.n = start + 1
+			}
+		case "extension":
+			if base, ok := s.types[localPart(k.attrValue("base"))]; ok {
+				s.walk(base, m, opt)
+			}
+			s.walk(k, m, opt)
+		case "element":
+			if name := k.attrValue("name"); name != "" {
+				m.add(name, s.typeOf(k), opt)
+				continue
+			}
+			head := localPart(k.attrValue("ref"))
+			group := s.group(head)
+			for _, name := range group {
+				if _, ok := m.pos[name]; !ok {
+					m.pos[name] = m.n
+					m.types[name] = s.typeOf(s.elements[name])
+					m.optional[name] = opt
+				}
+			}
+			m.n++
+		case "any":
+			if m.any < 0 {
+				m.any = m.n
+				m.n++
+			}
+		}
+	}
+}
+
+// add gives the element name the next position, unless an earlier
+// particle of the same name has one.
+func (m *contentModel) add(name string, t *node, optional bool) {
+	if _, ok := m.pos[name]; ok {
+		return
+	}
+	m.pos[name] = m.n
+	m.types[name] = t
+	m.optional[name] = optional
+	m.n++
+}
+
+// group returns head and the elements that may substitute for it,
+// directly or indirectly.
+func (s *xsdSchema) group(head string) []string {
+	names := []string{head}
+	for _, name := range s.members[head] {
+		names = append(names, s.group(name)...)
+	}
+	return names
+}
+
+// schemaOrder sorts the descendants of an OCIL element into the
+// order of the schema. Elements not in the OCIL namespace, other
+// than those the schema allows in extension points, go last.
+func schemaOrder(root *node) {
+	schemaOnce.Do(loadSchema)
+	if root.name.Space != Namespace {
+		return
+	}
+	decl, ok := schema.elements[root.name.Local]
+	if !ok {
+		return
+	}
+	orderChildren(root, schema.model(schema.typeOf(decl)))
+}
+
+// pruneOptional removes the empty descendants of an OCIL element that
+// the schema allows to be left out.
+func pruneOptional(root *node) {
+	schemaOnce.Do(loadSchema)
+	if root.name.Space != Namespace {
+		return
+	}
+	if decl, ok := schema.elements[root.name.Local]; ok {
+		root.prune(schema.model(schema.typeOf(decl)))
+	}
+}
+
+// orderChildren sorts the child elements of n by their positions in
+// m, and does the same for their descendants. Comments and
+// character data move with the element that follows them.
+func orderChildren(n *node, m *contentModel) {
+	if m == nil || n.mixed() {
+		return
+	}
+	type unit struct {
+		kids []*node
+		pos  int
+	}
+	var units []unit
+	var pending []*node
+	for _, k := range n.kids {
+		pending = append(pending, k)
+		if k.name.Local == "" {
+			continue
+		}
+		pos, ok := m.pos[k.name.Local]
+		switch {
+		case ok && k.name.Space == Namespace:
+			orderChildren(k, schema.model(m.types[k.name.Local]))
+		case m.any >= 0 && k.name.Space != Namespace:
+			pos = m.any
+		default:
+			pos = m.n
+		}
+		units = append(units, unit{pending, pos})
+		pending = nil
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].pos < units[j].pos })
+	n.kids = n.kids[:0]
+	for _, u := range units {
+		n.kids = append(n.kids, u.kids...)
+	}
+	n.kids = append(n.kids, pending...)
+}
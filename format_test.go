@@ -0,0 +1,96 @@
+package postal
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFormatIdempotent(t *testing.T) {
+	for _, name := range []string{"testdata/sample.xml", "testdata/answers-a.xml", "testdata/answers-b.xml"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var once, twice bytes.Buffer
+		if err := Format(&once, bytes.NewReader(data)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := Format(&twice, bytes.NewReader(once.Bytes())); err != nil {
+			t.Fatalf("%s: formatting again: %v", name, err)
+		}
+		if once.String() != twice.String() {
+			t.Errorf("%s: formatting is not idempotent:\n%s\n---\n%s", name, once.String(), twice.String())
+		}
+		if _, err := ReadDocument(bytes.NewReader(once.Bytes())); err != nil {
+			t.Errorf("%s: formatted document does not read: %v", name, err)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	const head = `<ocil xmlns="http://scap.nist.gov/schema/ocil/2.0">`
+	tests := []struct {
+		name string
+		in   string
+		// want are fragments expected in order in the output.
+		want []string
+		err  string
+	}{
+		{
+			name: "schema order",
+			in:   head + `<generator><timestamp>2020-01-01T00:00:00</timestamp><schema_version>2.0</schema_version></generator><document><title>T</title></document></ocil>`,
+			want: []string{"<schema_version>2.0</schema_version>", "<timestamp>2020-01-01T00:00:00</timestamp>"},
+		},
+		{
+			name: "comments move with the next element",
+			in:   head + `<!-- about the document --><document><title>T</title></document><generator><schema_version>2.0</schema_version><timestamp>2020-01-01T00:00:00</timestamp></generator></ocil>`,
+			want: []string{"<generator>", "<!-- about the document -->", "<document>"},
+		},
+		{
+			name: "extension content kept",
+			in:   head + `<generator><schema_version>2.0</schema_version><timestamp>2020-01-01T00:00:00</timestamp><additional_data><x:tool xmlns:x="urn:example">kept</x:tool></additional_data></generator><document><title>T</title></document></ocil>`,
+			want: []string{`="urn:example"`, "<additional_data>", ":tool>kept</"},
+		},
+		{
+			name: "attributes sorted",
+			in:   head + `<generator><schema_version>2.0</schema_version><timestamp>2020-01-01T00:00:00</timestamp></generator><document><title>T</title></document><questionnaires><questionnaire id="ocil:a:questionnaire:1" child_only="true"><actions><test_action_ref>ocil:a:testaction:1</test_action_ref></actions></questionnaire></questionnaires></ocil>`,
+			want: []string{`<questionnaire child_only="true" id="ocil:a:questionnaire:1">`},
+		},
+		{
+			name: "long description wrapped",
+			in:   head + `<generator><schema_version>2.0</schema_version><timestamp>2020-01-01T00:00:00</timestamp></generator><document><title>T</title><description>` + strings.Repeat("word ", 30) + `</description></document></ocil>`,
+			want: []string{"<description>", "word\n", "</description>"},
+		},
+		{
+			name: "not OCIL",
+			in:   `<html/>`,
+			err:  "root element",
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := Format(&buf, strings.NewReader(tt.in))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		out := buf.String()
+		rest := out
+		for _, w := range tt.want {
+			i := strings.Index(rest, w)
+			if i < 0 {
+				t.Errorf("%s: %q missing or out of order in:\n%s", tt.name, w, out)
+				break
+			}
+			rest = rest[i+len(w):]
+		}
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/redhatrises/goscap
+
+go 1.22
+
+require go.etcd.io/bbolt v1.3.11
+
+require golang.org/x/sys v0.10.0 // indirect
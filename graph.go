@@ -0,0 +1,341 @@
+package postal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A GraphFormat selects the language of WriteGraph.
+type GraphFormat int
+
+const (
+	// GraphDOT is the Graphviz DOT language.
+	GraphDOT GraphFormat = iota
+	// GraphMermaid is a Mermaid flowchart.
+	GraphMermaid
+)
+
+// graphColors are the fill colors of nodes with a result.
+var graphColors = map[ResultType]string{
+	ResultPass:          "#c8e6c9",
+	ResultFail:          "#ffcdd2",
+	ResultError:         "#ffe0b2",
+	ResultUnknown:       "#e0e0e0",
+	ResultNotTested:     "#fff9c4",
+	ResultNotApplicable: "#bbdefb",
+}
+
+// graphLabelWidth is the length at which question text is cut in
+// node labels.
+const graphLabelWidth = 60
+
+type graphNode struct {
+	id     string
+	label  string
+	shape  string // box, operator, action, question or result
+	result ResultType
+}
+
+type graphEdge struct {
+	from, to string
+	label    string
+	dashed   bool
+	// taken marks the handler an overlaid result went through.
+	taken bool
+}
+
+type graph struct {
+	x       *Index
+	nodes   []*graphNode
+	edges   []graphEdge
+	ids     map[string]string
+	res     *ResultsType
+	answers Answers
+	vars    VariableSource
+}
+
+// WriteGraph writes the logic of the questionnaires of doc as a
+// graph: questionnaires, test actions and questions are nodes,
+// questionnaires combine their test actions through AND and OR
+// nodes, and each handler of a test action is an edge, labelled by
+// its when_* element and the answers it takes, to a result or to
+// the test action or questionnaire it defers to. A negated
+// reference is marked NOT.
+//
+// The graph covers the questionnaires in ids and everything they
+// lead to, or without ids every questionnaire that is not child
+// only. When res is not nil, nodes are colored by their results in
+// it, questions show their answers and the handlers the answers
+// selected are drawn bold; vars supplies the external and local
+// variables the handlers need.
+func WriteGraph(w io.Writer, doc *OCILType, format GraphFormat, ids []QuestionnaireIDPattern, res *ResultsType, vars VariableSource) error {
+	x, err := NewIndex(doc)
+	if err != nil {
+		return err
+	}
+	g := &graph{x: x, ids: make(map[string]string), res: res, vars: variableChain{x, vars}}
+	if res != nil {
+		g.answers = res.Answers()
+	}
+	if len(ids) == 0 {
+		for _, q := range doc.Questionnaires.Questionnaire {
+			if !q.Child_only {
+				ids = append(ids, q.Id)
+			}
+		}
+	}
+	for _, id := range ids {
+		if _, ok := x.Questionnaires[id]; !ok {
+			return fmt.Errorf("unknown questionnaire %s", id)
+		}
+		g.item(TestActionRefValuePattern(id))
+	}
+	bw := bufio.NewWriter(w)
+	if format == GraphMermaid {
+		g.writeMermaid(bw)
+	} else {
+		g.writeDOT(bw)
+	}
+	return bw.Flush()
+}
+
+func (g *graph) node(key, label, shape string, result ResultType) string {
+	id := fmt.Sprintf("n%d", len(g.nodes)+1)
+	g.ids[key] = id
+	g.nodes = append(g.nodes, &graphNode{id, label, shape, result})
+	return id
+}
+
+// item returns the node of a questionnaire or test action, adding
+// it and everything it leads to on first use.
+func (g *graph) item(ref TestActionRefValuePattern) string {
+	if id, ok := g.ids[string(ref)]; ok {
+		return id
+	}
+	if q, ok := g.x.Questionnaires[QuestionnaireIDPattern(ref)]; ok {
+		label := string(q.Id)
+		if q.Title.Value != "" {
+			label = q.Title.Value + "\n" + label
+		}
+		id := g.node(string(ref), label, "box", g.result(ref))
+		g.operation(id, q.Actions)
+		return id
+	}
+	ta, ok := g.x.TestActions[QuestionTestActionIDPattern(ref)]
+	if !ok {
+		return g.node(string(ref), string(ref)+"\n(unknown)", "box", "")
+	}
+	id := g.node(string(ref), string(ref), "action", g.result(ref))
+	g.edges = append(g.edges, graphEdge{from: id, to: g.question(ta.QuestionRef()), dashed: true})
+	ans, answered := g.answer(ta.QuestionRef())
+	taken := -1
+	for i, h := range ta.Handlers() {
+		if answered && taken < 0 {
+			if ok, err := h.Condition.Matches(ans, g.vars); err == nil && ok {
+				taken = i
+			}
+		}
+		e := graphEdge{from: id, label: g.handlerLabel(h), taken: taken == i}
+		if h.Ref() != "" {
+			e.to = g.item(h.Ref())
+			if h.Test_action_ref.Negate {
+				e.label = "NOT " + e.label
+			}
+		} else {
+			key := string(ref) + " " + string(h.Result)
+			to, ok := g.ids[key]
+			if !ok {
+				to = g.node(key, string(h.Result), "result", h.Result)
+			}
+			e.to = to
+		}
+		g.edges = append(g.edges, e)
+	}
+	return id
+}
+
+// operation links a questionnaire to its test actions, through an
+// operator node when there are several or the operation is negated.
+func (g *graph) operation(from string, op OperationType) {
+	if len(op.Test_action_ref) > 1 || op.Negate {
+		name := string(op.Operation)
+		if name == "" {
+			name = string(OperatorAnd)
+		}
+		if op.Negate {
+			name = "NOT " + name
+		}
+		opID := g.node(from+" op", name, "operator", "")
+		g.edges = append(g.edges, graphEdge{from: from, to: opID})
+		from = opID
+	}
+	for _, r := range op.Test_action_ref {
+		e := graphEdge{from: from, to: g.item(r.TestActionRefValuePattern)}
+		if r.Negate {
+			e.label = "NOT"
+		}
+		g.edges = append(g.edges, e)
+	}
+}
+
+func (g *graph) question(id QuestionIDPattern) string {
+	if n, ok := g.ids[string(id)]; ok {
+		return n
+	}
+	q, ok := g.x.Questions[id]
+	if !ok {
+		return g.node(string(id), string(id)+"\n(unknown)", "question", "")
+	}
+	label := g.x.QuestionText(q, g.vars)
+	if r := []rune(label); len(r) > graphLabelWidth {
+		label = strings.TrimSpace(string(r[:graphLabelWidth-3])) + "..."
+	}
+	if a, ok := g.answer(id); ok {
+		label += "\nanswer: " + g.x.FormatAnswer(q, a)
+	}
+	return g.node(string(id), label, "question", "")
+}
+
+func (g *graph) result(ref TestActionRefValuePattern) ResultType {
+	if g.res == nil {
+		return ""
+	}
+	for _, r := range g.res.Questionnaire_results.Questionnaire_result {
+		if TestActionRefValuePattern(r.Questionnaire_ref) == ref {
+			return r.Result
+		}
+	}
+	for _, r := range g.res.Test_action_results.Test_action_result {
+		if r.Test_action_ref == ref {
+			return r.Result
+		}
+	}
+	return ""
+}
+
+func (g *graph) answer(id QuestionIDPattern) (Answer, bool) {
+	a, ok := g.answers[id]
+	return a, ok
+}
+
+// handlerLabel names a handler and the answers it takes: the text
+// of its choices, or its values, ranges or patterns.
+func (g *graph) handlerLabel(h Handler) string {
+	c := h.Condition
+	switch {
+	case c.Response != ResponseAnswered || c.Boolean != nil:
+		return h.Name
+	case c.Choices != nil:
+		texts := make([]string, len(c.Choices))
+		for i, id := range c.Choices {
+			texts[i] = string(id)
+			if ch, ok := g.x.Choice(id); ok {
+				if ch.Var_ref != "" {
+					texts[i] = "$" + string(ch.Var_ref)
+				} else {
+					texts[i] = fmt.Sprintf("%q", ch.Value)
+				}
+			}
+		}
+		return h.Name + " " + strings.Join(texts, ", ")
+	}
+	return h.Name + " " + c.String()
+}
+
+func (g *graph) writeDOT(w *bufio.Writer) {
+	quote := func(s string) string {
+		s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+		return `"` + s + `"`
+	}
+	shapes := map[string]string{
+		"box":      "shape=box",
+		"operator": "shape=circle",
+		"action":   "shape=ellipse",
+		"question": "shape=note",
+		"result":   "shape=box, style=rounded",
+	}
+	fmt.Fprintln(w, "digraph ocil {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [fontname="Helvetica", fontsize=10];`)
+	fmt.Fprintln(w, `  edge [fontname="Helvetica", fontsize=9];`)
+	for _, n := range g.nodes {
+		attrs := shapes[n.shape]
+		if color, ok := graphColors[n.result]; ok && (g.res != nil || n.shape == "result") {
+			if n.shape == "result" {
+				attrs = "shape=box, style=\"rounded,filled\""
+			} else {
+				attrs += ", style=filled"
+			}
+			attrs += ", fillcolor=" + quote(color)
+		}
+		fmt.Fprintf(w, "  %s [label=%s, %s];\n", n.id, quote(n.label), attrs)
+	}
+	for _, e := range g.edges {
+		var attrs []string
+		if e.label != "" {
+			attrs = append(attrs, "label="+quote(e.label))
+		}
+		if e.dashed {
+			attrs = append(attrs, "style=dashed", "arrowhead=none")
+		}
+		if e.taken {
+			attrs = append(attrs, "penwidth=3")
+		}
+		fmt.Fprintf(w, "  %s -> %s", e.from, e.to)
+		if len(attrs) > 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(attrs, ", "))
+		}
+		fmt.Fprintln(w, ";")
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func (g *graph) writeMermaid(w *bufio.Writer) {
+	quote := func(s string) string {
+		s = strings.NewReplacer(`"`, "#quot;", "\n", "<br/>").Replace(s)
+		return `"` + s + `"`
+	}
+	shapes := map[string][2]string{
+		"box":      {"[", "]"},
+		"operator": {"((", "))"},
+		"action":   {"(", ")"},
+		"question": {"[/", "/]"},
+		"result":   {"([", "])"},
+	}
+	fmt.Fprintln(w, "flowchart LR")
+	classes := make(map[ResultType][]string)
+	for _, n := range g.nodes {
+		s := shapes[n.shape]
+		fmt.Fprintf(w, "  %s%s%s%s\n", n.id, s[0], quote(n.label), s[1])
+		if _, ok := graphColors[n.result]; ok && (g.res != nil || n.shape == "result") {
+			classes[n.result] = append(classes[n.result], n.id)
+		}
+	}
+	var taken []string
+	for i, e := range g.edges {
+		arrow := "-->"
+		if e.dashed {
+			arrow = "-.-"
+		}
+		if e.label != "" {
+			fmt.Fprintf(w, "  %s %s|%s| %s\n", e.from, arrow, quote(e.label), e.to)
+		} else {
+			fmt.Fprintf(w, "  %s %s %s\n", e.from, arrow, e.to)
+		}
+		if e.taken {
+			taken = append(taken, fmt.Sprint(i))
+		}
+	}
+	for _, r := range []ResultType{ResultPass, ResultFail, ResultError, ResultUnknown, ResultNotTested, ResultNotApplicable} {
+		if ids := classes[r]; len(ids) > 0 {
+			class := strings.ToLower(string(r))
+			fmt.Fprintf(w, "  classDef %s fill:%s\n", class, graphColors[r])
+			fmt.Fprintf(w, "  class %s %s\n", strings.Join(ids, ","), class)
+		}
+	}
+	if len(taken) > 0 {
+		fmt.Fprintf(w, "  linkStyle %s stroke-width:3px\n", strings.Join(taken, ","))
+	}
+}
@@ -0,0 +1,47 @@
+package postal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteGraph(t *testing.T) {
+	tests := []struct {
+		name string
+		// answers, if set, names the document whose answers are
+		// evaluated for the results shown.
+		answers string
+		golden  string
+	}{
+		{"logic", "", "sample.dot"},
+		{"results", "answers-a.xml", "sample-a.dot"},
+	}
+	vars := Variables{"ocil:org.example:variable:1": "8"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ReadFile("testdata/sample.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res *ResultsType
+			if tt.answers != "" {
+				adoc, err := ReadFile("testdata/" + tt.answers)
+				if err != nil {
+					t.Fatal(err)
+				}
+				x, err := NewIndex(adoc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res, err = x.Evaluate(adoc.Results.Answers(), vars); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var buf bytes.Buffer
+			if err := WriteGraph(&buf, doc, GraphDOT, nil, res, vars); err != nil {
+				t.Fatal(err)
+			}
+			golden(t, tt.golden, buf.Bytes())
+		})
+	}
+}
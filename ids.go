@@ -0,0 +1,123 @@
+package postal
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+var idRE = regexp.MustCompile(`^ocil:([A-Za-z0-9_\-.]+):([a-z]+):([1-9][0-9]*)$`)
+
+// An ID is an OCIL identifier of the form ocil:namespace:kind:n,
+// such as ocil:org.example:question:3.
+type ID struct {
+	Namespace string
+	Kind      string
+	N         int
+}
+
+// ParseID splits an identifier into its parts.
+func ParseID(s string) (ID, bool) {
+	m := idRE.FindStringSubmatch(s)
+	if m == nil {
+		return ID{}, false
+	}
+	n, err := strconv.Atoi(m[3])
+	if err != nil {
+		return ID{}, false
+	}
+	return ID{m[1], m[2], n}, true
+}
+
+func (id ID) String() string {
+	return fmt.Sprintf("ocil:%s:%s:%d", id.Namespace, id.Kind, id.N)
+}
+
+// idTypes are the types of the fields holding item IDs and
+// references to items.
+var idTypes = map[reflect.Type]bool{
+	reflect.TypeOf(ArtifactIDPattern("")):           true,
+	reflect.TypeOf(ChoiceGroupIDPattern("")):        true,
+	reflect.TypeOf(ChoiceIDPattern("")):             true,
+	reflect.TypeOf(QuestionIDPattern("")):           true,
+	reflect.TypeOf(QuestionTestActionIDPattern("")): true,
+	reflect.TypeOf(QuestionnaireIDPattern("")):      true,
+	reflect.TypeOf(TestActionRefValuePattern("")):   true,
+	reflect.TypeOf(VariableIDPattern("")):           true,
+}
+
+// MapIDs replaces every item ID in doc, and every reference to one,
+// including those in the results, by f of it. Empty IDs are left
+// alone.
+func MapIDs(doc *OCILType, f func(id string) string) {
+	mapIDs(reflect.ValueOf(doc).Elem(), f)
+}
+
+func mapIDs(v reflect.Value, f func(string) string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			mapIDs(v.Elem(), f)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			mapIDs(v.Index(i), f)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				mapIDs(v.Field(i), f)
+			}
+		}
+	case reflect.String:
+		if !idTypes[v.Type()] || v.String() == "" {
+			return
+		}
+		if s := f(v.String()); s != v.String() && v.CanSet() {
+			v.SetString(s)
+		}
+	}
+}
+
+// CloneDocument returns a deep copy of doc, which transforms such
+// as MapIDs may change without affecting doc.
+func CloneDocument(doc *OCILType) *OCILType {
+	return cloneValue(reflect.ValueOf(doc)).Interface().(*OCILType)
+}
+
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := c.Field(i); f.CanSet() {
+				f.Set(cloneValue(v.Field(i)))
+			}
+		}
+		// The order of a container is unexported, so the copy
+		// above shares it with v; give the clone its own.
+		if o, ok := c.Addr().Interface().(documentOrdered); ok {
+			o.setOrder(o.documentItems())
+		}
+		return c
+	}
+	return v
+}
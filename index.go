@@ -0,0 +1,381 @@
+package postal
+
+import "fmt"
+
+// A TestAction is one of the concrete question test action
+// types: boolean, choice, numeric or string.
+type TestAction interface {
+	TestActionID() QuestionTestActionIDPattern
+	QuestionRef() QuestionIDPattern
+	// Handlers returns the when_* elements of the test action in
+	// document order, followed by the handlers for exceptional
+	// responses that are present.
+	Handlers() []Handler
+}
+
+// A Question is one of the concrete question types: boolean,
+// choice, numeric or string.
+type Question interface {
+	QuestionID() QuestionIDPattern
+	Text() []QuestionTextType
+	Instruction() InstructionsType
+}
+
+// A Handler is a single when_* element of a test action. Its
+// Condition describes the answers that select it; the handler
+// then either yields Result or defers to Test_action_ref.
+type Handler struct {
+	Name            string
+	Condition       Condition
+	Result          ResultType
+	Test_action_ref TestActionRefType
+	Artifact_refs   ArtifactRefsType
+}
+
+// Ref returns the test action or questionnaire the handler defers
+// to, or the empty string when the handler yields a result.
+func (h Handler) Ref() TestActionRefValuePattern {
+	return h.Test_action_ref.TestActionRefValuePattern
+}
+
+func exceptionalHandlers(q QuestionIDPattern, unknown, notTested, notApplicable, err TestActionConditionType) []Handler {
+	var hs []Handler
+	for _, c := range []struct {
+		name string
+		resp UserResponseType
+		cond TestActionConditionType
+	}{
+		{"when_unknown", ResponseUnknown, unknown},
+		{"when_not_tested", ResponseNotTested, notTested},
+		{"when_not_applicable", ResponseNotApplicable, notApplicable},
+		{"when_error", ResponseError, err},
+	} {
+		if c.cond.Result == "" && c.cond.Test_action_ref.TestActionRefValuePattern == "" {
+			continue
+		}
+		hs = append(hs, Handler{
+			Name:            c.name,
+			Condition:       Condition{Question: q, Response: c.resp},
+			Result:          c.cond.Result,
+			Test_action_ref: c.cond.Test_action_ref,
+			Artifact_refs:   c.cond.Artifact_refs,
+		})
+	}
+	return hs
+}
+
+func (t *BooleanQuestionTestActionType) TestActionID() QuestionTestActionIDPattern { return t.Id }
+func (t *BooleanQuestionTestActionType) QuestionRef() QuestionIDPattern            { return t.Question_ref }
+
+func (t *BooleanQuestionTestActionType) Handlers() []Handler {
+	yes, no := true, false
+	hs := []Handler{
+		{"when_true", Condition{Question: t.Question_ref, Response: ResponseAnswered, Boolean: &yes},
+			t.When_true.Result, t.When_true.Test_action_ref, t.When_true.Artifact_refs},
+		{"when_false", Condition{Question: t.Question_ref, Response: ResponseAnswered, Boolean: &no},
+			t.When_false.Result, t.When_false.Test_action_ref, t.When_false.Artifact_refs},
+	}
+	return append(hs, exceptionalHandlers(t.Question_ref, t.When_unknown, t.When_not_tested, t.When_not_applicable, t.When_error)...)
+}
+
+func (t *ChoiceQuestionTestActionType) TestActionID() QuestionTestActionIDPattern { return t.Id }
+func (t *ChoiceQuestionTestActionType) QuestionRef() QuestionIDPattern            { return t.Question_ref }
+
+func (t *ChoiceQuestionTestActionType) Handlers() []Handler {
+	var hs []Handler
+	for _, w := range t.When_choice {
+		hs = append(hs, Handler{"when_choice", Condition{Question: t.Question_ref, Response: ResponseAnswered, Choices: w.Choice_ref},
+			w.Result, w.Test_action_ref, w.Artifact_refs})
+	}
+	return append(hs, exceptionalHandlers(t.Question_ref, t.When_unknown, t.When_not_tested, t.When_not_applicable, t.When_error)...)
+}
+
+func (t *NumericQuestionTestActionType) TestActionID() QuestionTestActionIDPattern { return t.Id }
+func (t *NumericQuestionTestActionType) QuestionRef() QuestionIDPattern            { return t.Question_ref }
+
+func (t *NumericQuestionTestActionType) Handlers() []Handler {
+	var hs []Handler
+	for i := range t.When_equals {
+		w := &t.When_equals[i]
+		hs = append(hs, Handler{"when_equals", Condition{Question: t.Question_ref, Response: ResponseAnswered, Equals: w},
+			w.Result, w.Test_action_ref, w.Artifact_refs})
+	}
+	for i := range t.When_range {
+		w := &t.When_range[i]
+		hs = append(hs, Handler{"when_range", Condition{Question: t.Question_ref, Response: ResponseAnswered, Range: w},
+			w.Result, w.Test_action_ref, w.Artifact_refs})
+	}
+	return append(hs, exceptionalHandlers(t.Question_ref, t.When_unknown, t.When_not_tested, t.When_not_applicable, t.When_error)...)
+}
+
+func (t *StringQuestionTestActionType) TestActionID() QuestionTestActionIDPattern { return t.Id }
+func (t *StringQuestionTestActionType) QuestionRef() QuestionIDPattern            { return t.Question_ref }
+
+func (t *StringQuestionTestActionType) Handlers() []Handler {
+	var hs []Handler
+	for i := range t.When_pattern {
+		w := &t.When_pattern[i]
+		hs = append(hs, Handler{"when_pattern", Condition{Question: t.Question_ref, Response: ResponseAnswered, Pattern: w},
+			w.Result, w.Test_action_ref, w.Artifact_refs})
+	}
+	return append(hs, exceptionalHandlers(t.Question_ref, t.When_unknown, t.When_not_tested, t.When_not_applicable, t.When_error)...)
+}
+
+func (t *BooleanQuestionType) QuestionID() QuestionIDPattern { return t.Id }
+func (t *BooleanQuestionType) Text() []QuestionTextType      { return t.Question_text }
+func (t *BooleanQuestionType) Instruction() InstructionsType { return t.Instructions }
+func (t *ChoiceQuestionType) QuestionID() QuestionIDPattern  { return t.Id }
+func (t *ChoiceQuestionType) Text() []QuestionTextType       { return t.Question_text }
+func (t *ChoiceQuestionType) Instruction() InstructionsType  { return t.Instructions }
+func (t *NumericQuestionType) QuestionID() QuestionIDPattern { return t.Id }
+func (t *NumericQuestionType) Text() []QuestionTextType      { return t.Question_text }
+func (t *NumericQuestionType) Instruction() InstructionsType { return t.Instructions }
+func (t *StringQuestionType) QuestionID() QuestionIDPattern  { return t.Id }
+func (t *StringQuestionType) Text() []QuestionTextType       { return t.Question_text }
+func (t *StringQuestionType) Instruction() InstructionsType  { return t.Instructions }
+
+// Index provides lookup by ID of the items in an OCIL
+// document. The pointers refer into the indexed document.
+type Index struct {
+	Doc            *OCILType
+	Questionnaires map[QuestionnaireIDPattern]*QuestionnaireType
+	TestActions    map[QuestionTestActionIDPattern]TestAction
+	Questions      map[QuestionIDPattern]Question
+	ChoiceGroups   map[ChoiceGroupIDPattern]*ChoiceGroupType
+	Constants      map[VariableIDPattern]*ConstantVariableType
+	Locals         map[VariableIDPattern]*LocalVariableType
+	Externals      map[VariableIDPattern]*ExternalVariableType
+
+	// Applicability holds the platforms questionnaires apply to,
+	// initially those named by CPE references. Entries may be
+	// added or replaced before evaluating.
+	Applicability Applicability
+
+	choices map[ChoiceIDPattern]*ChoiceType
+}
+
+// NewIndex indexes doc. It returns an error if an ID is used
+// twice within the same kind of item.
+func NewIndex(doc *OCILType) (*Index, error) {
+	x := &Index{
+		Doc:            doc,
+		Questionnaires: make(map[QuestionnaireIDPattern]*QuestionnaireType),
+		TestActions:    make(map[QuestionTestActionIDPattern]TestAction),
+		Questions:      make(map[QuestionIDPattern]Question),
+		ChoiceGroups:   make(map[ChoiceGroupIDPattern]*ChoiceGroupType),
+		Constants:      make(map[VariableIDPattern]*ConstantVariableType),
+		Locals:         make(map[VariableIDPattern]*LocalVariableType),
+		Externals:      make(map[VariableIDPattern]*ExternalVariableType),
+		choices:        make(map[ChoiceIDPattern]*ChoiceType),
+		Applicability:  referenceApplicability(doc),
+	}
+	for i := range doc.Questionnaires.Questionnaire {
+		q := &doc.Questionnaires.Questionnaire[i]
+		if _, ok := x.Questionnaires[q.Id]; ok {
+			return nil, fmt.Errorf("duplicate questionnaire id %s", q.Id)
+		}
+		x.Questionnaires[q.Id] = q
+	}
+	for _, ta := range doc.Test_actions.All() {
+		if _, ok := x.TestActions[ta.TestActionID()]; ok {
+			return nil, fmt.Errorf("duplicate test action id %s", ta.TestActionID())
+		}
+		x.TestActions[ta.TestActionID()] = ta
+	}
+	for _, q := range doc.Questions.All() {
+		if _, ok := x.Questions[q.QuestionID()]; ok {
+			return nil, fmt.Errorf("duplicate question id %s", q.QuestionID())
+		}
+		x.Questions[q.QuestionID()] = q
+	}
+	for i := range doc.Questions.Choice_group {
+		g := &doc.Questions.Choice_group[i]
+		if _, ok := x.ChoiceGroups[g.Id]; ok {
+			return nil, fmt.Errorf("duplicate choice group id %s", g.Id)
+		}
+		x.ChoiceGroups[g.Id] = g
+		for j := range g.Choice {
+			x.choices[g.Choice[j].Id] = &g.Choice[j]
+		}
+	}
+	for i := range doc.Questions.Choice_question {
+		q := &doc.Questions.Choice_question[i]
+		for _, c := range q.InlineChoices() {
+			x.choices[c.Id] = c
+		}
+	}
+	vars := make(map[VariableIDPattern]bool)
+	dup := func(id VariableIDPattern) error {
+		if vars[id] {
+			return fmt.Errorf("duplicate variable id %s", id)
+		}
+		vars[id] = true
+		return nil
+	}
+	for i := range doc.Variables.Constant_variable {
+		v := &doc.Variables.Constant_variable[i]
+		if err := dup(v.Id); err != nil {
+			return nil, err
+		}
+		x.Constants[v.Id] = v
+	}
+	for i := range doc.Variables.Local_variable {
+		v := &doc.Variables.Local_variable[i]
+		if err := dup(v.Id); err != nil {
+			return nil, err
+		}
+		x.Locals[v.Id] = v
+	}
+	for i := range doc.Variables.External_variable {
+		v := &doc.Variables.External_variable[i]
+		if err := dup(v.Id); err != nil {
+			return nil, err
+		}
+		x.Externals[v.Id] = v
+	}
+	return x, nil
+}
+
+// All returns every test action in the container, in document
+// order.
+func (t *TestActionsType) All() []TestAction {
+	var all []TestAction
+	for _, it := range t.order.items(t.kinds()) {
+		all = append(all, it.v.(TestAction))
+	}
+	return all
+}
+
+// Add appends a copy of the test action, a pointer to one of the
+// test action types, to the container.
+func (t *TestActionsType) Add(ta TestAction) {
+	switch ta := ta.(type) {
+	case *BooleanQuestionTestActionType:
+		t.Boolean_question_test_action = append(t.Boolean_question_test_action, *ta)
+		t.order.add("boolean_question_test_action")
+	case *ChoiceQuestionTestActionType:
+		t.Choice_question_test_action = append(t.Choice_question_test_action, *ta)
+		t.order.add("choice_question_test_action")
+	case *NumericQuestionTestActionType:
+		t.Numeric_question_test_action = append(t.Numeric_question_test_action, *ta)
+		t.order.add("numeric_question_test_action")
+	case *StringQuestionTestActionType:
+		t.String_question_test_action = append(t.String_question_test_action, *ta)
+		t.order.add("string_question_test_action")
+	}
+}
+
+// All returns every question in the container, in document order.
+func (t *QuestionsType) All() []Question {
+	var all []Question
+	for _, it := range t.order.items(t.kinds()) {
+		if q, ok := it.v.(Question); ok {
+			all = append(all, q)
+		}
+	}
+	return all
+}
+
+// Add appends a copy of the question, a pointer to one of the
+// question types, to the container.
+func (t *QuestionsType) Add(q Question) {
+	switch q := q.(type) {
+	case *BooleanQuestionType:
+		t.Boolean_question = append(t.Boolean_question, *q)
+		t.order.add("boolean_question")
+	case *ChoiceQuestionType:
+		t.Choice_question = append(t.Choice_question, *q)
+		t.order.add("choice_question")
+	case *NumericQuestionType:
+		t.Numeric_question = append(t.Numeric_question, *q)
+		t.order.add("numeric_question")
+	case *StringQuestionType:
+		t.String_question = append(t.String_question, *q)
+		t.order.add("string_question")
+	}
+}
+
+// InlineChoices returns the choices given in the question itself,
+// leaving out those of the choice groups it refers to.
+func (t *ChoiceQuestionType) InlineChoices() []*ChoiceType {
+	var choices []*ChoiceType
+	for _, c := range t.Choices {
+		if c.Choice != nil {
+			choices = append(choices, c.Choice)
+		}
+	}
+	return choices
+}
+
+// Choices returns the choices of q in presentation order, the
+// document order of its choices and choice groups, with
+// choice_group_ref elements expanded.
+func (x *Index) Choices(q *ChoiceQuestionType) ([]ChoiceType, error) {
+	var choices []ChoiceType
+	for _, c := range q.Choices {
+		if c.Choice != nil {
+			choices = append(choices, *c.Choice)
+			continue
+		}
+		g, ok := x.ChoiceGroups[c.Choice_group_ref]
+		if !ok {
+			return nil, fmt.Errorf("question %s: unknown choice group %s", q.Id, c.Choice_group_ref)
+		}
+		choices = append(choices, g.Choice...)
+	}
+	return choices, nil
+}
+
+// Lookup implements VariableSource for the constant variables of
+// the document.
+func (x *Index) Lookup(id VariableIDPattern) (string, bool) {
+	if v, ok := x.Constants[id]; ok {
+		return v.Value, true
+	}
+	return "", false
+}
+
+// Choice returns the choice with the given ID, whether defined in
+// a choice question or in a choice group.
+func (x *Index) Choice(id ChoiceIDPattern) (*ChoiceType, bool) {
+	c, ok := x.choices[id]
+	return c, ok
+}
+
+// ReachableQuestions returns the questions that evaluating the
+// questionnaire or test action with the given ID may ask,
+// following every handler and child questionnaire, in depth-first
+// order. Unknown references are ignored.
+func (x *Index) ReachableQuestions(id TestActionRefValuePattern) []QuestionIDPattern {
+	var out []QuestionIDPattern
+	seen := make(map[TestActionRefValuePattern]bool)
+	asked := make(map[QuestionIDPattern]bool)
+	var walk func(TestActionRefValuePattern)
+	walk = func(id TestActionRefValuePattern) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		if q, ok := x.Questionnaires[QuestionnaireIDPattern(id)]; ok {
+			for _, r := range q.Actions.Test_action_ref {
+				walk(r.TestActionRefValuePattern)
+			}
+			return
+		}
+		ta, ok := x.TestActions[QuestionTestActionIDPattern(id)]
+		if !ok {
+			return
+		}
+		if q := ta.QuestionRef(); !asked[q] {
+			asked[q] = true
+			out = append(out, q)
+		}
+		for _, h := range ta.Handlers() {
+			if h.Ref() != "" {
+				walk(h.Ref())
+			}
+		}
+	}
+	walk(id)
+	return out
+}
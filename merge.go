@@ -0,0 +1,219 @@
+package postal
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// A Collision is an ID used for different items by two of the
+// documents given to Merge, numbered from 0.
+type Collision struct {
+	ID            string
+	First, Second int
+}
+
+// A CollisionError lists the ID collisions that prevented a merge.
+type CollisionError []Collision
+
+func (e CollisionError) Error() string {
+	s := make([]string, len(e))
+	for i, c := range e {
+		s[i] = fmt.Sprintf("%s is used by documents %d and %d", c.ID, c.First+1, c.Second+1)
+	}
+	return "ID collisions: " + strings.Join(s, "; ")
+}
+
+// Merge combines the questionnaires, test actions, questions,
+// choice groups, artifacts and variables of docs into one document
+// titled title. The results of docs are dropped.
+//
+// When namespaces[i] is not empty, the namespace segment of every
+// ID in docs[i], and of every reference to one, is first rewritten
+// to it. An item appearing unchanged in several documents is kept
+// once; a choice group with the same choices as an earlier one is
+// dropped and references to it and its choices are redirected to
+// the earlier group. Any other ID used twice is a collision, and
+// the error is a CollisionError listing every one.
+//
+// The merged document has a single generator and document header:
+// the descriptions and notices of docs, each once, under title.
+func Merge(docs []*OCILType, namespaces []string, title string) (*OCILType, error) {
+	out := &OCILType{
+		Generator: GeneratorType{
+			Product_name:   "ocil3 merge",
+			Schema_version: 2.0,
+			Timestamp:      time.Now().UTC().Truncate(time.Second),
+		},
+		Document: DocumentType{Title: title},
+	}
+	m := &merger{seen: make(map[string]mergeItem), groups: make(map[string]ChoiceGroupType)}
+	for i, doc := range docs {
+		m.doc = i
+		c := CloneDocument(doc)
+		c.Results = ResultsType{}
+		if i < len(namespaces) && namespaces[i] != "" {
+			ns := namespaces[i]
+			MapIDs(c, func(s string) string {
+				id, ok := ParseID(s)
+				if !ok {
+					return s
+				}
+				id.Namespace = ns
+				return id.String()
+			})
+		}
+		m.dedupeGroups(c)
+		m.add(out, c)
+		out.Document.Description = appendUnique(out.Document.Description, c.Document.Description...)
+		out.Document.Notice = appendUnique(out.Document.Notice, c.Document.Notice...)
+	}
+	if len(m.collisions) > 0 {
+		return nil, m.collisions
+	}
+	if _, err := NewIndex(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type mergeItem struct {
+	doc   int
+	value interface{}
+}
+
+type merger struct {
+	doc        int
+	seen       map[string]mergeItem
+	groups     map[string]ChoiceGroupType
+	collisions CollisionError
+}
+
+// keep reports whether the item with the given ID is to be added:
+// it is new, not an unchanged copy of an earlier item. A changed
+// item is recorded as a collision.
+func (m *merger) keep(id string, v interface{}) bool {
+	prev, ok := m.seen[id]
+	if !ok {
+		m.seen[id] = mergeItem{m.doc, v}
+		return true
+	}
+	if !reflect.DeepEqual(prev.value, v) {
+		m.collisions = append(m.collisions, Collision{id, prev.doc, m.doc})
+	}
+	return false
+}
+
+// choiceGroupKey identifies a choice group by its choices.
+func choiceGroupKey(g ChoiceGroupType) string {
+	var b strings.Builder
+	for _, c := range g.Choice {
+		fmt.Fprintf(&b, "%q %q\n", c.Value, c.Var_ref)
+	}
+	return b.String()
+}
+
+// dedupeGroups drops the choice groups of doc whose choices equal
+// those of an earlier group, redirecting references to the earlier
+// group and its choices.
+func (m *merger) dedupeGroups(doc *OCILType) {
+	remap := make(map[string]string)
+	var groups []ChoiceGroupType
+	for _, g := range doc.Questions.Choice_group {
+		k := choiceGroupKey(g)
+		prev, ok := m.groups[k]
+		if !ok {
+			m.groups[k] = g
+			groups = append(groups, g)
+			continue
+		}
+		if prev.Id == g.Id {
+			continue
+		}
+		remap[string(g.Id)] = string(prev.Id)
+		for i, c := range g.Choice {
+			remap[string(c.Id)] = string(prev.Choice[i].Id)
+		}
+	}
+	doc.Questions.Choice_group = groups
+	if len(remap) > 0 {
+		MapIDs(doc, func(id string) string {
+			if to, ok := remap[id]; ok {
+				return to
+			}
+			return id
+		})
+	}
+}
+
+// add appends the items of doc to out.
+func (m *merger) add(out, doc *OCILType) {
+	for _, q := range doc.Questionnaires.Questionnaire {
+		if m.keep(string(q.Id), q) {
+			out.Questionnaires.Questionnaire = append(out.Questionnaires.Questionnaire, q)
+		}
+	}
+	for _, t := range doc.Test_actions.All() {
+		if m.keep(string(t.TestActionID()), t) {
+			out.Test_actions.Add(t)
+		}
+	}
+	qs, oqs := &doc.Questions, &out.Questions
+	for _, q := range qs.All() {
+		if m.keep(string(q.QuestionID()), q) {
+			if cq, ok := q.(*ChoiceQuestionType); ok {
+				for _, c := range cq.InlineChoices() {
+					m.keep(string(c.Id), *c)
+				}
+			}
+			oqs.Add(q)
+		}
+	}
+	for _, g := range qs.Choice_group {
+		if m.keep(string(g.Id), g) {
+			for _, c := range g.Choice {
+				m.keep(string(c.Id), c)
+			}
+			oqs.Choice_group = append(oqs.Choice_group, g)
+		}
+	}
+	for _, a := range doc.Artifacts.Artifact {
+		if m.keep(string(a.Id), a) {
+			out.Artifacts.Artifact = append(out.Artifacts.Artifact, a)
+		}
+	}
+	vs, ovs := &doc.Variables, &out.Variables
+	for _, v := range vs.Constant_variable {
+		if m.keep(string(v.Id), v) {
+			ovs.Constant_variable = append(ovs.Constant_variable, v)
+		}
+	}
+	for _, v := range vs.External_variable {
+		if m.keep(string(v.Id), v) {
+			ovs.External_variable = append(ovs.External_variable, v)
+		}
+	}
+	for _, v := range vs.Local_variable {
+		if m.keep(string(v.Id), v) {
+			ovs.Local_variable = append(ovs.Local_variable, v)
+		}
+	}
+}
+
+// appendUnique appends the strings of add not already in list.
+func appendUnique(list []string, add ...string) []string {
+	for _, s := range add {
+		dup := false
+		for _, t := range list {
+			if t == s {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			list = append(list, s)
+		}
+	}
+	return list
+}
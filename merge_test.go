@@ -0,0 +1,104 @@
+package postal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	changed := func(doc *OCILType) {
+		doc.Questions.String_question[0].Question_text = []QuestionTextType{PlainText("Who owns it?")}
+		doc.Variables.External_variable[0].Description.Value = "Other"
+	}
+	tests := []struct {
+		name       string
+		change     func(doc *OCILType)
+		namespaces []string
+		// counts are the questionnaires, questions and choice
+		// groups merged.
+		counts     [3]int
+		collisions []Collision
+	}{
+		{
+			name:   "same document twice",
+			counts: [3]int{2, 5, 1},
+		},
+		{
+			name:       "changed items collide",
+			change:     changed,
+			collisions: []Collision{{"ocil:org.example:question:4", 0, 1}, {"ocil:org.example:variable:1", 0, 1}},
+		},
+		{
+			name:       "namespaces separate the documents",
+			change:     changed,
+			namespaces: []string{"", "org.other"},
+			counts:     [3]int{4, 10, 1},
+		},
+		{
+			name:       "namespace given for both",
+			namespaces: []string{"org.a", "org.b"},
+			counts:     [3]int{4, 10, 1},
+		},
+	}
+	for _, tt := range tests {
+		a, err := ReadFile("testdata/sample.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := CloneDocument(a)
+		if tt.change != nil {
+			tt.change(b)
+		}
+		out, err := Merge([]*OCILType{a, b}, tt.namespaces, "Merged")
+		if tt.collisions != nil {
+			var ce CollisionError
+			if !errors.As(err, &ce) {
+				t.Errorf("%s: error %v, want collisions", tt.name, err)
+			} else if !reflect.DeepEqual([]Collision(ce), tt.collisions) {
+				t.Errorf("%s: collisions %v, want %v", tt.name, ce, tt.collisions)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		counts := [3]int{len(out.Questionnaires.Questionnaire), len(out.Questions.All()), len(out.Questions.Choice_group)}
+		if counts != tt.counts {
+			t.Errorf("%s: counts %v, want %v", tt.name, counts, tt.counts)
+		}
+		if out.Document.Title != "Merged" || !reflect.DeepEqual(out.Document.Description, []string{"A sample"}) {
+			t.Errorf("%s: header %+v", tt.name, out.Document)
+		}
+	}
+}
+
+func TestMergeRedirectsChoiceGroups(t *testing.T) {
+	a, err := ReadFile("testdata/sample.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Merge([]*OCILType{a, CloneDocument(a)}, []string{"", "org.other"}, "Merged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := NewIndex(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := x.Questions["ocil:org.other:question:3"].(*ChoiceQuestionType)
+	var refs []ChoiceGroupIDPattern
+	for _, c := range q.Choices {
+		if c.Choice_group_ref != "" {
+			refs = append(refs, c.Choice_group_ref)
+		}
+	}
+	if want := []ChoiceGroupIDPattern{"ocil:org.example:choicegroup:1"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("group references %v, want %v", refs, want)
+	}
+	ta := x.TestActions["ocil:org.other:testaction:3"].(*ChoiceQuestionTestActionType)
+	if got := ta.When_choice[1].Choice_ref; !reflect.DeepEqual(got, []ChoiceIDPattern{"ocil:org.example:choice:3"}) {
+		t.Errorf("choice references %v, want the earlier group's choice", got)
+	}
+}
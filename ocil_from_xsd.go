@@ -35,38 +35,6 @@ type ArtifactRefsType struct {
 	Artifact_ref []ArtifactRefType `xml:"http://scap.nist.gov/schema/ocil/2.0 artifact_ref"`
 }
 
-// The ArtifactResultType type defines structures containing
-// information about the submitted artifact, its value, who provided and
-// submitted it, and when it was submitted.
-type ArtifactResultType struct {
-	Artifact_value ArtifactValueType    `xml:"http://scap.nist.gov/schema/ocil/2.0 artifact_value"`
-	Provider       ProviderValuePattern `xml:"http://scap.nist.gov/schema/ocil/2.0 provider"`
-	Submitter      UserType             `xml:"http://scap.nist.gov/schema/ocil/2.0 submitter"`
-	Artifact_ref   ArtifactIDPattern    `xml:"artifact_ref,attr"`
-	Timestamp      time.Time            `xml:"timestamp,attr"`
-}
-
-func (t *ArtifactResultType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-	type T ArtifactResultType
-	var layout struct {
-		*T
-		Timestamp *xsdDateTime `xml:"timestamp,attr"`
-	}
-	layout.T = (*T)(t)
-	layout.Timestamp = (*xsdDateTime)(&layout.T.Timestamp)
-	return e.EncodeElement(layout, start)
-}
-func (t *ArtifactResultType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	type T ArtifactResultType
-	var overlay struct {
-		*T
-		Timestamp *xsdDateTime `xml:"timestamp,attr"`
-	}
-	overlay.T = (*T)(t)
-	overlay.Timestamp = (*xsdDateTime)(&overlay.T.Timestamp)
-	return d.DecodeElement(&overlay, &start)
-}
-
 // The ArtifactResultsType type defines structures
 // containing a set of artifact_result elements.
 type ArtifactResultsType struct {
@@ -282,36 +250,6 @@ func (t *ChoiceQuestionTestActionType) UnmarshalXML(d *xml.Decoder, start xml.St
 	return d.DecodeElement(&overlay, &start)
 }
 
-// The ChoiceQuestionType type defines a question with one
-// or more acceptable answers specified by the author. The response will
-// be one of these specified answers. Acceptable answers are specified
-// either explicitly using the choice element or implicitly using the
-// choice_group_ref element to reference a choice_group element. Choices
-// are presented in the order in which they are provided. All the choices in
-// a choice_group are inserted in the order in which they appear within the
-// choice_group.
-type ChoiceQuestionType struct {
-	Choice             ChoiceType           `xml:"http://scap.nist.gov/schema/ocil/2.0 choice"`
-	Choice_group_ref   ChoiceGroupIDPattern `xml:"http://scap.nist.gov/schema/ocil/2.0 choice_group_ref"`
-	Question_text      []QuestionTextType   `xml:"http://scap.nist.gov/schema/ocil/2.0 question_text"`
-	Instructions       InstructionsType     `xml:"http://scap.nist.gov/schema/ocil/2.0 instructions,omitempty"`
-	Notes              []string             `xml:"http://scap.nist.gov/schema/ocil/2.0 notes,omitempty"`
-	Default_answer_ref ChoiceIDPattern      `xml:"default_answer_ref,attr,omitempty"`
-	Id                 QuestionIDPattern    `xml:"id,attr"`
-	Revision           int                  `xml:"revision,attr,omitempty"`
-}
-
-func (t *ChoiceQuestionType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	type T ChoiceQuestionType
-	var overlay struct {
-		*T
-		Revision *int `xml:"revision,attr,omitempty"`
-	}
-	overlay.T = (*T)(t)
-	overlay.Revision = (*int)(&overlay.T.Revision)
-	return d.DecodeElement(&overlay, &start)
-}
-
 // The ChoiceTestActionConditionType type defines a structure that
 // specifies the action to take in a choice_test_action when a particular
 // choice is selected in response to a choice_question.
@@ -430,44 +368,6 @@ func (t *ExternalVariableType) UnmarshalXML(d *xml.Decoder, start xml.StartEleme
 	return d.DecodeElement(&overlay, &start)
 }
 
-// The GeneratorType type defines an element that is used
-// to hold information about when a particular OCIL document was generated,
-// what version of the schema was used, what tool was used to generate the
-// document, and what version of the tool was used.
-//
-// Additional generator information is also allowed although
-// it is not part of the official OCIL language. Individual organizations
-// can place generator information that they feel is important.
-type GeneratorType struct {
-	Product_name    string                 `xml:"http://scap.nist.gov/schema/ocil/2.0 product_name,omitempty"`
-	Product_version string                 `xml:"http://scap.nist.gov/schema/ocil/2.0 product_version,omitempty"`
-	Author          []UserType             `xml:"http://scap.nist.gov/schema/ocil/2.0 author,omitempty"`
-	Schema_version  float64                `xml:"http://scap.nist.gov/schema/ocil/2.0 schema_version"`
-	Timestamp       time.Time              `xml:"http://scap.nist.gov/schema/ocil/2.0 timestamp"`
-	Additional_data ExtensionContainerType `xml:"http://scap.nist.gov/schema/ocil/2.0 additional_data,omitempty"`
-}
-
-func (t *GeneratorType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-	type T GeneratorType
-	var layout struct {
-		*T
-		Timestamp *xsdDateTime `xml:"http://scap.nist.gov/schema/ocil/2.0 timestamp"`
-	}
-	layout.T = (*T)(t)
-	layout.Timestamp = (*xsdDateTime)(&layout.T.Timestamp)
-	return e.EncodeElement(layout, start)
-}
-func (t *GeneratorType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	type T GeneratorType
-	var overlay struct {
-		*T
-		Timestamp *xsdDateTime `xml:"http://scap.nist.gov/schema/ocil/2.0 timestamp"`
-	}
-	overlay.T = (*T)(t)
-	overlay.Timestamp = (*xsdDateTime)(&overlay.T.Timestamp)
-	return d.DecodeElement(&overlay, &start)
-}
-
 // The InstructionsType type defines a series of steps
 // intended to guide the user in answering a question.
 type InstructionsType struct {
@@ -715,13 +615,6 @@ func (t *QuestionResultType) UnmarshalXML(d *xml.Decoder, start xml.StartElement
 	return d.DecodeElement(&overlay, &start)
 }
 
-// The QuestionResultsType type defines structures
-// containing computed results of all evaluated question
-// types.
-type QuestionResultsType struct {
-	Question_result []QuestionResultType `xml:"http://scap.nist.gov/schema/ocil/2.0 question_result"`
-}
-
 // Must match the pattern ocil:[A-Za-z0-9_\-\.]+:testaction:[1-9][0-9]*
 type QuestionTestActionIDPattern string
 
@@ -752,12 +645,6 @@ func (t *QuestionTestActionType) UnmarshalXML(d *xml.Decoder, start xml.StartEle
 	return d.DecodeElement(&overlay, &start)
 }
 
-// The QuestionTextType complex type defines a structure
-// to hold the text and variables that comprise a question's text.
-type QuestionTextType struct {
-	Sub []SubstitutionTextType `xml:"http://scap.nist.gov/schema/ocil/2.0 sub,omitempty"`
-}
-
 // The QuestionType complex type defines a structure to
 // describe a question and any instructions to help in determining an
 // answer.
@@ -834,13 +721,6 @@ type QuestionnairesType struct {
 	Questionnaire []QuestionnaireType `xml:"http://scap.nist.gov/schema/ocil/2.0 questionnaire"`
 }
 
-// The QuestionsType type defines structures containing a
-// set of QuestionType and ChoiceGroupType elements.
-type QuestionsType struct {
-	Question     []QuestionType    `xml:"http://scap.nist.gov/schema/ocil/2.0 question"`
-	Choice_group []ChoiceGroupType `xml:"http://scap.nist.gov/schema/ocil/2.0 choice_group,omitempty"`
-}
-
 // The RangeTestActionConditionType type defines a structure that specifies
 // the action to take in a numeric_test_action when a value given
 // in response to a numeric_question falls within the indicated range.
@@ -851,31 +731,6 @@ type RangeTestActionConditionType struct {
 	Artifact_refs   ArtifactRefsType  `xml:"http://scap.nist.gov/schema/ocil/2.0 artifact_refs,omitempty"`
 }
 
-// The RangeType type defines a structure that specifies a
-// range against which a numeric response is to be compared.
-type RangeType struct {
-	Min RangeValueType `xml:"http://scap.nist.gov/schema/ocil/2.0 min,omitempty"`
-	Max RangeValueType `xml:"http://scap.nist.gov/schema/ocil/2.0 max,omitempty"`
-}
-
-// Defines a specific bound in a range.
-type RangeValueType struct {
-	Value     float64           `xml:",chardata"`
-	Inclusive bool              `xml:"inclusive,attr,omitempty"`
-	Var_ref   VariableIDPattern `xml:"var_ref,attr,omitempty"`
-}
-
-func (t *RangeValueType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	type T RangeValueType
-	var overlay struct {
-		*T
-		Inclusive *bool `xml:"inclusive,attr,omitempty"`
-	}
-	overlay.T = (*T)(t)
-	overlay.Inclusive = (*bool)(&overlay.T.Inclusive)
-	return d.DecodeElement(&overlay, &start)
-}
-
 type Reference struct {
 	Href string `xml:"href,attr,omitempty"`
 }
@@ -1110,36 +965,6 @@ type SubstitutionTextType struct {
 	Var_ref VariableIDPattern `xml:"var_ref,attr"`
 }
 
-// The SystemTargetType type defines structures containing
-// information about the organization it belongs to, a set of ip addresses
-// of computers/networks included in the system, descrioption about it, and
-// the roles it performs.
-type SystemTargetType struct {
-	Organization string   `xml:"http://scap.nist.gov/schema/ocil/2.0 organization,omitempty"`
-	Ipaddress    []string `xml:"http://scap.nist.gov/schema/ocil/2.0 ipaddress,omitempty"`
-	Description  TextType `xml:"http://scap.nist.gov/schema/ocil/2.0 description,omitempty"`
-	Name         string   `xml:"http://scap.nist.gov/schema/ocil/2.0 name"`
-	Notes        []string `xml:"http://scap.nist.gov/schema/ocil/2.0 notes,omitempty"`
-	Revision     int      `xml:"revision,attr,omitempty"`
-}
-
-func (t *SystemTargetType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	type T SystemTargetType
-	var overlay struct {
-		*T
-		Revision *int `xml:"revision,attr,omitempty"`
-	}
-	overlay.T = (*T)(t)
-	overlay.Revision = (*int)(&overlay.T.Revision)
-	return d.DecodeElement(&overlay, &start)
-}
-
-// The TargetsType type defines structures containing a set
-// of target elements.
-type TargetsType struct {
-	Target []NamedItemBaseType `xml:"http://scap.nist.gov/schema/ocil/2.0 target"`
-}
-
 // The TestActionConditionType complex type specifies processing
 // instructions - either produce a result or move on to another test. The
 // TestActionConditionType is extended by all handlers ("when_...") in
@@ -1187,12 +1012,6 @@ type TestActionResultsType struct {
 	Test_action_result []TestActionResultType `xml:"http://scap.nist.gov/schema/ocil/2.0 test_action_result"`
 }
 
-// The TestActionsType type defines a container for a set of
-// test action elements.
-type TestActionsType struct {
-	Test_action []ItemBaseType `xml:"http://scap.nist.gov/schema/ocil/2.0 test_action"`
-}
-
 // The data model that holds text-based artifacts.
 type TextArtifactValueType struct {
 	Data      string `xml:"http://scap.nist.gov/schema/ocil/2.0 data"`
@@ -1211,29 +1030,6 @@ type TextType struct {
 // exceptional condition may have occurred.
 type UserResponseType string
 
-// The UserType type defines structures containing
-// information about a user such as name, organization, position, email, and
-// role.
-type UserType struct {
-	Organization []string `xml:"http://scap.nist.gov/schema/ocil/2.0 organization,omitempty"`
-	Position     []string `xml:"http://scap.nist.gov/schema/ocil/2.0 position,omitempty"`
-	Email        []string `xml:"http://scap.nist.gov/schema/ocil/2.0 email,omitempty"`
-	Name         string   `xml:"http://scap.nist.gov/schema/ocil/2.0 name"`
-	Notes        []string `xml:"http://scap.nist.gov/schema/ocil/2.0 notes,omitempty"`
-	Revision     int      `xml:"revision,attr,omitempty"`
-}
-
-func (t *UserType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	type T UserType
-	var overlay struct {
-		*T
-		Revision *int `xml:"revision,attr,omitempty"`
-	}
-	overlay.T = (*T)(t)
-	overlay.Revision = (*int)(&overlay.T.Revision)
-	return d.DecodeElement(&overlay, &start)
-}
-
 // May be one of TEXT, NUMERIC
 type VariableDataType string
 
@@ -1270,12 +1066,6 @@ func (t *VariableType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) erro
 	return d.DecodeElement(&overlay, &start)
 }
 
-// The VariablesType type defines structures containing a
-// set of variables.
-type VariablesType struct {
-	Variable []VariableType `xml:"http://scap.nist.gov/schema/ocil/2.0 variable"`
-}
-
 // May be one of PASS, FAIL
 type _anon1 string
 
@@ -0,0 +1,527 @@
+package postal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The types in this file replace those xsdgen generates from
+// ocil-2.0.xsd where the generated form cannot be decoded by
+// encoding/xml or loses information: the containers of abstract
+// elements, which hold their substitution group members instead and
+// keep them in document order, choice questions, whose choices and
+// choice group references interleave, mixed question text, the
+// optional bounds of ranges, and the generator, whose schema version
+// is a decimal. Regenerating ocil_from_xsd.go must leave these types
+// out.
+
+// The ArtifactResultType type defines structures containing
+// information about the submitted artifact, its value, who provided and
+// submitted it, and when it was submitted.
+//
+// The abstract artifact_value element is expanded into its
+// substitution group members, exactly one of which is set.
+type ArtifactResultType struct {
+	Text_artifact_value      *TextArtifactValueType      `xml:"http://scap.nist.gov/schema/ocil/2.0 text_artifact_value,omitempty"`
+	Binary_artifact_value    *BinaryArtifactValueType    `xml:"http://scap.nist.gov/schema/ocil/2.0 binary_artifact_value,omitempty"`
+	Reference_artifact_value *ReferenceArtifactValueType `xml:"http://scap.nist.gov/schema/ocil/2.0 reference_artifact_value,omitempty"`
+	Provider                 ProviderValuePattern        `xml:"http://scap.nist.gov/schema/ocil/2.0 provider"`
+	Submitter                UserType                    `xml:"http://scap.nist.gov/schema/ocil/2.0 submitter"`
+	Artifact_ref             ArtifactIDPattern           `xml:"artifact_ref,attr"`
+	Timestamp                time.Time                   `xml:"timestamp,attr"`
+}
+
+func (t *ArtifactResultType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type T ArtifactResultType
+	var layout struct {
+		*T
+		Timestamp *xsdDateTime `xml:"timestamp,attr"`
+	}
+	layout.T = (*T)(t)
+	layout.Timestamp = (*xsdDateTime)(&layout.T.Timestamp)
+	return e.EncodeElement(layout, start)
+}
+
+func (t *ArtifactResultType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T ArtifactResultType
+	var overlay struct {
+		*T
+		Timestamp *xsdDateTime `xml:"timestamp,attr"`
+	}
+	overlay.T = (*T)(t)
+	overlay.Timestamp = (*xsdDateTime)(&overlay.T.Timestamp)
+	return d.DecodeElement(&overlay, &start)
+}
+
+// The ChoiceQuestionType type defines a question with one
+// or more acceptable answers specified by the author. The response will
+// be one of these specified answers. Acceptable answers are specified
+// either explicitly using the choice element or implicitly using the
+// choice_group_ref element to reference a choice_group element. Choices
+// are presented in the order in which they are provided. All the choices in
+// a choice_group are inserted in the order in which they appear within the
+// choice_group.
+type ChoiceQuestionType struct {
+	Question_text      []QuestionTextType `xml:"http://scap.nist.gov/schema/ocil/2.0 question_text"`
+	Instructions       InstructionsType   `xml:"http://scap.nist.gov/schema/ocil/2.0 instructions,omitempty"`
+	Notes              []string           `xml:"http://scap.nist.gov/schema/ocil/2.0 notes,omitempty"`
+	Choices            []ChoiceItem       `xml:",any"`
+	Default_answer_ref ChoiceIDPattern    `xml:"default_answer_ref,attr,omitempty"`
+	Id                 QuestionIDPattern  `xml:"id,attr"`
+	Revision           int                `xml:"revision,attr,omitempty"`
+}
+
+func (t *ChoiceQuestionType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T ChoiceQuestionType
+	var overlay struct {
+		*T
+		Revision *int `xml:"revision,attr,omitempty"`
+	}
+	overlay.T = (*T)(t)
+	overlay.Revision = (*int)(&overlay.T.Revision)
+	return d.DecodeElement(&overlay, &start)
+}
+
+// A ChoiceItem is a choice element of a choice question or, when
+// Choice is nil, a choice_group_ref element.
+type ChoiceItem struct {
+	Choice           *ChoiceType
+	Choice_group_ref ChoiceGroupIDPattern
+}
+
+func (c *ChoiceItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	switch start.Name.Local {
+	case "choice":
+		c.Choice = new(ChoiceType)
+		return d.DecodeElement(c.Choice, &start)
+	case "choice_group_ref":
+		return d.DecodeElement(&c.Choice_group_ref, &start)
+	}
+	return fmt.Errorf("unexpected element %s in choice_question", start.Name.Local)
+}
+
+func (c *ChoiceItem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if c.Choice != nil {
+		return e.EncodeElement(c.Choice, ocilStart("choice"))
+	}
+	return e.EncodeElement(c.Choice_group_ref, ocilStart("choice_group_ref"))
+}
+
+// The GeneratorType type defines an element that is used
+// to hold information about when a particular OCIL document was generated,
+// what version of the schema was used, what tool was used to generate the
+// document, and what version of the tool was used.
+//
+// Additional generator information is also allowed although
+// it is not part of the official OCIL language. Individual organizations
+// can place generator information that they feel is important.
+type GeneratorType struct {
+	Product_name    string                 `xml:"http://scap.nist.gov/schema/ocil/2.0 product_name,omitempty"`
+	Product_version string                 `xml:"http://scap.nist.gov/schema/ocil/2.0 product_version,omitempty"`
+	Author          []UserType             `xml:"http://scap.nist.gov/schema/ocil/2.0 author,omitempty"`
+	Schema_version  float64                `xml:"http://scap.nist.gov/schema/ocil/2.0 schema_version"`
+	Timestamp       time.Time              `xml:"http://scap.nist.gov/schema/ocil/2.0 timestamp"`
+	Additional_data ExtensionContainerType `xml:"http://scap.nist.gov/schema/ocil/2.0 additional_data,omitempty"`
+}
+
+func (t *GeneratorType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type T GeneratorType
+	var layout struct {
+		*T
+		Schema_version *schemaVersion `xml:"http://scap.nist.gov/schema/ocil/2.0 schema_version"`
+		Timestamp      *xsdDateTime   `xml:"http://scap.nist.gov/schema/ocil/2.0 timestamp"`
+	}
+	layout.T = (*T)(t)
+	layout.Schema_version = (*schemaVersion)(&layout.T.Schema_version)
+	layout.Timestamp = (*xsdDateTime)(&layout.T.Timestamp)
+	return e.EncodeElement(layout, start)
+}
+func (t *GeneratorType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T GeneratorType
+	var overlay struct {
+		*T
+		Timestamp *xsdDateTime `xml:"http://scap.nist.gov/schema/ocil/2.0 timestamp"`
+	}
+	overlay.T = (*T)(t)
+	overlay.Timestamp = (*xsdDateTime)(&overlay.T.Timestamp)
+	return d.DecodeElement(&overlay, &start)
+}
+
+// schemaVersion writes a schema version such as 2.0 with its
+// fraction, which encoding/xml drops from a float64.
+type schemaVersion float64
+
+func (v schemaVersion) MarshalText() ([]byte, error) {
+	s := strconv.FormatFloat(float64(v), 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return []byte(s), nil
+}
+
+// The QuestionResultsType type defines structures
+// containing computed results of all evaluated question
+// types.
+//
+// The abstract question_result element is expanded into its
+// substitution group members.
+type QuestionResultsType struct {
+	Boolean_question_result []BooleanQuestionResultType
+	Choice_question_result  []ChoiceQuestionResultType
+	Numeric_question_result []NumericQuestionResultType
+	String_question_result  []StringQuestionResultType
+	order                   itemOrder
+}
+
+func (t *QuestionResultsType) kinds() []itemKind {
+	return []itemKind{
+		{"boolean_question_result", &t.Boolean_question_result},
+		{"choice_question_result", &t.Choice_question_result},
+		{"numeric_question_result", &t.Numeric_question_result},
+		{"string_question_result", &t.String_question_result},
+	}
+}
+
+func (t *QuestionResultsType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalItems(e, start, t.order.items(t.kinds()))
+}
+
+func (t *QuestionResultsType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return t.order.decode(d, t.kinds())
+}
+
+// The QuestionTextType complex type defines a structure
+// to hold the text and variables that comprise a question's text.
+//
+// The content is mixed, so it is decoded by hand into runs of text
+// and sub elements; see question_text.go.
+type QuestionTextType struct {
+	Content []QuestionTextPart
+}
+
+// The QuestionsType type defines structures containing a
+// set of QuestionType and ChoiceGroupType elements.
+//
+// The abstract question element is expanded into its
+// substitution group members.
+type QuestionsType struct {
+	Boolean_question []BooleanQuestionType
+	Choice_question  []ChoiceQuestionType
+	Numeric_question []NumericQuestionType
+	String_question  []StringQuestionType
+	Choice_group     []ChoiceGroupType
+	order            itemOrder
+}
+
+func (t *QuestionsType) kinds() []itemKind {
+	return []itemKind{
+		{"boolean_question", &t.Boolean_question},
+		{"choice_question", &t.Choice_question},
+		{"numeric_question", &t.Numeric_question},
+		{"string_question", &t.String_question},
+		{"choice_group", &t.Choice_group},
+	}
+}
+
+func (t *QuestionsType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalItems(e, start, t.order.items(t.kinds()))
+}
+
+func (t *QuestionsType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return t.order.decode(d, t.kinds())
+}
+
+// The RangeType type defines a structure that specifies a
+// range against which a numeric response is to be compared.
+type RangeType struct {
+	Min *RangeValueType `xml:"http://scap.nist.gov/schema/ocil/2.0 min,omitempty"`
+	Max *RangeValueType `xml:"http://scap.nist.gov/schema/ocil/2.0 max,omitempty"`
+}
+
+// Defines a specific bound in a range.
+type RangeValueType struct {
+	Value     float64           `xml:",chardata"`
+	Inclusive bool              `xml:"inclusive,attr,omitempty"`
+	Var_ref   VariableIDPattern `xml:"var_ref,attr,omitempty"`
+}
+
+func (t *RangeValueType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T RangeValueType
+	var overlay struct {
+		*T
+		Inclusive *bool `xml:"inclusive,attr,omitempty"`
+	}
+	overlay.T = (*T)(t)
+	// The schema default, which xsdgen does not apply.
+	overlay.T.Inclusive = true
+	overlay.Inclusive = (*bool)(&overlay.T.Inclusive)
+	return d.DecodeElement(&overlay, &start)
+}
+
+// The SystemTargetType type defines structures containing
+// information about the organization it belongs to, a set of ip addresses
+// of computers/networks included in the system, descrioption about it, and
+// the roles it performs.
+type SystemTargetType struct {
+	Notes        []string `xml:"http://scap.nist.gov/schema/ocil/2.0 notes,omitempty"`
+	Name         string   `xml:"http://scap.nist.gov/schema/ocil/2.0 name"`
+	Organization string   `xml:"http://scap.nist.gov/schema/ocil/2.0 organization,omitempty"`
+	Ipaddress    []string `xml:"http://scap.nist.gov/schema/ocil/2.0 ipaddress,omitempty"`
+	Description  TextType `xml:"http://scap.nist.gov/schema/ocil/2.0 description,omitempty"`
+	Revision     int      `xml:"revision,attr,omitempty"`
+}
+
+func (t *SystemTargetType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T SystemTargetType
+	var overlay struct {
+		*T
+		Revision *int `xml:"revision,attr,omitempty"`
+	}
+	overlay.T = (*T)(t)
+	overlay.Revision = (*int)(&overlay.T.Revision)
+	return d.DecodeElement(&overlay, &start)
+}
+
+// The TargetsType type defines structures containing a set
+// of target elements.
+//
+// The abstract target element is expanded into its
+// substitution group members.
+type TargetsType struct {
+	User   []UserType
+	System []SystemTargetType
+	order  itemOrder
+}
+
+func (t *TargetsType) kinds() []itemKind {
+	return []itemKind{
+		{"user", &t.User},
+		{"system", &t.System},
+	}
+}
+
+func (t *TargetsType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalItems(e, start, t.order.items(t.kinds()))
+}
+
+func (t *TargetsType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return t.order.decode(d, t.kinds())
+}
+
+// The TestActionsType type defines a container for a set of
+// test action elements.
+//
+// The abstract test_action element is expanded into its
+// substitution group members.
+type TestActionsType struct {
+	Boolean_question_test_action []BooleanQuestionTestActionType
+	Choice_question_test_action  []ChoiceQuestionTestActionType
+	Numeric_question_test_action []NumericQuestionTestActionType
+	String_question_test_action  []StringQuestionTestActionType
+	order                        itemOrder
+}
+
+func (t *TestActionsType) kinds() []itemKind {
+	return []itemKind{
+		{"boolean_question_test_action", &t.Boolean_question_test_action},
+		{"choice_question_test_action", &t.Choice_question_test_action},
+		{"numeric_question_test_action", &t.Numeric_question_test_action},
+		{"string_question_test_action", &t.String_question_test_action},
+	}
+}
+
+func (t *TestActionsType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalItems(e, start, t.order.items(t.kinds()))
+}
+
+func (t *TestActionsType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return t.order.decode(d, t.kinds())
+}
+
+// The UserType type defines structures containing
+// information about a user such as name, organization, position, email, and
+// role.
+type UserType struct {
+	Notes        []string `xml:"http://scap.nist.gov/schema/ocil/2.0 notes,omitempty"`
+	Name         string   `xml:"http://scap.nist.gov/schema/ocil/2.0 name"`
+	Organization []string `xml:"http://scap.nist.gov/schema/ocil/2.0 organization,omitempty"`
+	Position     []string `xml:"http://scap.nist.gov/schema/ocil/2.0 position,omitempty"`
+	Email        []string `xml:"http://scap.nist.gov/schema/ocil/2.0 email,omitempty"`
+	Revision     int      `xml:"revision,attr,omitempty"`
+}
+
+func (t *UserType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T UserType
+	var overlay struct {
+		*T
+		Revision *int `xml:"revision,attr,omitempty"`
+	}
+	overlay.T = (*T)(t)
+	overlay.Revision = (*int)(&overlay.T.Revision)
+	return d.DecodeElement(&overlay, &start)
+}
+
+// The VariablesType type defines structures containing a
+// set of variables.
+//
+// The abstract variable element is expanded into its
+// substitution group members.
+type VariablesType struct {
+	Constant_variable []ConstantVariableType
+	Local_variable    []LocalVariableType
+	External_variable []ExternalVariableType
+	order             itemOrder
+}
+
+func (t *VariablesType) kinds() []itemKind {
+	return []itemKind{
+		{"constant_variable", &t.Constant_variable},
+		{"local_variable", &t.Local_variable},
+		{"external_variable", &t.External_variable},
+	}
+}
+
+func (t *VariablesType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalItems(e, start, t.order.items(t.kinds()))
+}
+
+func (t *VariablesType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return t.order.decode(d, t.kinds())
+}
+
+// An itemOrder records the order of the items of a container that
+// holds each kind of item in a slice of its own, as the element name
+// of each item in turn. Items appended to a slice without recording
+// them follow the recorded items, kind by kind.
+type itemOrder []string
+
+// An itemKind is the element name of a kind of item and a pointer
+// to the slice holding the items of that kind.
+type itemKind struct {
+	name  string
+	slice interface{}
+}
+
+// An item is an element name and a pointer to the item in its
+// slice.
+type item struct {
+	name string
+	v    interface{}
+}
+
+// items returns the items of the given kinds in order.
+func (o itemOrder) items(kinds []itemKind) []item {
+	slices := make(map[string]reflect.Value)
+	for _, k := range kinds {
+		slices[k.name] = reflect.ValueOf(k.slice).Elem()
+	}
+	next := make(map[string]int)
+	var items []item
+	add := func(name string) {
+		s, ok := slices[name]
+		if i := next[name]; ok && i < s.Len() {
+			items = append(items, item{name, s.Index(i).Addr().Interface()})
+			next[name]++
+		}
+	}
+	for _, name := range o {
+		add(name)
+	}
+	for _, k := range kinds {
+		for next[k.name] < slices[k.name].Len() {
+			add(k.name)
+		}
+	}
+	return items
+}
+
+// add records an item appended to the slice of the named kind.
+func (o *itemOrder) add(name string) {
+	*o = append(*o, name)
+}
+
+// set replaces the recorded order by the order of items.
+func (o *itemOrder) set(items []item) {
+	*o = nil
+	for _, it := range items {
+		o.add(it.name)
+	}
+}
+
+// decode appends each child element of the element being decoded to
+// the slice of its kind, recording the order. Elements of other
+// names are skipped.
+func (o *itemOrder) decode(d *xml.Decoder, kinds []itemKind) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			var slice interface{}
+			for _, k := range kinds {
+				if k.name == tok.Name.Local {
+					slice = k.slice
+				}
+			}
+			if slice == nil {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.DecodeElement(slice, &tok); err != nil {
+				return err
+			}
+			o.add(tok.Name.Local)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// documentOrdered is implemented by the containers that keep their
+// items of several kinds in document order. Code walking documents by
+// reflection sees only the slices of such a container, so whatever
+// copies or drops items must carry the order across with
+// documentItems and setOrder.
+type documentOrdered interface {
+	documentItems() []item
+	setOrder(items []item)
+}
+
+func (t *QuestionResultsType) documentItems() []item { return t.order.items(t.kinds()) }
+func (t *QuestionsType) documentItems() []item       { return t.order.items(t.kinds()) }
+func (t *TargetsType) documentItems() []item         { return t.order.items(t.kinds()) }
+func (t *TestActionsType) documentItems() []item     { return t.order.items(t.kinds()) }
+func (t *VariablesType) documentItems() []item       { return t.order.items(t.kinds()) }
+
+func (t *QuestionResultsType) setOrder(items []item) { t.order.set(items) }
+func (t *QuestionsType) setOrder(items []item)       { t.order.set(items) }
+func (t *TargetsType) setOrder(items []item)         { t.order.set(items) }
+func (t *TestActionsType) setOrder(items []item)     { t.order.set(items) }
+func (t *VariablesType) setOrder(items []item)       { t.order.set(items) }
+
+// marshalItems encodes start with the items as its children.
+func marshalItems(e *xml.Encoder, start xml.StartElement, items []item) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, it := range items {
+		if err := e.EncodeElement(it.v, ocilStart(it.name)); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// ocilStart returns the start of an element named local in the OCIL
+// namespace.
+func ocilStart(local string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Space: Namespace, Local: local}}
+}
@@ -0,0 +1,217 @@
+package postal
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChoicesOrder(t *testing.T) {
+	const doc = `<ocil xmlns="http://scap.nist.gov/schema/ocil/2.0"><questions>
+<choice_question id="ocil:t:question:1"><question_text>Q</question_text>%s</choice_question>
+<choice_group id="ocil:t:choicegroup:1"><choice id="ocil:t:choice:2">B</choice><choice id="ocil:t:choice:3">C</choice></choice_group>
+</questions></ocil>`
+	const (
+		a = `<choice id="ocil:t:choice:1">A</choice>`
+		g = `<choice_group_ref>ocil:t:choicegroup:1</choice_group_ref>`
+		d = `<choice id="ocil:t:choice:4">D</choice>`
+	)
+	tests := []struct {
+		name    string
+		choices string
+		want    []string
+	}{
+		{"inline first", a + g, []string{"A", "B", "C"}},
+		{"group first", g + a, []string{"B", "C", "A"}},
+		{"interleaved", a + g + d, []string{"A", "B", "C", "D"}},
+		{"group only", g, []string{"B", "C"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ReadDocument(strings.NewReader(fmt.Sprintf(doc, tt.choices)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The order must also survive writing the document.
+			var buf bytes.Buffer
+			if err := WriteDocument(&buf, doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc, err = ReadDocument(&buf); err != nil {
+				t.Fatal(err)
+			}
+			x, err := NewIndex(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			choices, err := x.Choices(&doc.Questions.Choice_question[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range choices {
+				got = append(got, c.Value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("choices %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerOrder(t *testing.T) {
+	const src = `<ocil xmlns="http://scap.nist.gov/schema/ocil/2.0">
+<test_actions>
+  <string_question_test_action id="ocil:t:testaction:1" question_ref="ocil:t:question:2"/>
+  <boolean_question_test_action id="ocil:t:testaction:2" question_ref="ocil:t:question:1"/>
+  <string_question_test_action id="ocil:t:testaction:3" question_ref="ocil:t:question:3"/>
+</test_actions>
+<questions>
+  <string_question id="ocil:t:question:2"><question_text>B</question_text></string_question>
+  <boolean_question id="ocil:t:question:1"><question_text>A</question_text></boolean_question>
+  <string_question id="ocil:t:question:3"><question_text>C</question_text></string_question>
+</questions>
+<results>
+  <targets>
+    <system><name>host</name></system>
+    <user><name>alice</name></user>
+  </targets>
+</results>
+</ocil>`
+	doc, err := ReadDocument(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Questions.Add(&BooleanQuestionType{Id: "ocil:t:question:4"})
+	var buf bytes.Buffer
+	if err := WriteDocument(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc, err = ReadDocument(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ta := range doc.Test_actions.All() {
+		got = append(got, string(ta.TestActionID()))
+	}
+	for _, q := range doc.Questions.All() {
+		got = append(got, string(q.QuestionID()))
+	}
+	for _, tg := range doc.Results.Targets.All() {
+		got = append(got, tg.TargetName())
+	}
+	want := []string{
+		"ocil:t:testaction:1", "ocil:t:testaction:2", "ocil:t:testaction:3",
+		"ocil:t:question:2", "ocil:t:question:1", "ocil:t:question:3", "ocil:t:question:4",
+		"host", "alice",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("items %q, want %q", got, want)
+	}
+}
+
+func TestContainerOrderTransforms(t *testing.T) {
+	const src = `<ocil xmlns="http://scap.nist.gov/schema/ocil/2.0">
+<questionnaires>
+  <questionnaire id="ocil:t:questionnaire:1"><actions>
+    <test_action_ref>ocil:t:testaction:2</test_action_ref>
+    <test_action_ref>ocil:t:testaction:3</test_action_ref>
+  </actions></questionnaire>
+</questionnaires>
+<test_actions>
+  <boolean_question_test_action id="ocil:t:testaction:1" question_ref="ocil:t:question:1"/>
+  <string_question_test_action id="ocil:t:testaction:2" question_ref="ocil:t:question:2"/>
+  <boolean_question_test_action id="ocil:t:testaction:3" question_ref="ocil:t:question:3"/>
+</test_actions>
+<questions>
+  <boolean_question id="ocil:t:question:1"><question_text>A</question_text></boolean_question>
+  <string_question id="ocil:t:question:2"><question_text>B</question_text></string_question>
+  <boolean_question id="ocil:t:question:3"><question_text>C</question_text></boolean_question>
+</questions>
+</ocil>`
+	tests := []struct {
+		name string
+		// apply transforms doc, returning the document to check and
+		// the new IDs of renumbered items.
+		apply func(doc *OCILType) (*OCILType, IDMap, error)
+		// want lists the test actions and questions expected, by
+		// their IDs in src, in document order.
+		want []string
+	}{
+		{
+			name: "extract",
+			apply: func(doc *OCILType) (*OCILType, IDMap, error) {
+				out, err := Extract(doc, []QuestionnaireIDPattern{"ocil:t:questionnaire:1"})
+				return out, nil, err
+			},
+			want: []string{
+				"ocil:t:testaction:2", "ocil:t:testaction:3",
+				"ocil:t:question:2", "ocil:t:question:3",
+			},
+		},
+		{
+			name: "renumber",
+			apply: func(doc *OCILType) (*OCILType, IDMap, error) {
+				m, err := Renumber(doc, "")
+				return doc, m, err
+			},
+			want: []string{
+				"ocil:t:testaction:1", "ocil:t:testaction:2", "ocil:t:testaction:3",
+				"ocil:t:question:1", "ocil:t:question:2", "ocil:t:question:3",
+			},
+		},
+		{
+			name: "clone",
+			apply: func(doc *OCILType) (*OCILType, IDMap, error) {
+				out := CloneDocument(doc)
+				out.Test_actions.Add(&StringQuestionTestActionType{Id: "ocil:t:testaction:4", Question_ref: "ocil:t:question:2"})
+				// Items added to doc after cloning stay out of
+				// the clone.
+				doc.Test_actions.Add(&BooleanQuestionTestActionType{Id: "ocil:t:testaction:5", Question_ref: "ocil:t:question:1"})
+				return out, nil, nil
+			},
+			want: []string{
+				"ocil:t:testaction:1", "ocil:t:testaction:2", "ocil:t:testaction:3", "ocil:t:testaction:4",
+				"ocil:t:question:1", "ocil:t:question:2", "ocil:t:question:3",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ReadDocument(strings.NewReader(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, m, err := tt.apply(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := WriteDocument(&buf, out); err != nil {
+				t.Fatal(err)
+			}
+			if out, err = ReadDocument(&buf); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, ta := range out.Test_actions.All() {
+				got = append(got, string(ta.TestActionID()))
+			}
+			for _, q := range out.Questions.All() {
+				got = append(got, string(q.QuestionID()))
+			}
+			want := tt.want
+			if m != nil {
+				want = nil
+				for _, id := range tt.want {
+					want = append(want, m[id])
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("items %q, want %q", got, want)
+			}
+		})
+	}
+}
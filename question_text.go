@@ -0,0 +1,118 @@
+package postal
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// A QuestionTextPart is a run of question text or, when Sub is not
+// nil, a substitution of the value of a variable.
+type QuestionTextPart struct {
+	Text string
+	Sub  *SubstitutionTextType
+}
+
+// PlainText returns question text without substitutions.
+func PlainText(s string) QuestionTextType {
+	return QuestionTextType{Content: []QuestionTextPart{{Text: s}}}
+}
+
+func (t *QuestionTextType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.CharData:
+			if n := len(t.Content); n > 0 && t.Content[n-1].Sub == nil {
+				t.Content[n-1].Text += string(tok)
+			} else {
+				t.Content = append(t.Content, QuestionTextPart{Text: string(tok)})
+			}
+		case xml.StartElement:
+			if tok.Name.Local != "sub" {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			var sub SubstitutionTextType
+			if err := d.DecodeElement(&sub, &tok); err != nil {
+				return err
+			}
+			t.Content = append(t.Content, QuestionTextPart{Sub: &sub})
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (t *QuestionTextType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, p := range t.Content {
+		if p.Sub != nil {
+			sub := xml.StartElement{
+				Name: xml.Name{Space: Namespace, Local: "sub"},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "var_ref"}, Value: string(p.Sub.Var_ref)}},
+			}
+			if err := e.EncodeElement("", sub); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.EncodeToken(xml.CharData(p.Text)); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// Format returns the text with each substitution replaced by
+// sub(var_ref). Runs of white space are collapsed.
+func (t QuestionTextType) Format(sub func(VariableIDPattern) string) string {
+	var b strings.Builder
+	for _, p := range t.Content {
+		if p.Sub != nil {
+			b.WriteString(sub(p.Sub.Var_ref))
+		} else {
+			b.WriteString(p.Text)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// String returns the text with each substitution shown as a
+// ${var_ref} placeholder.
+func (t QuestionTextType) String() string {
+	return t.Format(func(id VariableIDPattern) string { return "${" + string(id) + "}" })
+}
+
+// QuestionText joins the question_text elements of q, substituting
+// the values of the document's constants and then of vars, which
+// may be nil. Variables without a value are shown as placeholders.
+func (x *Index) QuestionText(q Question, vars VariableSource) string {
+	chain := variableChain{x, vars}
+	var parts []string
+	for _, t := range q.Text() {
+		parts = append(parts, t.Format(func(id VariableIDPattern) string {
+			if v, ok := chain.Lookup(id); ok {
+				return v
+			}
+			return "${" + string(id) + "}"
+		}))
+	}
+	return strings.Join(parts, " ")
+}
+
+// QuestionText joins the question_text elements of q, showing
+// substitutions as placeholders.
+func QuestionText(q Question) string {
+	var parts []string
+	for _, t := range q.Text() {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,244 @@
+package postal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Reference systems recognized by ReferenceSystem.
+const (
+	SystemCCE   = "CCE"
+	SystemCVE   = "CVE"
+	SystemCPE   = "CPE"
+	SystemCCI   = "CCI"
+	SystemNIST  = "NIST 800-53"
+	SystemSTIG  = "STIG"
+	SystemOther = "other"
+)
+
+// referencePatterns recognize reference identifiers by form, in the
+// order they are tried.
+var referencePatterns = []struct {
+	system string
+	re     *regexp.Regexp
+}{
+	{SystemCCE, regexp.MustCompile(`^CCE-\d+(-\d)?$`)},
+	{SystemCVE, regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)},
+	{SystemCPE, regexp.MustCompile(`^(?i)cpe:`)},
+	{SystemCCI, regexp.MustCompile(`^CCI-\d+$`)},
+	{SystemSTIG, regexp.MustCompile(`^(V-\d+|SV-\d+r\d+(_rule)?|[A-Z0-9]+-\d{2}-\d{6})$`)},
+	{SystemNIST, regexp.MustCompile(`^[A-Z]{2}-\d+( ?\(\d+\))?$`)},
+}
+
+// referenceHrefs recognize reference systems by a substring of the
+// reference href, for identifiers of no recognizable form.
+var referenceHrefs = []struct {
+	system string
+	substr string
+}{
+	{SystemCCE, "cce.mitre.org"},
+	{SystemCVE, "cve.mitre.org"},
+	{SystemCVE, "nvd.nist.gov/vuln"},
+	{SystemCPE, "cpe"},
+	{SystemCCI, "/cci"},
+	{SystemNIST, "800-53"},
+	{SystemSTIG, "stig"},
+}
+
+// ReferenceSystem names the identification system of a reference:
+// one of the System constants, judged by the form of its value and
+// failing that by its href.
+func ReferenceSystem(r ReferenceType) string {
+	v := strings.TrimSpace(r.Value)
+	for _, p := range referencePatterns {
+		if p.re.MatchString(v) {
+			return p.system
+		}
+	}
+	href := strings.ToLower(r.Href)
+	for _, h := range referenceHrefs {
+		if strings.Contains(href, h.substr) {
+			return h.system
+		}
+	}
+	return SystemOther
+}
+
+// referenceKey normalizes an identifier for lookup: without white
+// space and in upper case, so that "ac-2 (1)" finds "AC-2(1)".
+func referenceKey(id string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(id), ""))
+}
+
+// A RefEntry records one use of a reference: on a questionnaire,
+// or on an instruction step of a question reached from it.
+type RefEntry struct {
+	File          string
+	Questionnaire QuestionnaireIDPattern
+	Question      QuestionIDPattern
+	Reference     ReferenceType
+	System        string
+}
+
+// A RefIndex finds the questionnaires covering a reference
+// identifier across any number of documents.
+type RefIndex struct {
+	entries map[string][]RefEntry
+}
+
+// NewRefIndex returns an empty reference index.
+func NewRefIndex() *RefIndex {
+	return &RefIndex{entries: make(map[string][]RefEntry)}
+}
+
+func (ix *RefIndex) add(e RefEntry) {
+	k := referenceKey(e.Reference.Value)
+	if k == "" {
+		return
+	}
+	e.System = ReferenceSystem(e.Reference)
+	ix.entries[k] = append(ix.entries[k], e)
+}
+
+// Add indexes the references of the document read from file. A
+// reference on an instruction step is recorded for every
+// questionnaire from which the question is reachable.
+func (ix *RefIndex) Add(file string, doc *OCILType) error {
+	x, err := NewIndex(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	for _, q := range doc.Questionnaires.Questionnaire {
+		for _, r := range q.References.Reference {
+			ix.add(RefEntry{File: file, Questionnaire: q.Id, Reference: r})
+		}
+		for _, id := range x.ReachableQuestions(TestActionRefValuePattern(q.Id)) {
+			question, ok := x.Questions[id]
+			if !ok {
+				continue
+			}
+			var walk func([]StepType)
+			walk = func(steps []StepType) {
+				for _, s := range steps {
+					for _, r := range s.Reference {
+						ix.add(RefEntry{File: file, Questionnaire: q.Id, Question: id, Reference: r})
+					}
+					walk(s.Step)
+				}
+			}
+			walk(question.Instruction().Step)
+		}
+	}
+	return nil
+}
+
+// AddPath indexes the OCIL document in the named file, or every
+// OCIL 2.0 document below the named directory. Within a directory,
+// XML files whose root element is not that of OCIL 2.0 are skipped;
+// files that cannot be read, parsed or indexed do not stop the walk,
+// and their errors are returned together once the other documents
+// are indexed.
+func (ix *RefIndex) AddPath(name string) error {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		doc, err := ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := ix.Add(name, doc); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return nil
+	}
+	var errs []error
+	err = filepath.Walk(name, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if fi.IsDir() || filepath.Ext(path) != ".xml" {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		doc, err := ReadDocument(f)
+		f.Close()
+		if errors.Is(err, ErrNotOCIL) {
+			return nil
+		}
+		if err == nil {
+			err = ix.Add(path, doc)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", path, err))
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Find returns the uses of the reference identifier id, in the
+// order they were indexed.
+func (ix *RefIndex) Find(id string) []RefEntry {
+	return ix.entries[referenceKey(id)]
+}
+
+// Questionnaires returns the questionnaires covering id, each
+// once, sorted by file and ID.
+func (ix *RefIndex) Questionnaires(id string) []RefEntry {
+	seen := make(map[string]bool)
+	var out []RefEntry
+	for _, e := range ix.Find(id) {
+		k := e.File + "\x00" + string(e.Questionnaire)
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Questionnaire < out[j].Questionnaire
+	})
+	return out
+}
+
+// IDs returns the indexed identifiers of the given system, or of
+// every system when system is empty, sorted.
+func (ix *RefIndex) IDs(system string) []string {
+	var ids []string
+	for _, es := range ix.entries {
+		if system == "" || es[0].System == system {
+			ids = append(ids, strings.TrimSpace(es[0].Reference.Value))
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Uncovered returns the identifiers among ids that no indexed
+// questionnaire references, in the order given.
+func (ix *RefIndex) Uncovered(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		if len(ix.Find(id)) == 0 {
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package postal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddPath(t *testing.T) {
+	sample, err := os.ReadFile("testdata/sample.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	benchmark, err := os.ReadFile("testdata/benchmark.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const dupQuestionnaires = `<ocil xmlns="http://scap.nist.gov/schema/ocil/2.0"><questionnaires>
+<questionnaire id="ocil:t:questionnaire:1"/><questionnaire id="ocil:t:questionnaire:1"/>
+</questionnaires></ocil>`
+	tests := []struct {
+		name  string
+		files map[string]string
+		// errs lists the files whose errors are reported.
+		errs []string
+	}{
+		{"documents only", map[string]string{"a.xml": string(sample), "sub/b.xml": string(sample)}, nil},
+		{"other XML and files skipped", map[string]string{"a.xml": string(sample), "benchmark.xml": string(benchmark), "notes.txt": "x"}, nil},
+		{"parse error", map[string]string{"a.xml": string(sample), "broken.xml": string(sample[:len(sample)/2])}, []string{"broken.xml"}},
+		{"malformed XML", map[string]string{"a.xml": string(sample), "junk.xml": "not xml"}, []string{"junk.xml"}},
+		{"index error", map[string]string{"a.xml": string(sample), "dup.xml": dupQuestionnaires}, []string{"dup.xml"}},
+		{"several errors", map[string]string{"a.xml": string(sample), "broken.xml": "<ocil", "dup.xml": dupQuestionnaires}, []string{"broken.xml", "dup.xml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				p := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(p), 0o777); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(data), 0o666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ix := NewRefIndex()
+			err := ix.AddPath(dir)
+			var msg string
+			if err != nil {
+				msg = err.Error()
+			}
+			if got := strings.Count(msg, "\n") + 1; err != nil && got != len(tt.errs) || err == nil && len(tt.errs) > 0 {
+				t.Errorf("error %q, want errors for %q", msg, tt.errs)
+			}
+			for _, name := range tt.errs {
+				if !strings.Contains(msg, filepath.Join(dir, name)+":") {
+					t.Errorf("error %q does not report %s", msg, name)
+				}
+			}
+			// The readable documents are indexed despite the errors.
+			if len(ix.Find("CCE-1234")) == 0 {
+				t.Errorf("a.xml not indexed")
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package postal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// A ChecklistFormat selects the markup of WriteChecklist.
+type ChecklistFormat int
+
+const (
+	ChecklistMarkdown ChecklistFormat = iota
+	ChecklistText
+)
+
+// WriteChecklist writes the questionnaires of doc for reading
+// without XML: for each questionnaire the test actions it reaches,
+// each with its question text, instructions, allowed answers and
+// the outcome each answer leads to. Variable substitutions in
+// question text take their values from the document's constants
+// and vars, which may be nil; others are shown as ${var_ref}
+// placeholders. The output is stable for a given document, so it
+// can be committed alongside the XML to give readable diffs.
+func WriteChecklist(w io.Writer, doc *OCILType, format ChecklistFormat, vars VariableSource) error {
+	x, err := NewIndex(doc)
+	if err != nil {
+		return err
+	}
+	c := &checklist{x: x, vars: vars, w: bufio.NewWriter(w), md: format == ChecklistMarkdown}
+	c.heading(1, c.escape(doc.Document.Title))
+	for _, d := range doc.Document.Description {
+		c.para(c.escape(d))
+	}
+	for _, n := range doc.Document.Notice {
+		c.para(c.escape(n))
+	}
+	for i := range doc.Questionnaires.Questionnaire {
+		if err := c.questionnaire(&doc.Questionnaires.Questionnaire[i]); err != nil {
+			return err
+		}
+	}
+	return c.w.Flush()
+}
+
+type checklist struct {
+	x    *Index
+	vars VariableSource
+	w    *bufio.Writer
+	md   bool
+}
+
+// The text arguments of heading, para and item are in the output
+// format: document text must have been passed through escape.
+
+func (c *checklist) heading(level int, text string) {
+	if text = strings.Join(strings.Fields(text), " "); text == "" {
+		return
+	}
+	if c.md {
+		fmt.Fprintf(c.w, "%s %s\n\n", strings.Repeat("#", level), text)
+		return
+	}
+	underline := "="
+	if level > 1 {
+		underline = "-"
+	}
+	if level > 2 {
+		fmt.Fprintf(c.w, "%s\n\n", text)
+		return
+	}
+	fmt.Fprintf(c.w, "%s\n%s\n\n", text, strings.Repeat(underline, len(text)))
+}
+
+func (c *checklist) para(text string) {
+	if text = strings.Join(strings.Fields(text), " "); text != "" {
+		fmt.Fprintf(c.w, "%s\n\n", text)
+	}
+}
+
+// item writes text as a list item, indenting any further lines of
+// it so that they continue the item.
+func (c *checklist) item(depth int, text string) {
+	bullet := "- "
+	if !c.md {
+		bullet = "* "
+	}
+	indent := strings.Repeat("  ", depth)
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	fmt.Fprintf(c.w, "%s%s%s\n", indent, bullet, lines[0])
+	for _, l := range lines[1:] {
+		if l = strings.TrimRight(l, " \t"); l == "" {
+			fmt.Fprintln(c.w)
+			continue
+		}
+		fmt.Fprintf(c.w, "%s  %s\n", indent, l)
+	}
+}
+
+// mdSpecial are the characters escape backslash-escapes so that
+// Markdown does not read them as emphasis, links, code spans,
+// headings, block quotes or HTML, such as the <VulnDiscussion> tags
+// of STIG content.
+const mdSpecial = "\\`*_[]#<>&"
+
+// mdListMarker and mdNumberMarker find the starts of lines that
+// Markdown would read as list items or heading underlines.
+var (
+	mdListMarker   = regexp.MustCompile(`(?m)^([ \t]*)([-+=])`)
+	mdNumberMarker = regexp.MustCompile(`(?m)^([ \t]*[0-9]+)([.)])`)
+)
+
+// escape returns document text s as literal text in the output
+// format.
+func (c *checklist) escape(s string) string {
+	if !c.md {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(mdSpecial, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	s = mdListMarker.ReplaceAllString(b.String(), `${1}\${2}`)
+	return mdNumberMarker.ReplaceAllString(s, `${1}\${2}`)
+}
+
+// code formats s as a code span, using a fence longer than any run
+// of backticks in s.
+func (c *checklist) code(s string) string {
+	if !c.md {
+		return s
+	}
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if len(fence) > 1 {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+var mdHref = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
+// link formats document text linking to href.
+func (c *checklist) link(text, href string) string {
+	text = c.escape(text)
+	if c.md && href != "" {
+		return "[" + text + "](" + mdHref.Replace(href) + ")"
+	}
+	if href != "" {
+		return text + " <" + href + ">"
+	}
+	return text
+}
+
+func (c *checklist) questionnaire(q *QuestionnaireType) error {
+	title := q.Title.Value
+	if title == "" {
+		title = string(q.Id)
+	}
+	c.heading(2, c.escape(title))
+	info := "ID: " + c.code(string(q.Id))
+	if q.Child_only {
+		info += " (child only)"
+	}
+	c.para(info)
+	c.para(c.escape(q.Description.Value))
+	for _, n := range q.Notes {
+		c.para("Note: " + c.escape(n))
+	}
+	c.references(q.References.Reference)
+	c.para(c.operation(q.Actions))
+
+	// Render every test action reachable without passing through
+	// another questionnaire; child questionnaires have their own
+	// section.
+	seen := make(map[QuestionTestActionIDPattern]bool)
+	var walk func(refs []TestActionRefValuePattern) error
+	walk = func(refs []TestActionRefValuePattern) error {
+		for _, ref := range refs {
+			id := QuestionTestActionIDPattern(ref)
+			ta, ok := c.x.TestActions[id]
+			if !ok || seen[id] {
+				continue
+			}
+			seen[id] = true
+			if err := c.testAction(ta); err != nil {
+				return err
+			}
+			var next []TestActionRefValuePattern
+			for _, h := range ta.Handlers() {
+				if h.Ref() != "" {
+					next = append(next, h.Ref())
+				}
+			}
+			if err := walk(next); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var refs []TestActionRefValuePattern
+	for _, r := range q.Actions.Test_action_ref {
+		refs = append(refs, r.TestActionRefValuePattern)
+	}
+	return walk(refs)
+}
+
+func (c *checklist) operation(op OperationType) string {
+	var s string
+	if op.Operation == OperatorOr {
+		s = "Passes when any of these pass"
+	} else {
+		s = "Passes when all of these pass"
+	}
+	if op.Negate {
+		s += ", negated"
+	}
+	var refs []string
+	for _, r := range op.Test_action_ref {
+		refs = append(refs, c.target(r))
+	}
+	return s + ": " + strings.Join(refs, ", ")
+}
+
+// target names the test action or questionnaire a reference
+// points to.
+func (c *checklist) target(r TestActionRefType) string {
+	s := c.code(string(r.TestActionRefValuePattern))
+	if q, ok := c.x.Questionnaires[QuestionnaireIDPattern(r.TestActionRefValuePattern)]; ok {
+		s = "questionnaire " + s
+		if q.Title.Value != "" {
+			s += " (" + c.escape(q.Title.Value) + ")"
+		}
+	}
+	if r.Negate {
+		s = "not " + s
+	}
+	return s
+}
+
+func (c *checklist) outcome(h Handler) string {
+	if h.Ref() != "" {
+		return "continue with " + c.target(h.Test_action_ref)
+	}
+	if h.Result == "" {
+		return "no result"
+	}
+	return string(h.Result)
+}
+
+func (c *checklist) testAction(ta TestAction) error {
+	c.heading(3, "Test action "+c.code(string(ta.TestActionID())))
+	q, ok := c.x.Questions[ta.QuestionRef()]
+	if !ok {
+		return fmt.Errorf("test action %s: unknown question %s", ta.TestActionID(), ta.QuestionRef())
+	}
+	c.para("Question " + c.code(string(q.QuestionID())) + ": " + c.escape(c.x.QuestionText(q, c.vars)))
+	if in := q.Instruction(); len(in.Step) > 0 {
+		title := in.Title.Value
+		if title == "" {
+			title = "Instructions"
+		}
+		c.para(c.escape(title) + ":")
+		c.steps(0, in.Step)
+		fmt.Fprintln(c.w)
+	}
+	for _, n := range questionNotes(q) {
+		c.para("Note: " + c.escape(n))
+	}
+
+	c.para("Answers:")
+	handlers := ta.Handlers()
+	if cq, ok := q.(*ChoiceQuestionType); ok {
+		choices, err := c.x.Choices(cq)
+		if err != nil {
+			return err
+		}
+		for _, ch := range choices {
+			out := "no handler (ERROR)"
+			for _, h := range handlers {
+				if matched, _ := h.Condition.Matches(Answer{Response: ResponseAnswered, Choice: ch.Id}, nil); matched {
+					out = c.outcome(h)
+					break
+				}
+			}
+			text := ch.Value
+			if ch.Var_ref != "" {
+				text = "${" + string(ch.Var_ref) + "}"
+			}
+			text = c.escape(text)
+			c.item(0, fmt.Sprintf("%s %s → %s", text, c.code(string(ch.Id)), out))
+		}
+	}
+	for _, h := range handlers {
+		if h.Condition.Choices != nil {
+			continue
+		}
+		c.item(0, c.answer(q, h.Condition)+" → "+c.outcome(h))
+	}
+	fmt.Fprintln(c.w)
+	return nil
+}
+
+// answer describes the answers selecting a handler.
+func (c *checklist) answer(q Question, cond Condition) string {
+	if cond.Response != ResponseAnswered {
+		return "response " + string(cond.Response)
+	}
+	if cond.Boolean != nil {
+		return c.escape(c.x.FormatAnswer(q, Answer{Response: ResponseAnswered, Boolean: *cond.Boolean}))
+	}
+	return c.escape(cond.String())
+}
+
+func (c *checklist) steps(depth int, steps []StepType) {
+	for _, s := range steps {
+		text := c.escape(strings.Join(strings.Fields(s.Description.Value), " "))
+		if s.Is_required {
+			text += " (required)"
+		}
+		var refs []string
+		for _, r := range s.Reference {
+			refs = append(refs, c.link(r.Value, r.Href))
+		}
+		if len(refs) > 0 {
+			text += " [" + strings.Join(refs, ", ") + "]"
+		}
+		c.item(depth, text)
+		c.steps(depth+1, s.Step)
+	}
+}
+
+func (c *checklist) references(refs []ReferenceType) {
+	if len(refs) == 0 {
+		return
+	}
+	c.para("References:")
+	for _, r := range refs {
+		c.item(0, c.link(r.Value, r.Href))
+	}
+	fmt.Fprintln(c.w)
+}
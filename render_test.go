@@ -0,0 +1,56 @@
+package postal
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with the named file in testdata, or rewrites
+// the file with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	name = "testdata/" + name
+	if *update {
+		if err := os.WriteFile(name, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n%s", name, got)
+	}
+}
+
+func TestWriteChecklist(t *testing.T) {
+	tests := []struct {
+		doc    string
+		format ChecklistFormat
+		golden string
+	}{
+		{"sample.xml", ChecklistMarkdown, "sample.md"},
+		{"sample.xml", ChecklistText, "sample.txt"},
+		{"markup.xml", ChecklistMarkdown, "markup.md"},
+		{"markup.xml", ChecklistText, "markup.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			doc, err := ReadFile("testdata/" + tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := WriteChecklist(&buf, doc, tt.format, nil); err != nil {
+				t.Fatal(err)
+			}
+			golden(t, tt.golden, buf.Bytes())
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package postal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// idKinds gives the kind segment of the IDs of each type of item.
+var idKinds = map[reflect.Type]string{
+	reflect.TypeOf(QuestionnaireIDPattern("")):      "questionnaire",
+	reflect.TypeOf(QuestionTestActionIDPattern("")): "testaction",
+	reflect.TypeOf(QuestionIDPattern("")):           "question",
+	reflect.TypeOf(ChoiceIDPattern("")):             "choice",
+	reflect.TypeOf(ChoiceGroupIDPattern("")):        "choicegroup",
+	reflect.TypeOf(VariableIDPattern("")):           "variable",
+	reflect.TypeOf(ArtifactIDPattern("")):           "artifact",
+}
+
+// renumberKinds is the order of kinds in a written IDMap.
+var renumberKinds = []string{"questionnaire", "testaction", "question", "choicegroup", "choice", "variable", "artifact"}
+
+// An IDMap maps old item IDs to new ones.
+type IDMap map[string]string
+
+// Renumber gives every questionnaire, test action, question,
+// choice, choice group, variable and artifact of doc a new ID in
+// namespace ns, numbering the items of each kind from 1 in document
+// order, and rewrites every reference to them, including those in
+// the results. When ns is empty each item keeps its namespace and
+// numbers are compacted per namespace. The returned map lets other
+// documents, such as earlier results, be migrated with Apply.
+func Renumber(doc *OCILType, ns string) (IDMap, error) {
+	if ns != "" {
+		if _, ok := ParseID(ID{ns, "question", 1}.String()); !ok {
+			return nil, fmt.Errorf("invalid namespace %q", ns)
+		}
+	}
+	if _, err := NewIndex(doc); err != nil {
+		return nil, err
+	}
+	m := make(IDMap)
+	next := make(map[ID]int)
+	var err error
+	itemIDs(reflect.ValueOf(doc).Elem(), func(id string, kind string) {
+		if _, ok := m[id]; ok || err != nil {
+			return
+		}
+		to := ID{Namespace: ns, Kind: kind}
+		if ns == "" {
+			old, ok := ParseID(id)
+			if !ok {
+				err = fmt.Errorf("%s: not an OCIL ID", id)
+				return
+			}
+			to.Namespace = old.Namespace
+		}
+		counter := ID{Namespace: to.Namespace, Kind: kind}
+		next[counter]++
+		to.N = next[counter]
+		m[id] = to.String()
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.Apply(doc)
+	return m, nil
+}
+
+// itemIDs calls f with the ID and kind of every item defined in v,
+// in document order.
+func itemIDs(v reflect.Value, f func(id, kind string)) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			itemIDs(v.Elem(), f)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			itemIDs(v.Index(i), f)
+		}
+	case reflect.Struct:
+		// The containers mixing items of several kinds are walked
+		// in document order rather than kind by kind.
+		if v.CanAddr() {
+			if c, ok := v.Addr().Interface().(documentOrdered); ok {
+				for _, it := range c.documentItems() {
+					itemIDs(reflect.ValueOf(it.v), f)
+				}
+				return
+			}
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if kind, ok := idKinds[field.Type]; ok && field.Name == "Id" {
+				if id := v.Field(i).String(); id != "" {
+					f(id, kind)
+				}
+				continue
+			}
+			itemIDs(v.Field(i), f)
+		}
+	}
+}
+
+// Apply replaces the IDs of m in doc, and every reference to them,
+// by their new IDs. Other IDs are left alone.
+func (m IDMap) Apply(doc *OCILType) {
+	MapIDs(doc, func(id string) string {
+		if to, ok := m[id]; ok {
+			return to
+		}
+		return id
+	})
+}
+
+// Write writes m as one old=new line per ID, ordered by kind and
+// new number.
+func (m IDMap) Write(w io.Writer) error {
+	order := make(map[string]int)
+	for i, k := range renumberKinds {
+		order[k] = i
+	}
+	olds := make([]string, 0, len(m))
+	for old := range m {
+		olds = append(olds, old)
+	}
+	sort.Slice(olds, func(i, j int) bool {
+		a, aok := ParseID(m[olds[i]])
+		b, bok := ParseID(m[olds[j]])
+		switch {
+		case !aok || !bok:
+			return m[olds[i]] < m[olds[j]]
+		case order[a.Kind] != order[b.Kind]:
+			return order[a.Kind] < order[b.Kind]
+		case a.Namespace != b.Namespace:
+			return a.Namespace < b.Namespace
+		}
+		return a.N < b.N
+	})
+	bw := bufio.NewWriter(w)
+	for _, old := range olds {
+		fmt.Fprintf(bw, "%s=%s\n", old, m[old])
+	}
+	return bw.Flush()
+}
+
+// ReadIDMap reads an IDMap written by Write. Blank lines and lines
+// starting with # are ignored.
+func ReadIDMap(r io.Reader) (IDMap, error) {
+	m := make(IDMap)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: %q is not an old=new mapping", n, line)
+		}
+		old, new := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if old == "" || new == "" {
+			return nil, fmt.Errorf("line %d: %q is not an old=new mapping", n, line)
+		}
+		if prev, ok := m[old]; ok && prev != new {
+			return nil, fmt.Errorf("line %d: %s is mapped twice", n, old)
+		}
+		m[old] = new
+	}
+	return m, s.Err()
+}
+
+// ReadIDMapFile reads the IDMap in the named file.
+func ReadIDMapFile(name string) (IDMap, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := ReadIDMap(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,150 @@
+package postal
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenumber(t *testing.T) {
+	tests := []struct {
+		name string
+		// gaps are applied to the sample before renumbering.
+		gaps IDMap
+		ns   string
+		// want lists the IDs expected to move; every other ID
+		// maps to itself.
+		want IDMap
+		err  bool
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name: "compact gaps",
+			gaps: IDMap{
+				"ocil:org.example:question:2":      "ocil:org.example:question:9",
+				"ocil:org.example:question:5":      "ocil:org.example:question:20",
+				"ocil:org.example:choice:1":        "ocil:org.example:choice:7",
+				"ocil:org.example:questionnaire:1": "ocil:org.example:questionnaire:3",
+			},
+			want: IDMap{
+				"ocil:org.example:question:9":      "ocil:org.example:question:2",
+				"ocil:org.example:question:20":     "ocil:org.example:question:5",
+				"ocil:org.example:choice:7":        "ocil:org.example:choice:1",
+				"ocil:org.example:questionnaire:3": "ocil:org.example:questionnaire:1",
+			},
+		},
+		{
+			name: "document order",
+			gaps: IDMap{
+				"ocil:org.example:question:1": "ocil:org.example:question:5",
+				"ocil:org.example:question:5": "ocil:org.example:question:1",
+			},
+			want: IDMap{
+				"ocil:org.example:question:5": "ocil:org.example:question:1",
+				"ocil:org.example:question:1": "ocil:org.example:question:5",
+			},
+		},
+		{
+			name: "new namespace",
+			ns:   "org.new",
+		},
+		{
+			name: "per namespace",
+			gaps: IDMap{
+				"ocil:org.example:question:3": "ocil:org.other:question:3",
+				"ocil:org.example:question:4": "ocil:org.other:question:4",
+			},
+			want: IDMap{
+				"ocil:org.example:question:5": "ocil:org.example:question:3",
+				"ocil:org.other:question:3":   "ocil:org.other:question:1",
+				"ocil:org.other:question:4":   "ocil:org.other:question:2",
+			},
+		},
+		{
+			name: "invalid namespace",
+			ns:   "org example",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		doc, err := ReadFile("testdata/answers-a.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.gaps.Apply(doc)
+		before := CloneDocument(doc)
+		m, err := Renumber(doc, tt.ns)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for old, to := range m {
+			want := old
+			if tt.ns != "" {
+				want = strings.Replace(old, ":org.example:", ":"+tt.ns+":", 1)
+			}
+			if w, ok := tt.want[old]; ok {
+				want = w
+			}
+			if to != want {
+				t.Errorf("%s: %s renumbered to %s, want %s", tt.name, old, to, want)
+			}
+		}
+		for old := range tt.want {
+			if _, ok := m[old]; !ok {
+				t.Errorf("%s: %s not renumbered", tt.name, old)
+			}
+		}
+		// The results follow the renumbering, so the answers of
+		// the document still give the same results.
+		m.Apply(before)
+		if !reflect.DeepEqual(doc.Results.Answers(), before.Results.Answers()) {
+			t.Errorf("%s: results not renumbered with the document", tt.name)
+		}
+		if _, err := NewIndex(doc); err != nil {
+			t.Errorf("%s: renumbered document: %v", tt.name, err)
+		}
+	}
+}
+
+func TestIDMapReadWrite(t *testing.T) {
+	m := IDMap{
+		"ocil:a:question:9":      "ocil:b:question:2",
+		"ocil:a:question:3":      "ocil:b:question:1",
+		"ocil:a:questionnaire:4": "ocil:b:questionnaire:1",
+		"ocil:a:variable:1":      "ocil:b:variable:1",
+	}
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "ocil:a:questionnaire:4=ocil:b:questionnaire:1\n" +
+		"ocil:a:question:3=ocil:b:question:1\n" +
+		"ocil:a:question:9=ocil:b:question:2\n" +
+		"ocil:a:variable:1=ocil:b:variable:1\n"
+	if buf.String() != want {
+		t.Errorf("written:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	back, err := ReadIDMap(strings.NewReader("# comment\n\n" + buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, m) {
+		t.Errorf("read back %v, want %v", back, m)
+	}
+
+	for _, in := range []string{"no mapping\n", "=x\n", "x=\n", "a=b\na=c\n"} {
+		if _, err := ReadIDMap(strings.NewReader(in)); err == nil {
+			t.Errorf("ReadIDMap(%q) accepted", in)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package postal
+
+import (
+	"encoding/base64"
+	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteHTMLReport renders the results held by doc as a
+// self-contained HTML page: a summary of questionnaire results by
+// status followed by a section per questionnaire with its
+// questions, answers, instructions, references and artifact
+// evidence. Binary artifacts are embedded as data URIs so the page
+// needs nothing beyond the file itself.
+func WriteHTMLReport(w io.Writer, doc *OCILType) error {
+	x, err := NewIndex(doc)
+	if err != nil {
+		return err
+	}
+	return reportTemplate.Execute(w, newReport(x))
+}
+
+type report struct {
+	Doc            *OCILType
+	Title          string
+	Targets        []Target
+	Start, End     string
+	Summary        []reportCount
+	Questionnaires []reportQuestionnaire
+	Artifacts      []reportArtifact
+}
+
+type reportCount struct {
+	Result ResultType
+	Count  int
+}
+
+type reportQuestionnaire struct {
+	*QuestionnaireType
+	Result    ResultType
+	Questions []reportQuestion
+	Artifacts []reportArtifact
+}
+
+type reportQuestion struct {
+	ID           QuestionIDPattern
+	Text         string
+	Answer       string
+	Instructions InstructionsType
+	Notes        []string
+}
+
+type reportArtifact struct {
+	Ref       ArtifactIDPattern
+	Title     string
+	Submitter string
+	Timestamp string
+	MimeType  string
+	Text      string
+	Data      template.URL
+	Image     bool
+	Href      string
+}
+
+func newReport(x *Index) *report {
+	doc := x.Doc
+	res := &doc.Results
+	r := &report{
+		Doc:     doc,
+		Title:   doc.Document.Title,
+		Targets: res.Targets.All(),
+		Start:   formatTime(res.Start_time),
+		End:     formatTime(res.End_time),
+	}
+	if res.Title.Value != "" {
+		r.Title = res.Title.Value
+	}
+	results := make(map[QuestionnaireIDPattern]*QuestionnaireResultType)
+	for i := range res.Questionnaire_results.Questionnaire_result {
+		q := &res.Questionnaire_results.Questionnaire_result[i]
+		results[q.Questionnaire_ref] = q
+	}
+	answers := res.Answers()
+	counts := make(map[ResultType]int)
+	for i := range doc.Questionnaires.Questionnaire {
+		q := &doc.Questionnaires.Questionnaire[i]
+		rq := reportQuestionnaire{QuestionnaireType: q, Result: ResultNotTested}
+		if qr, ok := results[q.Id]; ok {
+			rq.Result = qr.Result
+			for _, a := range qr.Artifact_results.Artifact_result {
+				rq.Artifacts = append(rq.Artifacts, newReportArtifact(x, a))
+			}
+		}
+		if !q.Child_only {
+			counts[rq.Result]++
+		}
+		for _, id := range x.ReachableQuestions(TestActionRefValuePattern(q.Id)) {
+			question, ok := x.Questions[id]
+			if !ok {
+				continue
+			}
+			rq.Questions = append(rq.Questions, reportQuestion{
+				ID:           id,
+				Text:         QuestionText(question),
+				Answer:       x.FormatAnswer(question, answers[id]),
+				Instructions: question.Instruction(),
+				Notes:        questionNotes(question),
+			})
+		}
+		r.Questionnaires = append(r.Questionnaires, rq)
+	}
+	for _, a := range res.Artifact_results.Artifact_result {
+		r.Artifacts = append(r.Artifacts, newReportArtifact(x, a))
+	}
+	for _, res := range summaryResults {
+		r.Summary = append(r.Summary, reportCount{res, counts[res]})
+	}
+	return r
+}
+
+// mimeTypePattern matches a MIME type without parameters.
+var mimeTypePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9!#$&^_.+-]*/[a-z0-9][a-z0-9!#$&^_.+-]*$`)
+
+// inlineImageTypes are the types of the binary artifacts shown as
+// images in a report.
+var inlineImageTypes = map[string]bool{
+	"image/bmp":  true,
+	"image/gif":  true,
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+func newReportArtifact(x *Index, a ArtifactResultType) reportArtifact {
+	ra := reportArtifact{
+		Ref:       a.Artifact_ref,
+		Submitter: a.Submitter.Name,
+		Timestamp: formatTime(a.Timestamp),
+	}
+	for _, def := range x.Doc.Artifacts.Artifact {
+		if def.Id == a.Artifact_ref {
+			ra.Title = def.Title.Value
+		}
+	}
+	switch {
+	case a.Text_artifact_value != nil:
+		ra.MimeType = a.Text_artifact_value.Mime_type
+		ra.Text = a.Text_artifact_value.Data
+	case a.Binary_artifact_value != nil:
+		v := a.Binary_artifact_value
+		ra.MimeType = v.Mime_type
+		// The type comes from the results, so it enters the data
+		// URL only when well formed and shows inline only when an
+		// image type a browser renders without running scripts.
+		typ := strings.ToLower(v.Mime_type)
+		if !mimeTypePattern.MatchString(typ) {
+			typ = "application/octet-stream"
+		}
+		ra.Image = inlineImageTypes[typ]
+		ra.Data = template.URL("data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(v.Data))
+	case a.Reference_artifact_value != nil:
+		ra.Href = a.Reference_artifact_value.Reference.Href
+	}
+	return ra
+}
+
+// FormatAnswer renders an answer to q for display: Yes/No or
+// True/False according to the question model, the text of the
+// selected choice, or the number or string given. Exceptional
+// responses are shown as the response itself.
+func (x *Index) FormatAnswer(q Question, a Answer) string {
+	if a.Response == "" {
+		return ""
+	}
+	if a.Response != ResponseAnswered {
+		return string(a.Response)
+	}
+	switch q := q.(type) {
+	case *BooleanQuestionType:
+		if q.Model == ModelYesNo {
+			if a.Boolean {
+				return "Yes"
+			}
+			return "No"
+		}
+		if a.Boolean {
+			return "True"
+		}
+		return "False"
+	case *ChoiceQuestionType:
+		if c, ok := x.Choice(a.Choice); ok {
+			return c.Value
+		}
+		return string(a.Choice)
+	case *NumericQuestionType:
+		return strconv.FormatFloat(a.Numeric, 'f', -1, 64)
+	}
+	return a.String
+}
+
+func questionNotes(q Question) []string {
+	switch q := q.(type) {
+	case *BooleanQuestionType:
+		return q.Notes
+	case *ChoiceQuestionType:
+		return q.Notes
+	case *NumericQuestionType:
+		return q.Notes
+	case *StringQuestionType:
+		return q.Notes
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower": func(r ResultType) string { return strings.ToLower(string(r)) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; color: #222; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: .3em .6em; text-align: left; vertical-align: top; }
+section { border-top: 1px solid #ccc; margin-top: 2em; }
+pre { background: #f4f4f4; padding: .5em; overflow-x: auto; }
+img { max-width: 100%; }
+.result { font-weight: bold; padding: .1em .4em; border-radius: .2em; }
+.pass { background: #cfc; } .fail { background: #fcc; } .error { background: #fc9; }
+.unknown, .not_tested, .not_applicable { background: #ddd; }
+.muted { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Doc.Document}}{{range .Description}}<p>{{.}}</p>
+{{end}}{{range .Notice}}<p class="muted">{{.}}</p>
+{{end}}{{end}}
+{{- if .Targets}}<h2>Targets</h2>
+<ul>{{range .Targets}}<li>{{.TargetName}}</li>{{end}}</ul>
+{{end}}
+{{- if .Start}}<p class="muted">Assessed {{.Start}}{{if .End}} to {{.End}}{{end}}</p>
+{{end}}
+<h2>Summary</h2>
+<table>
+<tr>{{range .Summary}}<th><span class="result {{lower .Result}}">{{.Result}}</span></th>{{end}}</tr>
+<tr>{{range .Summary}}<td>{{.Count}}</td>{{end}}</tr>
+</table>
+<table>
+<tr><th>Questionnaire</th><th>Result</th></tr>
+{{range .Questionnaires}}{{if not .Child_only}}<tr><td><a href="#{{.Id}}">{{if .Title.Value}}{{.Title.Value}}{{else}}{{.Id}}{{end}}</a></td><td><span class="result {{lower .Result}}">{{.Result}}</span></td></tr>
+{{end}}{{end}}</table>
+{{range .Questionnaires}}
+<section id="{{.Id}}">
+<h2>{{if .Title.Value}}{{.Title.Value}}{{else}}{{.Id}}{{end}} <span class="result {{lower .Result}}">{{.Result}}</span></h2>
+<p class="muted">{{.Id}}{{if .Child_only}} (child only){{end}}</p>
+{{with .Description.Value}}<p>{{.}}</p>{{end}}
+{{range .Notes}}<p class="muted">Note: {{.}}</p>{{end}}
+{{with .References.Reference}}<h3>References</h3>
+<ul>{{range .}}<li>{{template "reference" .}}</li>{{end}}</ul>
+{{end}}
+{{- with .Questions}}<h3>Questions</h3>
+{{range .}}<div>
+<p><strong>{{.Text}}</strong><br><span class="muted">{{.ID}}</span></p>
+<p>Answer: {{if .Answer}}{{.Answer}}{{else}}<span class="muted">not answered</span>{{end}}</p>
+{{with .Instructions}}{{if .Step}}<details><summary>{{if .Title.Value}}{{.Title.Value}}{{else}}Instructions{{end}}</summary>
+{{template "steps" .Step}}</details>{{end}}{{end}}
+{{range .Notes}}<p class="muted">Note: {{.}}</p>{{end}}
+</div>
+{{end}}{{end}}
+{{- with .Artifacts}}<h3>Evidence</h3>
+{{template "artifacts" .}}{{end}}
+</section>
+{{end}}
+{{- with .Artifacts}}<section>
+<h2>Evidence</h2>
+{{template "artifacts" .}}</section>
+{{end}}
+</body>
+</html>
+{{define "reference"}}{{if .Href}}<a href="{{.Href}}">{{.Value}}</a>{{else}}{{.Value}}{{end}}{{end}}
+{{define "artifacts"}}{{range .}}<div>
+<p><strong>{{if .Title}}{{.Title}}{{else}}{{.Ref}}{{end}}</strong> <span class="muted">{{.Submitter}} {{.Timestamp}}</span></p>
+{{if .Text}}<pre>{{.Text}}</pre>{{end}}
+{{if .Data}}{{if .Image}}<img src="{{.Data}}" alt="{{.Ref}}">{{else}}<a href="{{.Data}}" download>Download ({{.MimeType}})</a>{{end}}{{end}}
+{{if .Href}}<a href="{{.Href}}">{{.Href}}</a>{{end}}
+</div>
+{{end}}{{end}}
+{{define "steps"}}<ol>{{range .}}<li>{{.Description.Value}}{{if .Is_required}} <em>(required)</em>{{end}}
+{{range .Reference}} [{{template "reference" .}}]{{end}}
+{{if .Step}}{{template "steps" .Step}}{{end}}</li>
+{{end}}</ol>{{end}}
+`))
@@ -0,0 +1,47 @@
+package postal
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	doc, err := ReadFile("testdata/answers-a.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "answers-a.html", buf.Bytes())
+}
+
+func TestReportArtifactType(t *testing.T) {
+	x := sampleIndex(t)
+	tests := []struct {
+		mime  string
+		data  template.URL
+		image bool
+	}{
+		{"image/png", "data:image/png;base64,AQI=", true},
+		{"IMAGE/JPEG", "data:image/jpeg;base64,AQI=", true},
+		{"image/svg+xml", "data:image/svg+xml;base64,AQI=", false},
+		{"application/pdf", "data:application/pdf;base64,AQI=", false},
+		{"", "data:application/octet-stream;base64,AQI=", false},
+		{"text/html,<script>alert(1)</script>", "data:application/octet-stream;base64,AQI=", false},
+		{"image/png;charset=x", "data:application/octet-stream;base64,AQI=", false},
+		{`image/png" onerror="x`, "data:application/octet-stream;base64,AQI=", false},
+	}
+	for _, tt := range tests {
+		a := ArtifactResultType{
+			Artifact_ref:          "ocil:org.example:artifact:1",
+			Binary_artifact_value: &BinaryArtifactValueType{Data: []byte{1, 2}, Mime_type: tt.mime},
+		}
+		ra := newReportArtifact(x, a)
+		if ra.Data != tt.data || ra.Image != tt.image {
+			t.Errorf("%q: data %q image %v, want %q %v", tt.mime, ra.Data, ra.Image, tt.data, tt.image)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package postal
+
+import (
+	"encoding/xml"
+	"strconv"
+)
+
+// The question result types are written by hand so that a false
+// or zero answer is kept and the answer to a question that was not
+// ANSWERED is nil, as the schema requires.
+
+func (t *BooleanQuestionResultType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeQuestionResult(e, start, t.Question_ref, t.Response, nil, strconv.FormatBool(t.Answer))
+}
+
+func (t *ChoiceQuestionResultType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	attr := []xml.Attr{{Name: xml.Name{Local: "choice_ref"}, Value: string(t.Answer.Choice_ref)}}
+	return encodeQuestionResult(e, start, t.Question_ref, t.Response, attr, "")
+}
+
+func (t *NumericQuestionResultType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeQuestionResult(e, start, t.Question_ref, t.Response, nil, strconv.FormatFloat(t.Answer, 'f', -1, 64))
+}
+
+func (t *StringQuestionResultType) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeQuestionResult(e, start, t.Question_ref, t.Response, nil, t.Answer)
+}
+
+func encodeQuestionResult(e *xml.Encoder, start xml.StartElement, ref QuestionIDPattern, resp UserResponseType, attr []xml.Attr, value string) error {
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "question_ref"}, Value: string(ref)})
+	if resp != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "response"}, Value: string(resp)})
+	}
+	answer := xml.StartElement{Name: xml.Name{Space: Namespace, Local: "answer"}}
+	if resp != "" && resp != ResponseAnswered {
+		answer.Attr = []xml.Attr{{Name: xml.Name{Space: xsiNamespace, Local: "nil"}, Value: "true"}}
+		value = ""
+	} else {
+		answer.Attr = attr
+	}
+	tokens := []xml.Token{start, answer}
+	if value != "" {
+		tokens = append(tokens, xml.CharData(value))
+	}
+	tokens = append(tokens, answer.End(), start.End())
+	for _, t := range tokens {
+		if err := e.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithResults returns a shallow copy of doc carrying r as its
+// results.
+func WithResults(doc *OCILType, r *ResultsType) *OCILType {
+	out := *doc
+	out.Results = *r
+	return &out
+}
@@ -0,0 +1,142 @@
+package postal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A CSVTable names one of the flat tables WriteResultsCSV writes.
+type CSVTable string
+
+// Tables written by WriteResultsCSV.
+const (
+	CSVQuestionnaires CSVTable = "questionnaires"
+	CSVQuestions      CSVTable = "questions"
+	CSVArtifacts      CSVTable = "artifacts"
+)
+
+// CSVTables lists the tables in the order ocil3 export writes them.
+var CSVTables = []CSVTable{CSVQuestionnaires, CSVQuestions, CSVArtifacts}
+
+// csvColumns are the header rows of the tables. Columns are only
+// ever added at the end, so that consumers may address them by
+// position.
+var csvColumns = map[CSVTable][]string{
+	CSVQuestionnaires: {"file", "target", "questionnaire_id", "title", "result", "start_time", "end_time"},
+	CSVQuestions:      {"file", "target", "question_id", "question_text", "answer", "response", "submitter"},
+	CSVArtifacts:      {"file", "target", "artifact_ref", "title", "mime_type", "size", "href", "provider", "submitter", "timestamp"},
+}
+
+// Columns returns the header row of the table.
+func (t CSVTable) Columns() []string {
+	return csvColumns[t]
+}
+
+// WriteResultsCSV writes one table of the results held by docs as
+// CSV with a header row. files names the file each document was
+// read from and fills the file column. The target column joins the
+// names of the targets of each document with "; ". Times are in
+// RFC 3339 format.
+//
+// The questions table holds a row per answered question, in the
+// order of the document's questions by type. Answers are written
+// as for display: Yes/No or True/False, the choice text, or the
+// number or string given; for an exceptional response the answer
+// is empty. OCIL records no
+// submitter for answers, so the submitter column names the user
+// targets of the results.
+//
+// The size of an artifact is the length in bytes of its text or
+// binary value; references to external artifacts have an href
+// instead.
+func WriteResultsCSV(w io.Writer, table CSVTable, files []string, docs []*OCILType) error {
+	cols := table.Columns()
+	if cols == nil {
+		return fmt.Errorf("unknown table %q", table)
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(cols)
+	for i, doc := range docs {
+		x, err := NewIndex(doc)
+		if err != nil {
+			return fmt.Errorf("%s: %v", files[i], err)
+		}
+		for _, row := range csvRows(table, files[i], x) {
+			cw.Write(row)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvRows(table CSVTable, file string, x *Index) [][]string {
+	res := &x.Doc.Results
+	var targets, users []string
+	for _, t := range res.Targets.All() {
+		targets = append(targets, t.TargetName())
+		if _, ok := t.(*UserType); ok {
+			users = append(users, t.TargetName())
+		}
+	}
+	target := strings.Join(targets, "; ")
+
+	var rows [][]string
+	switch table {
+	case CSVQuestionnaires:
+		for _, r := range res.Questionnaire_results.Questionnaire_result {
+			var title string
+			if q, ok := x.Questionnaires[r.Questionnaire_ref]; ok {
+				title = q.Title.Value
+			}
+			rows = append(rows, []string{file, target, string(r.Questionnaire_ref), title, string(r.Result),
+				csvTime(res.Start_time), csvTime(res.End_time)})
+		}
+	case CSVQuestions:
+		answers := res.Answers()
+		for _, q := range x.Doc.Questions.All() {
+			a, ok := answers[q.QuestionID()]
+			if !ok {
+				continue
+			}
+			var answer string
+			if a.Response == ResponseAnswered {
+				answer = x.FormatAnswer(q, a)
+			}
+			rows = append(rows, []string{file, target, string(q.QuestionID()), x.QuestionText(q, nil), answer,
+				string(a.Response), strings.Join(users, "; ")})
+		}
+	case CSVArtifacts:
+		for _, a := range res.Artifact_results.Artifact_result {
+			var title, mime, size, href string
+			for _, def := range x.Doc.Artifacts.Artifact {
+				if def.Id == a.Artifact_ref {
+					title = def.Title.Value
+				}
+			}
+			switch {
+			case a.Text_artifact_value != nil:
+				mime = a.Text_artifact_value.Mime_type
+				size = strconv.Itoa(len(a.Text_artifact_value.Data))
+			case a.Binary_artifact_value != nil:
+				mime = a.Binary_artifact_value.Mime_type
+				size = strconv.Itoa(len(a.Binary_artifact_value.Data))
+			case a.Reference_artifact_value != nil:
+				href = a.Reference_artifact_value.Reference.Href
+			}
+			rows = append(rows, []string{file, target, string(a.Artifact_ref), title, mime, size, href,
+				string(a.Provider), a.Submitter.Name, csvTime(a.Timestamp)})
+		}
+	}
+	return rows
+}
+
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,58 @@
+package postal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteResultsCSV(t *testing.T) {
+	data, err := os.ReadFile("testdata/answers-a.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		text   string
+		target string
+		// wantText and wantTarget are read back: QuestionText joins
+		// the lines of question text, and XML and encoding/csv both
+		// read \r\n as \n.
+		wantText, wantTarget string
+	}{
+		{"plain", "Is a password policy configured?", "web1", "Is a password policy configured?", "web1"},
+		{"commas", "Is a policy, such as a GPO, configured?", "web1, rack 2", "Is a policy, such as a GPO, configured?", "web1, rack 2"},
+		{"quotes", `Is "Password must meet complexity requirements" enabled?`, `web1 "db"`, `Is "Password must meet complexity requirements" enabled?`, `web1 "db"`},
+		{"line breaks", "Run:\n    net accounts\nIs a policy configured?", "web1\r\nrack 2\n", "Run: net accounts Is a policy configured?", "web1\nrack 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := strings.Replace(string(data), "Is a password policy configured?", tt.text, 1)
+			in = strings.Replace(in, "<name>web1</name>", "<name>"+tt.target+"</name>", 1)
+			doc, err := ReadDocument(strings.NewReader(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := WriteResultsCSV(&buf, CSVQuestions, []string{"a,b.xml"}, []*OCILType{doc}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := [][]string{
+				CSVQuestions.Columns(),
+				{"a,b.xml", tt.wantTarget, "ocil:org.example:question:1", tt.wantText, "Yes", "ANSWERED", ""},
+				{"a,b.xml", tt.wantTarget, "ocil:org.example:question:2", "What is the minimum password length? Policy requires ${ocil:org.example:variable:1}.", "12", "ANSWERED", ""},
+				{"a,b.xml", tt.wantTarget, "ocil:org.example:question:3", "How are passwords stored?", "Hashed", "ANSWERED", ""},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("read back\n%q\nwant\n%q", got, want)
+			}
+		})
+	}
+}
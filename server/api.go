@@ -0,0 +1,596 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// The JSON API lives below /api; assets/openapi.json describes it.
+func (srv *Server) routeAPI() {
+	srv.mux.HandleFunc("GET /api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := assets.ReadFile("assets/openapi.json")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+	srv.mux.HandleFunc("GET /api/documents", srv.apiDocuments)
+	srv.mux.HandleFunc("POST /api/documents", srv.apiAddDocument)
+	srv.mux.HandleFunc("GET /api/documents/{id}", srv.apiDocument)
+	srv.mux.HandleFunc("GET /api/sessions", srv.apiSessions)
+	srv.mux.HandleFunc("POST /api/sessions", srv.apiNewSession)
+	srv.mux.HandleFunc("GET /api/sessions/{id}", srv.apiSession)
+	srv.mux.HandleFunc("GET /api/sessions/{id}/next", srv.apiNext)
+	srv.mux.HandleFunc("GET /api/sessions/{id}/answers", srv.apiAnswers)
+	srv.mux.HandleFunc("POST /api/sessions/{id}/answers", srv.apiAnswer)
+	srv.mux.HandleFunc("POST /api/sessions/{id}/artifacts", srv.apiArtifact)
+	srv.mux.HandleFunc("GET /api/sessions/{id}/results", srv.apiResults)
+	srv.mux.HandleFunc("GET /api/sessions/{id}/results.xml", srv.apiResultsXML)
+}
+
+type apiDocumentInfo struct {
+	ID             string                 `json:"id"`
+	Title          string                 `json:"title"`
+	Revision       int                    `json:"revision"`
+	Uploaded       time.Time              `json:"uploaded"`
+	Questionnaires []apiQuestionnaireInfo `json:"questionnaires"`
+}
+
+type apiQuestionnaireInfo struct {
+	ID    ocil.QuestionnaireIDPattern `json:"id"`
+	Title string                      `json:"title,omitempty"`
+}
+
+type apiTarget struct {
+	// Kind is system or user.
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type apiSessionRequest struct {
+	Document  string         `json:"document"`
+	Target    apiTarget      `json:"target"`
+	Assessor  string         `json:"assessor,omitempty"`
+	Variables ocil.Variables `json:"variables,omitempty"`
+}
+
+type apiSessionInfo struct {
+	ID        string         `json:"id"`
+	Document  string         `json:"document"`
+	Target    apiTarget      `json:"target"`
+	Assessor  string         `json:"assessor,omitempty"`
+	Variables ocil.Variables `json:"variables,omitempty"`
+	Started   time.Time      `json:"started"`
+	Updated   time.Time      `json:"updated"`
+	Answered  int            `json:"answered"`
+	Asked     int            `json:"asked"`
+	Complete  bool           `json:"complete"`
+}
+
+type apiQuestion struct {
+	ID            ocil.QuestionIDPattern      `json:"id"`
+	Questionnaire ocil.QuestionnaireIDPattern `json:"questionnaire"`
+	// Kind is boolean, choice, numeric or string.
+	Kind         string           `json:"kind"`
+	Text         string           `json:"text"`
+	Choices      []apiChoice      `json:"choices,omitempty"`
+	Instructions *apiInstructions `json:"instructions,omitempty"`
+	Notes        []string         `json:"notes,omitempty"`
+}
+
+type apiChoice struct {
+	// Value is the value to answer with: true or false for a
+	// boolean question, the choice ID for a choice question.
+	Value interface{} `json:"value"`
+	Text  string      `json:"text"`
+}
+
+type apiInstructions struct {
+	Title string    `json:"title,omitempty"`
+	Steps []apiStep `json:"steps"`
+}
+
+type apiStep struct {
+	Description string         `json:"description"`
+	References  []apiReference `json:"references,omitempty"`
+	Required    bool           `json:"required"`
+	Done        bool           `json:"done"`
+	Steps       []apiStep      `json:"steps,omitempty"`
+}
+
+type apiReference struct {
+	Text string `json:"text"`
+	Href string `json:"href,omitempty"`
+}
+
+type apiAnswer struct {
+	Question ocil.QuestionIDPattern `json:"question"`
+	// Response defaults to ANSWERED, which requires a value.
+	Response ocil.UserResponseType `json:"response,omitempty"`
+	Value    json.RawMessage       `json:"value,omitempty"`
+	// StepsDone, when given, marks the steps of the instructions
+	// done, numbering nested steps depth first.
+	StepsDone []bool `json:"steps_done,omitempty"`
+	// Submitter defaults to the assessor of the session.
+	Submitter string `json:"submitter,omitempty"`
+}
+
+// apiAnswerRecord is an answer in the history of a session. A
+// record without a response withdraws the earlier answers.
+type apiAnswerRecord struct {
+	Question  ocil.QuestionIDPattern `json:"question"`
+	Response  ocil.UserResponseType  `json:"response,omitempty"`
+	Value     interface{}            `json:"value,omitempty"`
+	Submitter string                 `json:"submitter,omitempty"`
+	Time      time.Time              `json:"time"`
+}
+
+type apiArtifact struct {
+	Questionnaire ocil.QuestionnaireIDPattern `json:"questionnaire"`
+	Artifact      ocil.ArtifactIDPattern      `json:"artifact"`
+	// One of Data, in base64, Href and Text, tried in that order,
+	// gives the value.
+	Text     string `json:"text,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Href     string `json:"href,omitempty"`
+}
+
+type apiResultsInfo struct {
+	Session        string                   `json:"session"`
+	Complete       bool                     `json:"complete"`
+	Questionnaires []apiQuestionnaireResult `json:"questionnaires"`
+}
+
+type apiQuestionnaireResult struct {
+	ID        ocil.QuestionnaireIDPattern `json:"id"`
+	Title     string                      `json:"title,omitempty"`
+	Result    ocil.ResultType             `json:"result"`
+	Artifacts int                         `json:"artifacts"`
+}
+
+// An apiStatusError is an error with the HTTP status to reply with.
+type apiStatusError struct {
+	status int
+	err    error
+}
+
+func (e *apiStatusError) Error() string { return e.err.Error() }
+
+func badRequest(format string, args ...interface{}) error {
+	return &apiStatusError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &apiStatusError{http.StatusNotFound, fmt.Errorf(format, args...)}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError replies with err as {"error": message}, with the
+// status of an apiStatusError or 500.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var se *apiStatusError
+	if errors.As(err, &se) {
+		status = se.status
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxUpload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// apiLookup returns the session named by the request path.
+func (srv *Server) apiLookup(r *http.Request) (*Session, error) {
+	s, ok := srv.sessions[r.PathValue("id")]
+	if !ok {
+		return nil, notFound("unknown session %q", r.PathValue("id"))
+	}
+	return s, nil
+}
+
+func (srv *Server) documentInfo(d *document) apiDocumentInfo {
+	info := apiDocumentInfo{ID: d.ID, Title: d.Title(), Revision: d.Revision, Uploaded: d.Uploaded, Questionnaires: []apiQuestionnaireInfo{}}
+	for _, q := range d.x.Doc.Questionnaires.Questionnaire {
+		if !q.Child_only {
+			info.Questionnaires = append(info.Questionnaires, apiQuestionnaireInfo{q.Id, q.Title.Value})
+		}
+	}
+	return info
+}
+
+func (srv *Server) sessionInfo(s *Session) (apiSessionInfo, error) {
+	ev, err := srv.evaluate(s)
+	if err != nil {
+		return apiSessionInfo{}, err
+	}
+	info := apiSessionInfo{
+		ID:        s.ID,
+		Document:  s.Document,
+		Assessor:  s.Assessor.Name,
+		Variables: s.Variables,
+		Started:   s.Started,
+		Updated:   s.Updated,
+		Asked:     len(ev.asked),
+	}
+	if len(s.Targets.User) > 0 {
+		info.Target = apiTarget{"user", s.Targets.User[0].Name}
+	} else if len(s.Targets.System) > 0 {
+		info.Target = apiTarget{"system", s.Targets.System[0].Name}
+	}
+	for _, id := range ev.asked {
+		if _, ok := s.Answers[id]; ok {
+			info.Answered++
+		}
+	}
+	_, pending := ev.next(s)
+	info.Complete = !pending
+	return info, nil
+}
+
+func (srv *Server) apiDocuments(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	infos := []apiDocumentInfo{}
+	for _, d := range srv.sortedDocuments() {
+		infos = append(infos, srv.documentInfo(d))
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// apiAddDocument reads an OCIL document from the request body.
+func (srv *Server) apiAddDocument(w http.ResponseWriter, r *http.Request) {
+	doc, err := ocil.ReadDocument(io.LimitReader(r.Body, maxUpload))
+	if err != nil {
+		writeError(w, badRequest("%v", err))
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	d, err := srv.addDocument(doc)
+	if err != nil {
+		writeError(w, badRequest("%v", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, srv.documentInfo(d))
+}
+
+func (srv *Server) apiDocument(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	d, ok := srv.documents[r.PathValue("id")]
+	if !ok {
+		writeError(w, notFound("unknown document %q", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, srv.documentInfo(d))
+}
+
+func (srv *Server) apiSessions(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	infos := []apiSessionInfo{}
+	for _, s := range srv.sortedSessions() {
+		info, err := srv.sessionInfo(s)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		infos = append(infos, info)
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (srv *Server) apiNewSession(w http.ResponseWriter, r *http.Request) {
+	var req apiSessionRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	var target ocil.Target
+	switch req.Target.Kind {
+	case "", "system":
+		target = ocil.NewSystemTarget(req.Target.Name)
+	case "user":
+		target = ocil.NewUserTarget(req.Target.Name)
+	default:
+		writeError(w, badRequest("target kind %q is neither system nor user", req.Target.Kind))
+		return
+	}
+	if req.Target.Name == "" {
+		writeError(w, badRequest("missing target name"))
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	d, ok := srv.documents[req.Document]
+	if !ok {
+		writeError(w, badRequest("unknown document %q", req.Document))
+		return
+	}
+	if err := d.x.CheckExternals(req.Variables); err != nil {
+		writeError(w, badRequest("%v", err))
+		return
+	}
+	s := NewSession(d.ID, target, req.Assessor)
+	s.Variables = req.Variables
+	if err := srv.saveSession(s); err != nil {
+		writeError(w, err)
+		return
+	}
+	srv.sessions[s.ID] = s
+	srv.replySession(w, http.StatusCreated, s)
+}
+
+func (srv *Server) replySession(w http.ResponseWriter, status int, s *Session) {
+	info, err := srv.sessionInfo(s)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, status, info)
+}
+
+func (srv *Server) apiSession(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, err := srv.apiLookup(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	srv.replySession(w, http.StatusOK, s)
+}
+
+// apiNext replies with the first question the evaluation needs
+// that has no answer yet, or with 204 No Content when every
+// question asked is answered.
+func (srv *Server) apiNext(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, err := srv.apiLookup(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ev, err := srv.evaluate(s)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	id, ok := ev.next(s)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	pq := srv.newPageQuestion(s, srv.index(s).Questions[id])
+	q := apiQuestion{
+		ID:            id,
+		Questionnaire: ev.askedBy[id],
+		Kind:          pq.Kind,
+		Text:          pq.Text,
+		Notes:         pq.Notes,
+	}
+	for _, c := range pq.Choices {
+		ac := apiChoice{Value: c.Value, Text: c.Text}
+		if pq.Kind == "boolean" {
+			ac.Value = c.Value == "true"
+		}
+		q.Choices = append(q.Choices, ac)
+	}
+	if len(pq.Steps) > 0 {
+		q.Instructions = &apiInstructions{Title: pq.Title, Steps: apiSteps(pq.Steps)}
+	}
+	writeJSON(w, http.StatusOK, q)
+}
+
+func apiSteps(steps []*pageStep) []apiStep {
+	var out []apiStep
+	for _, st := range steps {
+		as := apiStep{
+			Description: st.Description,
+			Required:    st.Required,
+			Done:        st.Done,
+			Steps:       apiSteps(st.Steps),
+		}
+		for _, r := range st.References {
+			as.References = append(as.References, apiReference{r.Value, r.Href})
+		}
+		out = append(out, as)
+	}
+	return out
+}
+
+// apiAnswer records an answer, or an exceptional response, and the
+// steps done, and replies with the updated session.
+func (srv *Server) apiAnswer(w http.ResponseWriter, r *http.Request) {
+	var req apiAnswer
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, err := srv.apiLookup(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	x := srv.index(s)
+	q, ok := x.Questions[req.Question]
+	if !ok {
+		writeError(w, badRequest("unknown question %q", req.Question))
+		return
+	}
+	var a ocil.Answer
+	switch req.Response {
+	case "", ocil.ResponseAnswered:
+		var value string
+		if err := json.Unmarshal(req.Value, &value); err != nil {
+			// A boolean or a number is given as is.
+			value = string(req.Value)
+		}
+		if a, err = parseAnswer(x, q, value); err != nil {
+			writeError(w, badRequest("%v", err))
+			return
+		}
+	case ocil.ResponseUnknown, ocil.ResponseNotApplicable, ocil.ResponseNotTested, ocil.ResponseError:
+		a = ocil.Answer{Response: req.Response}
+	default:
+		writeError(w, badRequest("unknown response %q", req.Response))
+		return
+	}
+	if req.StepsDone != nil {
+		if n := len(s.stepsDone(q)); len(req.StepsDone) != n {
+			writeError(w, badRequest("question %s has %d steps, not %d", req.Question, n, len(req.StepsDone)))
+			return
+		}
+	}
+	submitter := s.Assessor
+	if req.Submitter != "" {
+		submitter = ocil.UserType{Name: req.Submitter}
+	}
+	// The steps are recorded only with the answer, once both are
+	// known to be valid.
+	if err := srv.setAnswer(s, req.Question, a, submitter); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.StepsDone != nil {
+		s.Steps[req.Question] = req.StepsDone
+	}
+	s.Updated = time.Now()
+	if err := srv.saveSession(s); err != nil {
+		writeError(w, err)
+		return
+	}
+	srv.replySession(w, http.StatusOK, s)
+}
+
+// apiAnswers replies with every answer given in a session, in the
+// order given.
+func (srv *Server) apiAnswers(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, err := srv.apiLookup(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	answers, err := srv.st.Answers(s.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	x := srv.index(s)
+	recs := []apiAnswerRecord{}
+	for _, a := range answers {
+		rec := apiAnswerRecord{
+			Question:  a.Question,
+			Response:  a.Answer.Response,
+			Submitter: a.Submitter.Name,
+			Time:      a.Time,
+		}
+		if a.Answer.Response == ocil.ResponseAnswered {
+			switch x.Questions[a.Question].(type) {
+			case *ocil.BooleanQuestionType:
+				rec.Value = a.Answer.Boolean
+			case *ocil.ChoiceQuestionType:
+				rec.Value = a.Answer.Choice
+			case *ocil.NumericQuestionType:
+				rec.Value = a.Answer.Numeric
+			default:
+				rec.Value = a.Answer.String
+			}
+		}
+		recs = append(recs, rec)
+	}
+	writeJSON(w, http.StatusOK, recs)
+}
+
+func (srv *Server) apiArtifact(w http.ResponseWriter, r *http.Request) {
+	var req apiArtifact
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, err := srv.apiLookup(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	a := ocil.ArtifactResultType{Artifact_ref: req.Artifact}
+	switch {
+	case req.Data != nil:
+		a.Binary_artifact_value = &ocil.BinaryArtifactValueType{Data: req.Data, Mime_type: mimeType(req.MimeType, req.Data)}
+	case req.Href != "":
+		a.Reference_artifact_value = &ocil.ReferenceArtifactValueType{Reference: ocil.Reference{Href: req.Href}}
+	case req.Text != "":
+		mt := req.MimeType
+		if mt == "" {
+			mt = "text/plain"
+		}
+		a.Text_artifact_value = &ocil.TextArtifactValueType{Data: req.Text, Mime_type: mt}
+	default:
+		writeError(w, badRequest("missing artifact text, data or href"))
+		return
+	}
+	if err := srv.addArtifact(s, req.Questionnaire, a); err != nil {
+		writeError(w, badRequest("%v", err))
+		return
+	}
+	srv.replySession(w, http.StatusCreated, s)
+}
+
+func (srv *Server) apiResults(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, err := srv.apiLookup(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ev, err := srv.evaluate(s)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_, pending := ev.next(s)
+	info := apiResultsInfo{Session: s.ID, Complete: !pending, Questionnaires: []apiQuestionnaireResult{}}
+	for _, q := range srv.index(s).Doc.Questionnaires.Questionnaire {
+		if res, ok := ev.result[q.Id]; ok && !q.Child_only {
+			info.Questionnaires = append(info.Questionnaires, apiQuestionnaireResult{q.Id, q.Title.Value, res, len(s.Artifacts[q.Id])})
+		}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (srv *Server) apiResultsXML(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, err := srv.apiLookup(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	srv.writeResults(w, s)
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	ocil "github.com/redhatrises/goscap"
+	"github.com/redhatrises/goscap/store"
+)
+
+// newTestSession returns a server holding testdata/sample.xml and a
+// session started on it through the API.
+func newTestSession(t *testing.T) (*Server, *store.Memory, *Session) {
+	t.Helper()
+	st := store.NewMemory()
+	srv, err := New(st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := ocil.ReadFile("../testdata/sample.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docID, err := srv.AddDocument(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := serve(t, srv, "POST", "/api/sessions", apiSessionRequest{
+		Document:  docID,
+		Target:    apiTarget{Kind: "system", Name: "web1"},
+		Variables: ocil.Variables{"ocil:org.example:variable:1": "8"},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("new session: %d %s", rec.Code, rec.Body)
+	}
+	var info apiSessionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	return srv, st, srv.sessions[info.ID]
+}
+
+// serve sends a request with body v, as JSON unless nil, to srv.
+func serve(t *testing.T, srv *Server, method, path string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	if v != nil {
+		if err := json.NewEncoder(&body).Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(method, path, &body))
+	return rec
+}
+
+func TestAPIAnswer(t *testing.T) {
+	srv, st, s := newTestSession(t)
+	path := "/api/sessions/" + s.ID
+	steps := []struct {
+		name     string
+		method   string
+		path     string
+		answer   *apiAnswer
+		code     int
+		steps    []bool
+		complete bool
+	}{
+		{
+			name:   "invalid answer with steps",
+			method: "POST",
+			path:   path + "/answers",
+			answer: &apiAnswer{Question: "ocil:org.example:question:1", Value: json.RawMessage(`"maybe"`), StepsDone: []bool{true, true}},
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "wrong step count",
+			method: "POST",
+			path:   path + "/answers",
+			answer: &apiAnswer{Question: "ocil:org.example:question:1", Value: json.RawMessage(`true`), StepsDone: []bool{true}},
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "valid answer with steps",
+			method: "POST",
+			path:   path + "/answers",
+			answer: &apiAnswer{Question: "ocil:org.example:question:1", Value: json.RawMessage(`true`), StepsDone: []bool{true, false}},
+			code:   http.StatusOK,
+			steps:  []bool{true, false},
+		},
+		{
+			name:   "download before completion",
+			method: "GET",
+			path:   path + "/results.xml",
+			code:   http.StatusOK,
+			steps:  []bool{true, false},
+		},
+		{
+			name:   "numeric answer",
+			method: "POST",
+			path:   path + "/answers",
+			answer: &apiAnswer{Question: "ocil:org.example:question:2", Value: json.RawMessage(`10`)},
+			code:   http.StatusOK,
+			steps:  []bool{true, false},
+		},
+		{
+			name:     "final answer",
+			method:   "POST",
+			path:     path + "/answers",
+			answer:   &apiAnswer{Question: "ocil:org.example:question:3", Value: json.RawMessage(`"ocil:org.example:choice:1"`)},
+			code:     http.StatusOK,
+			steps:    []bool{true, false},
+			complete: true,
+		},
+	}
+	for _, step := range steps {
+		var body interface{}
+		if step.answer != nil {
+			body = step.answer
+		}
+		rec := serve(t, srv, step.method, step.path, body)
+		if rec.Code != step.code {
+			t.Fatalf("%s: status %d, want %d: %s", step.name, rec.Code, step.code, rec.Body)
+		}
+		if got := s.Steps["ocil:org.example:question:1"]; !reflect.DeepEqual(got, step.steps) {
+			t.Errorf("%s: steps %v, want %v", step.name, got, step.steps)
+		}
+		res, err := st.Results(s.ID)
+		switch {
+		case step.complete && err != nil:
+			t.Errorf("%s: no results stored: %v", step.name, err)
+		case step.complete && !bytes.Contains(res.Data, []byte("<result>PASS</result>")):
+			t.Errorf("%s: stored results do not pass:\n%s", step.name, res.Data)
+		case !step.complete && !errors.Is(err, store.ErrNotFound):
+			t.Errorf("%s: results stored before completion (%v)", step.name, err)
+		}
+	}
+}
+
+func TestReadForm(t *testing.T) {
+	srv, _, s := newTestSession(t)
+	form := map[string][]string{
+		"r:ocil:org.example:question:1":   {"ANSWERED"},
+		"a:ocil:org.example:question:1":   {"true"},
+		"s:ocil:org.example:question:1:0": {"on"},
+		"r:ocil:org.example:question:2":   {"ANSWERED"},
+		"a:ocil:org.example:question:2":   {"ten"},
+	}
+	if err := srv.readForm(s, form); err == nil {
+		t.Fatal("invalid form accepted")
+	}
+	if len(s.Answers) != 0 || len(s.Steps) != 0 {
+		t.Errorf("invalid form recorded answers %v and steps %v", s.Answers, s.Steps)
+	}
+	form["a:ocil:org.example:question:2"] = []string{"10"}
+	if err := srv.readForm(s, form); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Answers) != 2 {
+		t.Errorf("answers %v, want 2", s.Answers)
+	}
+	if got, want := s.Steps["ocil:org.example:question:1"], []bool{true, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("steps %v, want %v", got, want)
+	}
+}
+
+func TestAPIFlow(t *testing.T) {
+	st := store.NewMemory()
+	srv, err := New(st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("../testdata/sample.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("POST", "/api/documents", bytes.NewReader(data)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: %d %s", rec.Code, rec.Body)
+	}
+	var doc apiDocumentInfo
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	rec = serve(t, srv, "POST", "/api/sessions", apiSessionRequest{
+		Document:  doc.ID,
+		Target:    apiTarget{Kind: "user", Name: "alice"},
+		Assessor:  "bob",
+		Variables: ocil.Variables{"ocil:org.example:variable:1": "8"},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("new session: %d %s", rec.Code, rec.Body)
+	}
+	var info apiSessionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/sessions/" + info.ID
+
+	// Answer each question the evaluation asks for until none is
+	// left, in the order it asks them.
+	values := map[ocil.QuestionIDPattern]string{
+		"ocil:org.example:question:1": `true`,
+		"ocil:org.example:question:2": `12`,
+		"ocil:org.example:question:3": `"ocil:org.example:choice:3"`,
+		"ocil:org.example:question:4": `"root"`,
+		"ocil:org.example:question:5": `false`,
+	}
+	var asked []ocil.QuestionIDPattern
+	for {
+		rec := serve(t, srv, "GET", path+"/next", nil)
+		if rec.Code == http.StatusNoContent {
+			break
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("next: %d %s", rec.Code, rec.Body)
+		}
+		var q apiQuestion
+		if err := json.NewDecoder(rec.Body).Decode(&q); err != nil {
+			t.Fatal(err)
+		}
+		if len(asked) > len(values) {
+			t.Fatalf("asked %v and %s again", asked, q.ID)
+		}
+		asked = append(asked, q.ID)
+		rec = serve(t, srv, "POST", path+"/answers", apiAnswer{Question: q.ID, Value: json.RawMessage(values[q.ID])})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("answer %s: %d %s", q.ID, rec.Code, rec.Body)
+		}
+	}
+	want := []ocil.QuestionIDPattern{
+		"ocil:org.example:question:1", "ocil:org.example:question:2", "ocil:org.example:question:3",
+		"ocil:org.example:question:4", "ocil:org.example:question:5",
+	}
+	if !reflect.DeepEqual(asked, want) {
+		t.Errorf("asked %v, want %v", asked, want)
+	}
+
+	rec = serve(t, srv, "POST", path+"/artifacts", apiArtifact{
+		Questionnaire: "ocil:org.example:questionnaire:1",
+		Artifact:      "ocil:org.example:artifact:1",
+		Text:          "policy export",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("artifact: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(t, srv, "GET", path+"/results", nil)
+	var results apiResultsInfo
+	if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	wantResults := apiResultsInfo{Session: info.ID, Complete: true, Questionnaires: []apiQuestionnaireResult{
+		{"ocil:org.example:questionnaire:1", "Password policy", ocil.ResultPass, 1},
+	}}
+	if !reflect.DeepEqual(results, wantResults) {
+		t.Errorf("results %+v, want %+v", results, wantResults)
+	}
+
+	rec = serve(t, srv, "GET", path+"/answers", nil)
+	var history []apiAnswerRecord
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != len(want) || history[0].Submitter != "bob" {
+		t.Errorf("answer history %+v", history)
+	}
+
+	// A new server on the same store resumes the session.
+	srv2, err := New(st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = serve(t, srv2, "GET", path+"/results", nil)
+	var again apiResultsInfo
+	if err := json.NewDecoder(rec.Body).Decode(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, wantResults) {
+		t.Errorf("results after reloading %+v, want %+v", again, wantResults)
+	}
+	if rec := serve(t, srv2, "GET", "/api/sessions/missing", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("missing session: %d", rec.Code)
+	}
+}
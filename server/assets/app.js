@@ -0,0 +1,75 @@
+// Posts the answers form on every change and updates the results,
+// open items and visible questions from the reply.
+(function () {
+  "use strict";
+  var form = document.getElementById("answers");
+  if (!form) {
+    return;
+  }
+  var timer = null;
+
+  function update(st) {
+    document.querySelectorAll("[data-result]").forEach(function (el) {
+      var r = st.results[el.dataset.result] || "";
+      el.textContent = r;
+      el.className = "result " + r.toLowerCase();
+    });
+    document.querySelectorAll("[data-open]").forEach(function (el) {
+      var n = st.open[el.dataset.open];
+      el.textContent = n ? n : "";
+    });
+    var asked = {};
+    st.asked.forEach(function (id) { asked[id] = true; });
+    document.querySelectorAll("[data-question]").forEach(function (el) {
+      el.hidden = !asked[el.dataset.question];
+    });
+    document.getElementById("progress").textContent = st.answered + " of " + st.total;
+  }
+
+  function save() {
+    timer = null;
+    document.body.classList.add("saving");
+    fetch(form.action, {
+      method: "POST",
+      headers: { "Accept": "application/json" },
+      body: new URLSearchParams(new FormData(form))
+    }).then(function (resp) {
+      if (!resp.ok) {
+        return resp.text().then(function (msg) { throw new Error(msg); });
+      }
+      return resp.json();
+    }).then(function (st) {
+      document.body.classList.remove("saving", "failed");
+      update(st);
+    }).catch(function (err) {
+      document.body.classList.remove("saving");
+      document.body.classList.add("failed");
+      console.error(err);
+    });
+  }
+
+  function changed(ev) {
+    var el = ev.target;
+    if (!el.form || el.form !== form) {
+      return;
+    }
+    // Picking an answer means the question is answered.
+    if (el.name.indexOf("a:") === 0 && el.value !== "") {
+      var resp = document.querySelector("select[name='r:" + el.name.slice(2) + "']");
+      if (resp) {
+        resp.value = "ANSWERED";
+      }
+    }
+    if (timer) {
+      clearTimeout(timer);
+    }
+    timer = setTimeout(save, ev.type === "input" ? 400 : 0);
+  }
+
+  document.addEventListener("change", changed);
+  document.addEventListener("input", function (ev) {
+    if (ev.target.type === "text" || ev.target.type === "number") {
+      changed(ev);
+    }
+  });
+})();
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+)
+
+// tokenCookie names the cookie holding the form token of a browser
+// session.
+const tokenCookie = "ocil_token"
+
+// formToken returns the form token of the browser session of r,
+// starting a session if r has none. The forms of the pages carry
+// the token and checkForm compares it with the cookie, which a page
+// of another site can neither read nor set.
+func formToken(w http.ResponseWriter, r *http.Request) string {
+	if c, err := r.Cookie(tokenCookie); err == nil && c.Value != "" {
+		return c.Value
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	token := hex.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token
+}
+
+// checkForm returns h refusing a form whose token is not that of
+// the browser session.
+func checkForm(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie(tokenCookie)
+		if err != nil || c.Value == "" ||
+			subtle.ConstantTimeCompare([]byte(c.Value), []byte(r.PostFormValue("token"))) != 1 {
+			http.Error(w, "invalid form token; reload the page and try again", http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// sameOrigin reports whether r comes from a page of the server, or
+// from a client that is not a browser and sends neither Origin nor
+// Referer.
+func sameOrigin(r *http.Request) bool {
+	from := r.Header.Get("Origin")
+	if from == "" {
+		from = r.Header.Get("Referer")
+	}
+	if from == "" {
+		return true
+	}
+	u, err := url.Parse(from)
+	return err == nil && u.Host == r.Host
+}
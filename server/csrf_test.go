@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFormToken(t *testing.T) {
+	srv, _, s := newTestSession(t)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/sessions/"+s.ID, nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tokenCookie {
+		t.Fatalf("page set cookies %v", cookies)
+	}
+	token := cookies[0].Value
+	if !strings.Contains(rec.Body.String(), `name="token" value="`+token+`"`) {
+		t.Fatal("page forms lack the token")
+	}
+	tests := []struct {
+		name   string
+		cookie string
+		token  string
+		origin string
+		want   int
+	}{
+		{name: "matching token", cookie: token, token: token, want: http.StatusSeeOther},
+		{name: "same origin", cookie: token, token: token, origin: "http://example.com", want: http.StatusSeeOther},
+		{name: "no token", cookie: token, want: http.StatusForbidden},
+		{name: "no cookie", token: token, want: http.StatusForbidden},
+		{name: "neither", want: http.StatusForbidden},
+		{name: "other token", cookie: token, token: strings.Repeat("0", len(token)), want: http.StatusForbidden},
+		{name: "other origin", cookie: token, token: token, origin: "http://evil.example", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		form := url.Values{"r:ocil:org.example:question:1": {"UNKNOWN"}}
+		if tt.token != "" {
+			form.Set("token", tt.token)
+		}
+		req := httptest.NewRequest("POST", "/sessions/"+s.ID, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: tokenCookie, Value: tt.cookie})
+		}
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package server
+
+import (
+	"strconv"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// A page is the session form: the top-level questionnaires of the
+// document, each with the questions it may ask.
+type page struct {
+	Doc     *ocil.OCILType
+	Session *Session
+	// Token is the form token of the browser session.
+	Token          string
+	Questionnaires []*pageQuestionnaire
+	Answered       int
+	Asked          int
+}
+
+type pageQuestionnaire struct {
+	*ocil.QuestionnaireType
+	Result ocil.ResultType
+	// Open counts the required steps not done on the questions
+	// asked and the required artifacts not uploaded.
+	Open      int
+	Questions []*pageQuestion
+	// Shared lists the questions this questionnaire may ask that
+	// an earlier one shows.
+	Shared    []*pageQuestion
+	Artifacts []pageArtifact
+}
+
+type pageQuestion struct {
+	ID   ocil.QuestionIDPattern
+	Text string
+	// Kind is boolean, choice, numeric or string.
+	Kind     string
+	Choices  []pageChoice
+	Value    string
+	Response ocil.UserResponseType
+	Title    string
+	Steps    []*pageStep
+	Notes    []string
+	Asked    bool
+	// Home is the questionnaire showing the question.
+	Home ocil.QuestionnaireIDPattern
+}
+
+type pageChoice struct {
+	Value   string
+	Text    string
+	Checked bool
+}
+
+type pageStep struct {
+	Question    ocil.QuestionIDPattern
+	N           int
+	Description string
+	References  []ocil.ReferenceType
+	Required    bool
+	Done        bool
+	Steps       []*pageStep
+}
+
+type pageArtifact struct {
+	Session       string
+	Token         string
+	Questionnaire ocil.QuestionnaireIDPattern
+	ID            ocil.ArtifactIDPattern
+	Title         string
+	Description   string
+	Required      bool
+	Uploaded      []ocil.ArtifactResultType
+}
+
+// setToken sets the form token of the forms of p.
+func (p *page) setToken(token string) {
+	p.Token = token
+	for _, pq := range p.Questionnaires {
+		for i := range pq.Artifacts {
+			pq.Artifacts[i].Token = token
+		}
+	}
+}
+
+// responses are the responses offered besides an answer.
+var responses = []ocil.UserResponseType{
+	ocil.ResponseAnswered,
+	ocil.ResponseUnknown,
+	ocil.ResponseNotApplicable,
+}
+
+func (srv *Server) newPage(s *Session) (*page, error) {
+	ev, err := srv.evaluate(s)
+	if err != nil {
+		return nil, err
+	}
+	x := srv.index(s)
+	asked := make(map[ocil.QuestionIDPattern]bool)
+	for _, id := range ev.asked {
+		asked[id] = true
+	}
+	p := &page{Doc: x.Doc, Session: s}
+	shown := make(map[ocil.QuestionIDPattern]*pageQuestion)
+	for i := range x.Doc.Questionnaires.Questionnaire {
+		q := &x.Doc.Questionnaires.Questionnaire[i]
+		if q.Child_only {
+			continue
+		}
+		pq := &pageQuestionnaire{QuestionnaireType: q, Result: ev.result[q.Id]}
+		for _, id := range x.ReachableQuestions(ocil.TestActionRefValuePattern(q.Id)) {
+			question, ok := x.Questions[id]
+			if !ok {
+				continue
+			}
+			if prev, ok := shown[id]; ok {
+				pq.Shared = append(pq.Shared, prev)
+				continue
+			}
+			pqq := srv.newPageQuestion(s, question)
+			pqq.Asked = asked[id]
+			pqq.Home = q.Id
+			shown[id] = pqq
+			pq.Questions = append(pq.Questions, pqq)
+			if pqq.Asked {
+				p.Asked++
+				if _, ok := s.Answers[id]; ok {
+					p.Answered++
+				}
+			}
+		}
+		pq.Artifacts = srv.artifacts(s, q)
+		p.Questionnaires = append(p.Questionnaires, pq)
+	}
+	for _, pq := range p.Questionnaires {
+		pq.Open = pq.open()
+	}
+	return p, nil
+}
+
+func (srv *Server) newPageQuestion(s *Session, q ocil.Question) *pageQuestion {
+	x, vars := srv.index(s), srv.variables(s)
+	id := q.QuestionID()
+	a, answered := s.Answers[id]
+	pq := &pageQuestion{
+		ID:       id,
+		Text:     x.QuestionText(q, vars),
+		Response: ocil.ResponseAnswered,
+		Title:    q.Instruction().Title.Value,
+		Notes:    questionNotes(q),
+	}
+	if answered {
+		pq.Response = a.Response
+	}
+	answer := func(a ocil.Answer) string { return x.FormatAnswer(q, a) }
+	switch q := q.(type) {
+	case *ocil.BooleanQuestionType:
+		pq.Kind = "boolean"
+		for _, b := range []bool{true, false} {
+			pq.Choices = append(pq.Choices, pageChoice{
+				Value:   strconv.FormatBool(b),
+				Text:    answer(ocil.Answer{Response: ocil.ResponseAnswered, Boolean: b}),
+				Checked: answered && a.Response == ocil.ResponseAnswered && a.Boolean == b,
+			})
+		}
+	case *ocil.ChoiceQuestionType:
+		pq.Kind = "choice"
+		choices, _ := x.Choices(q)
+		for _, c := range choices {
+			text := c.Value
+			if c.Var_ref != "" {
+				text, _ = variableChain{x, vars}.Lookup(c.Var_ref)
+			}
+			pq.Choices = append(pq.Choices, pageChoice{
+				Value:   string(c.Id),
+				Text:    text,
+				Checked: answered && a.Response == ocil.ResponseAnswered && a.Choice == c.Id,
+			})
+		}
+	case *ocil.NumericQuestionType:
+		pq.Kind = "numeric"
+		if answered && a.Response == ocil.ResponseAnswered {
+			pq.Value = answer(a)
+		}
+	case *ocil.StringQuestionType:
+		pq.Kind = "string"
+		if answered && a.Response == ocil.ResponseAnswered {
+			pq.Value = a.String
+		}
+	}
+	done := s.stepsDone(q)
+	n := 0
+	var walk func([]ocil.StepType) []*pageStep
+	walk = func(steps []ocil.StepType) []*pageStep {
+		var out []*pageStep
+		for _, st := range steps {
+			ps := &pageStep{
+				Question:    id,
+				N:           n,
+				Description: st.Description.Value,
+				References:  st.Reference,
+				Required:    st.Is_required,
+				Done:        n < len(done) && done[n],
+			}
+			n++
+			ps.Steps = walk(st.Step)
+			out = append(out, ps)
+		}
+		return out
+	}
+	pq.Steps = walk(q.Instruction().Step)
+	return pq
+}
+
+// artifacts lists the artifacts the handlers of the test actions
+// of q refer to, directly or through child questionnaires, with
+// those uploaded for q in s.
+func (srv *Server) artifacts(s *Session, q *ocil.QuestionnaireType) []pageArtifact {
+	x := srv.index(s)
+	var out []pageArtifact
+	index := make(map[ocil.ArtifactIDPattern]int)
+	seen := make(map[ocil.TestActionRefValuePattern]bool)
+	var walk func(ocil.TestActionRefValuePattern)
+	walk = func(id ocil.TestActionRefValuePattern) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		if child, ok := x.Questionnaires[ocil.QuestionnaireIDPattern(id)]; ok {
+			for _, r := range child.Actions.Test_action_ref {
+				walk(r.TestActionRefValuePattern)
+			}
+			return
+		}
+		ta, ok := x.TestActions[ocil.QuestionTestActionIDPattern(id)]
+		if !ok {
+			return
+		}
+		for _, h := range ta.Handlers() {
+			for _, r := range h.Artifact_refs.Artifact_ref {
+				i, ok := index[r.Idref]
+				if !ok {
+					i = len(out)
+					index[r.Idref] = i
+					out = append(out, pageArtifact{Session: s.ID, Questionnaire: q.Id, ID: r.Idref})
+				}
+				out[i].Required = out[i].Required || r.Required
+			}
+			if h.Ref() != "" {
+				walk(h.Ref())
+			}
+		}
+	}
+	walk(ocil.TestActionRefValuePattern(q.Id))
+	for _, def := range x.Doc.Artifacts.Artifact {
+		if i, ok := index[def.Id]; ok {
+			out[i].Title = def.Title.Value
+			out[i].Description = def.Description.Value
+		}
+	}
+	for _, a := range s.Artifacts[q.Id] {
+		if i, ok := index[a.Artifact_ref]; ok {
+			out[i].Uploaded = append(out[i].Uploaded, a)
+		}
+	}
+	return out
+}
+
+// open counts the required steps not done on the questions q asks
+// and the required artifacts of q not uploaded.
+func (q *pageQuestionnaire) open() int {
+	n := 0
+	var count func([]*pageStep)
+	count = func(steps []*pageStep) {
+		for _, st := range steps {
+			if st.Required && !st.Done {
+				n++
+			}
+			count(st.Steps)
+		}
+	}
+	for _, pq := range append(q.Questions, q.Shared...) {
+		if pq.Asked {
+			count(pq.Steps)
+		}
+	}
+	for _, a := range q.Artifacts {
+		if a.Required && len(a.Uploaded) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// status is the part of a page the script updates after each
+// change.
+type status struct {
+	Results  map[ocil.QuestionnaireIDPattern]ocil.ResultType `json:"results"`
+	Open     map[ocil.QuestionnaireIDPattern]int             `json:"open"`
+	Asked    []ocil.QuestionIDPattern                        `json:"asked"`
+	Answered int                                             `json:"answered"`
+	Total    int                                             `json:"total"`
+}
+
+func (p *page) status() status {
+	st := status{
+		Results:  make(map[ocil.QuestionnaireIDPattern]ocil.ResultType),
+		Open:     make(map[ocil.QuestionnaireIDPattern]int),
+		Asked:    []ocil.QuestionIDPattern{},
+		Answered: p.Answered,
+		Total:    p.Asked,
+	}
+	for _, q := range p.Questionnaires {
+		st.Results[q.Id] = q.Result
+		st.Open[q.Id] = q.Open
+		for _, pq := range q.Questions {
+			if pq.Asked {
+				st.Asked = append(st.Asked, pq.ID)
+			}
+		}
+	}
+	return st
+}
+
+// questionNotes returns the notes of q.
+func questionNotes(q ocil.Question) []string {
+	switch q := q.(type) {
+	case *ocil.BooleanQuestionType:
+		return q.Notes
+	case *ocil.ChoiceQuestionType:
+		return q.Notes
+	case *ocil.NumericQuestionType:
+		return q.Notes
+	case *ocil.StringQuestionType:
+		return q.Notes
+	}
+	return nil
+}
+
+// variableChain looks a variable up in each source in turn.
+type variableChain []ocil.VariableSource
+
+func (c variableChain) Lookup(id ocil.VariableIDPattern) (string, bool) {
+	for _, s := range c {
+		if s == nil {
+			continue
+		}
+		if v, ok := s.Lookup(id); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
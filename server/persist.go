@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	ocil "github.com/redhatrises/goscap"
+	"github.com/redhatrises/goscap/store"
+)
+
+// load reads the documents and sessions of the store, replaying the
+// answers given in each session.
+func (srv *Server) load() error {
+	docs, err := srv.st.Documents()
+	if err != nil {
+		return err
+	}
+	for _, rec := range docs {
+		doc, err := ocil.ReadDocument(bytes.NewReader(rec.Data))
+		if err != nil {
+			return fmt.Errorf("document %s: %v", rec.ID, err)
+		}
+		x, err := ocil.NewIndex(doc)
+		if err != nil {
+			return fmt.Errorf("document %s: %v", rec.ID, err)
+		}
+		srv.documents[rec.ID] = &document{ID: rec.ID, Revision: rec.Revision, Uploaded: rec.Imported, x: x}
+	}
+	recs, err := srv.st.Sessions()
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		if _, ok := srv.documents[rec.Document]; !ok {
+			return fmt.Errorf("session %s: unknown document %s", rec.ID, rec.Document)
+		}
+		s := &Session{Session: *rec, Artifacts: make(map[ocil.QuestionnaireIDPattern][]ocil.ArtifactResultType)}
+		if s.Steps == nil {
+			s.Steps = make(map[ocil.QuestionIDPattern][]bool)
+		}
+		answers, err := srv.st.Answers(s.ID)
+		if err != nil {
+			return err
+		}
+		s.Answers = store.Current(answers)
+		artifacts, err := srv.st.Artifacts(s.ID)
+		if err != nil {
+			return err
+		}
+		for _, a := range artifacts {
+			s.Artifacts[a.Questionnaire] = append(s.Artifacts[a.Questionnaire], a.Result)
+		}
+		srv.sessions[s.ID] = s
+	}
+	return nil
+}
+
+// saveSession stores the current state of s and, once every
+// question its evaluation asks is answered, its results, so that the
+// stored results follow any later change. A session whose evaluation
+// fails has no results to store.
+func (srv *Server) saveSession(s *Session) error {
+	if err := srv.st.PutSession(&s.Session); err != nil {
+		return err
+	}
+	ev, err := srv.evaluate(s)
+	if err != nil {
+		return nil
+	}
+	if _, pending := ev.next(s); pending {
+		return nil
+	}
+	data, err := srv.resultsXML(s, ev)
+	if err != nil {
+		return err
+	}
+	return srv.st.PutResults(&store.Results{Session: s.ID, Time: time.Now(), Data: data})
+}
+
+// setAnswer records the answer to question id given in s by
+// submitter, unless it is the answer already recorded. An answer
+// with an empty response withdraws the recorded one.
+func (srv *Server) setAnswer(s *Session, id ocil.QuestionIDPattern, a ocil.Answer, submitter ocil.UserType) error {
+	if old, ok := s.Answers[id]; ok && old == a || !ok && a.Response == "" {
+		return nil
+	}
+	now := time.Now()
+	err := srv.st.AddAnswer(&store.Answer{Session: s.ID, Question: id, Answer: a, Submitter: submitter, Time: now})
+	if err != nil {
+		return err
+	}
+	if a.Response == "" {
+		delete(s.Answers, id)
+	} else {
+		s.Answers[id] = a
+	}
+	s.Updated = now
+	return nil
+}
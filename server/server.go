@@ -0,0 +1,228 @@
+// Package server hosts assessments against OCIL documents. A Server
+// renders the questionnaires of a document as forms in the browser
+// and offers the same sessions through a JSON API; both evaluate
+// the answers as they are given and produce the results document of
+// each assessment. Everything it serves, including its style sheet,
+// script and OpenAPI description, is embedded, so it works without
+// network access.
+package server
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ocil "github.com/redhatrises/goscap"
+	"github.com/redhatrises/goscap/store"
+)
+
+// maxUpload is the largest document or artifact upload accepted,
+// in bytes.
+const maxUpload = 32 << 20
+
+//go:embed assets
+var assets embed.FS
+
+var pages = template.Must(template.New("").Funcs(template.FuncMap{
+	"lower":     func(r ocil.ResultType) string { return strings.ToLower(string(r)) },
+	"time":      func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"responses": func() []ocil.UserResponseType { return responses },
+}).ParseFS(assets, "assets/*.html"))
+
+// A document is an assessed OCIL document, identified by a digest
+// of its canonical form. The documents with the same title are
+// numbered as revisions of one another.
+type document struct {
+	ID       string
+	Revision int
+	Uploaded time.Time
+	x        *ocil.Index
+}
+
+// Index returns the index of the document.
+func (d *document) Index() *ocil.Index { return d.x }
+
+// Title returns the title of the document, or its ID without one.
+func (d *document) Title() string {
+	if t := d.x.Doc.Document.Title; t != "" {
+		return t
+	}
+	return d.ID
+}
+
+// A Server serves the assessment of targets against its documents.
+// It records documents, sessions, answers, artifacts and exported
+// results in its store as they change, and New loads them again.
+type Server struct {
+	vars ocil.VariableSource
+	st   store.Store
+	mux  *http.ServeMux
+
+	mu        sync.Mutex
+	documents map[string]*document
+	sessions  map[string]*Session
+}
+
+// New returns a Server keeping its records in st, starting with
+// those already there. Variables are resolved from the constants of
+// a document, then from the variables of the session and then from
+// vars, which may be nil.
+func New(st store.Store, vars ocil.VariableSource) (*Server, error) {
+	srv := &Server{
+		vars:      vars,
+		st:        st,
+		mux:       http.NewServeMux(),
+		documents: make(map[string]*document),
+		sessions:  make(map[string]*Session),
+	}
+	if err := srv.load(); err != nil {
+		return nil, err
+	}
+	static, _ := fs.Sub(assets, "assets")
+	srv.mux.Handle("GET /assets/", http.StripPrefix("/assets/", http.FileServer(http.FS(static))))
+	srv.mux.HandleFunc("GET /{$}", srv.home)
+	srv.mux.HandleFunc("POST /documents", checkForm(srv.uploadDocument))
+	srv.mux.HandleFunc("POST /sessions", checkForm(srv.newSession))
+	srv.mux.HandleFunc("GET /sessions/{id}", srv.session)
+	srv.mux.HandleFunc("POST /sessions/{id}", checkForm(srv.answer))
+	srv.mux.HandleFunc("POST /sessions/{id}/artifacts", checkForm(srv.upload))
+	srv.mux.HandleFunc("GET /sessions/{id}/results.xml", srv.download)
+	srv.routeAPI()
+	return srv, nil
+}
+
+// ServeHTTP serves r, refusing a POST sent from a page of another
+// origin.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && !sameOrigin(r) {
+		http.Error(w, "cross-origin request refused", http.StatusForbidden)
+		return
+	}
+	srv.mux.ServeHTTP(w, r)
+}
+
+// AddDocument makes doc available for assessment and returns its
+// ID. Adding a document with the same content again returns the
+// same ID.
+func (srv *Server) AddDocument(doc *ocil.OCILType) (string, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	d, err := srv.addDocument(doc)
+	if err != nil {
+		return "", err
+	}
+	return d.ID, nil
+}
+
+func (srv *Server) addDocument(doc *ocil.OCILType) (*document, error) {
+	x, err := ocil.NewIndex(doc)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := ocil.WriteDocument(&buf, doc); err != nil {
+		return nil, err
+	}
+	name := doc.Document.Title
+	if name == "" {
+		name = store.DocumentID(buf.Bytes())
+	}
+	rec, err := srv.st.AddDocument(name, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := srv.documents[rec.ID]; ok {
+		return d, nil
+	}
+	d := &document{ID: rec.ID, Revision: rec.Revision, Uploaded: rec.Imported, x: x}
+	srv.documents[rec.ID] = d
+	return d, nil
+}
+
+// sortedDocuments returns the documents in upload order.
+func (srv *Server) sortedDocuments() []*document {
+	var ds []*document
+	for _, d := range srv.documents {
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].Uploaded.Before(ds[j].Uploaded) })
+	return ds
+}
+
+// sortedSessions returns the sessions in the order they started.
+func (srv *Server) sortedSessions() []*Session {
+	var ss []*Session
+	for _, s := range srv.sessions {
+		ss = append(ss, s)
+	}
+	sort.Slice(ss, func(i, j int) bool { return ss[i].Started.Before(ss[j].Started) })
+	return ss
+}
+
+// startSession starts the assessment of target by assessor against
+// the document with the given ID.
+func (srv *Server) startSession(docID string, target ocil.Target, assessor string) (*Session, error) {
+	if _, ok := srv.documents[docID]; !ok {
+		return nil, fmt.Errorf("unknown document %q", docID)
+	}
+	s := NewSession(docID, target, assessor)
+	if err := srv.saveSession(s); err != nil {
+		return nil, err
+	}
+	srv.sessions[s.ID] = s
+	return s, nil
+}
+
+// index returns the index of the document of s.
+func (srv *Server) index(s *Session) *ocil.Index {
+	return srv.documents[s.Document].x
+}
+
+// variables returns the source of the variables of s, after the
+// constants of its document.
+func (srv *Server) variables(s *Session) ocil.VariableSource {
+	return variableChain{s.Variables, srv.vars}
+}
+
+// addArtifact checks that the document of s defines the artifact of
+// a and records it for questionnaire qid.
+func (srv *Server) addArtifact(s *Session, qid ocil.QuestionnaireIDPattern, a ocil.ArtifactResultType) error {
+	x := srv.index(s)
+	if _, ok := x.Questionnaires[qid]; !ok {
+		return fmt.Errorf("unknown questionnaire %s", qid)
+	}
+	for _, def := range x.Doc.Artifacts.Artifact {
+		if def.Id != a.Artifact_ref {
+			continue
+		}
+		s.AddArtifact(qid, a)
+		as := s.Artifacts[qid]
+		if err := srv.st.AddArtifact(&store.Artifact{Session: s.ID, Questionnaire: qid, Result: as[len(as)-1]}); err != nil {
+			s.Artifacts[qid] = as[:len(as)-1]
+			return err
+		}
+		return srv.saveSession(s)
+	}
+	return fmt.Errorf("unknown artifact %s", a.Artifact_ref)
+}
+
+// fileName makes a target name safe to use as a file name.
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '"' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "results"
+	}
+	return name
+}
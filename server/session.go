@@ -0,0 +1,244 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	ocil "github.com/redhatrises/goscap"
+	"github.com/redhatrises/goscap/store"
+)
+
+// A Session is the assessment of one target: its record in the
+// store, with the answers given so far and the artifacts uploaded.
+type Session struct {
+	store.Session
+	Answers ocil.Answers
+	// Artifacts holds the artifacts uploaded for each questionnaire.
+	Artifacts map[ocil.QuestionnaireIDPattern][]ocil.ArtifactResultType
+}
+
+// NewSession starts the assessment of target by assessor against
+// the document with the given ID.
+func NewSession(docID string, target ocil.Target, assessor string) *Session {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	now := time.Now()
+	s := &Session{
+		Session: store.Session{
+			ID:       hex.EncodeToString(id),
+			Document: docID,
+			Assessor: ocil.UserType{Name: assessor},
+			Started:  now,
+			Updated:  now,
+			Steps:    make(map[ocil.QuestionIDPattern][]bool),
+		},
+		Answers:   make(ocil.Answers),
+		Artifacts: make(map[ocil.QuestionnaireIDPattern][]ocil.ArtifactResultType),
+	}
+	s.Targets.Add(target)
+	return s
+}
+
+// TargetName returns the name of the assessed target.
+func (s *Session) TargetName() string {
+	if ts := s.Targets.All(); len(ts) > 0 {
+		return ts[0].TargetName()
+	}
+	return ""
+}
+
+// AddArtifact records an artifact collected for questionnaire id,
+// submitted by the assessor and provided by the target.
+func (s *Session) AddArtifact(id ocil.QuestionnaireIDPattern, a ocil.ArtifactResultType) {
+	a.Submitter = s.Assessor
+	a.Provider = ocil.ProviderValuePattern(s.TargetName())
+	a.Timestamp = time.Now()
+	s.Artifacts[id] = append(s.Artifacts[id], a)
+	s.Updated = a.Timestamp
+}
+
+// stepsDone returns whether each step of q is done, depth first,
+// starting from the Is_done flags of the document.
+func (s *Session) stepsDone(q ocil.Question) []bool {
+	if done, ok := s.Steps[q.QuestionID()]; ok {
+		return done
+	}
+	var done []bool
+	var walk func([]ocil.StepType)
+	walk = func(steps []ocil.StepType) {
+		for _, st := range steps {
+			done = append(done, st.Is_done)
+			walk(st.Step)
+		}
+	}
+	walk(q.Instruction().Step)
+	return done
+}
+
+// parseAnswer reads an answer to q given as text: true or false for
+// a boolean question, a choice ID, a number or any string.
+func parseAnswer(x *ocil.Index, q ocil.Question, s string) (ocil.Answer, error) {
+	a := ocil.Answer{Response: ocil.ResponseAnswered}
+	var err error
+	switch q := q.(type) {
+	case *ocil.BooleanQuestionType:
+		a.Boolean, err = strconv.ParseBool(s)
+	case *ocil.ChoiceQuestionType:
+		a.Choice = ocil.ChoiceIDPattern(s)
+		var choices []ocil.ChoiceType
+		if choices, err = x.Choices(q); err != nil {
+			return a, err
+		}
+		for _, c := range choices {
+			if c.Id == a.Choice {
+				return a, nil
+			}
+		}
+		err = fmt.Errorf("%q is not a choice", s)
+	case *ocil.NumericQuestionType:
+		a.Numeric, err = strconv.ParseFloat(strings.TrimSpace(s), 64)
+	default:
+		a.String = s
+	}
+	if err != nil {
+		return a, fmt.Errorf("question %s: invalid answer %q", q.QuestionID(), s)
+	}
+	return a, nil
+}
+
+// evaluation is the state of a session against its document.
+type evaluation struct {
+	results *ocil.ResultsType
+	// asked holds the questions the evaluation needed, in the order
+	// it needed them, and askedBy the top-level questionnaire that
+	// first needed each.
+	asked   []ocil.QuestionIDPattern
+	askedBy map[ocil.QuestionIDPattern]ocil.QuestionnaireIDPattern
+	// result gives the result of every questionnaire evaluated.
+	result map[ocil.QuestionnaireIDPattern]ocil.ResultType
+}
+
+// recorder is an AnswerSource noting the questions asked.
+type recorder struct {
+	ev      *evaluation
+	answers ocil.Answers
+	current ocil.QuestionnaireIDPattern
+}
+
+func (r *recorder) Answer(q ocil.Question) (ocil.Answer, error) {
+	id := q.QuestionID()
+	r.ev.asked = append(r.ev.asked, id)
+	r.ev.askedBy[id] = r.current
+	return r.answers.Answer(q)
+}
+
+// evaluate computes the results of every questionnaire of the
+// document of s that is not child only from the answers of s.
+func (srv *Server) evaluate(s *Session) (*evaluation, error) {
+	x := srv.index(s)
+	ev := &evaluation{
+		askedBy: make(map[ocil.QuestionIDPattern]ocil.QuestionnaireIDPattern),
+		result:  make(map[ocil.QuestionnaireIDPattern]ocil.ResultType),
+	}
+	src := &recorder{ev: ev, answers: s.Answers}
+	e := ocil.NewEvaluator(x, src, srv.variables(s))
+	for _, q := range x.Doc.Questionnaires.Questionnaire {
+		if q.Child_only {
+			continue
+		}
+		src.current = q.Id
+		if _, err := e.Questionnaire(q.Id); err != nil {
+			return nil, err
+		}
+	}
+	r := e.Results()
+	r.Start_time = s.Started
+	r.End_time = s.Updated
+	r.Targets = s.Targets
+	ev.results = &r
+	qrs := r.Questionnaire_results.Questionnaire_result
+	for i := range qrs {
+		ev.result[qrs[i].Questionnaire_ref] = qrs[i].Result
+		qrs[i].Artifact_results.Artifact_result = s.Artifacts[qrs[i].Questionnaire_ref]
+	}
+	return ev, nil
+}
+
+// next returns the first question asked that s has no answer to.
+func (ev *evaluation) next(s *Session) (ocil.QuestionIDPattern, bool) {
+	for _, id := range ev.asked {
+		if _, ok := s.Answers[id]; !ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// resultsXML returns the document of s carrying the results of its
+// evaluation ev, with the steps of its questions marked done as
+// recorded.
+func (srv *Server) resultsXML(s *Session, ev *evaluation) ([]byte, error) {
+	doc := ocil.WithResults(srv.index(s).Doc, ev.results)
+	qs := &doc.Questions
+	qs.Boolean_question = append([]ocil.BooleanQuestionType(nil), qs.Boolean_question...)
+	for i := range qs.Boolean_question {
+		s.markSteps(&qs.Boolean_question[i])
+	}
+	qs.Choice_question = append([]ocil.ChoiceQuestionType(nil), qs.Choice_question...)
+	for i := range qs.Choice_question {
+		s.markSteps(&qs.Choice_question[i])
+	}
+	qs.Numeric_question = append([]ocil.NumericQuestionType(nil), qs.Numeric_question...)
+	for i := range qs.Numeric_question {
+		s.markSteps(&qs.Numeric_question[i])
+	}
+	qs.String_question = append([]ocil.StringQuestionType(nil), qs.String_question...)
+	for i := range qs.String_question {
+		s.markSteps(&qs.String_question[i])
+	}
+	var buf bytes.Buffer
+	if err := ocil.WriteDocument(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// markSteps replaces the steps of the copied question q by copies
+// whose Is_done flags are those recorded in s.
+func (s *Session) markSteps(q ocil.Question) {
+	done, ok := s.Steps[q.QuestionID()]
+	if !ok {
+		return
+	}
+	var mark func([]ocil.StepType) []ocil.StepType
+	mark = func(steps []ocil.StepType) []ocil.StepType {
+		out := make([]ocil.StepType, len(steps))
+		for i, st := range steps {
+			if len(done) > 0 {
+				st.Is_done, done = done[0], done[1:]
+			}
+			st.Step = mark(st.Step)
+			out[i] = st
+		}
+		return out
+	}
+	var in *ocil.InstructionsType
+	switch q := q.(type) {
+	case *ocil.BooleanQuestionType:
+		in = &q.Instructions
+	case *ocil.ChoiceQuestionType:
+		in = &q.Instructions
+	case *ocil.NumericQuestionType:
+		in = &q.Instructions
+	case *ocil.StringQuestionType:
+		in = &q.Instructions
+	}
+	in.Step = mark(in.Step)
+}
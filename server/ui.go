@@ -0,0 +1,277 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// lookup returns the session named by the request path, or replies
+// with 404 and returns nil.
+func (srv *Server) lookup(w http.ResponseWriter, r *http.Request) *Session {
+	s, ok := srv.sessions[r.PathValue("id")]
+	if !ok {
+		http.NotFound(w, r)
+		return nil
+	}
+	return s
+}
+
+func render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (srv *Server) home(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	type row struct {
+		*Session
+		Document *document
+	}
+	var rows []row
+	for _, s := range srv.sortedSessions() {
+		rows = append(rows, row{s, srv.documents[s.Document]})
+	}
+	render(w, "index.html", struct {
+		Documents []*document
+		Sessions  []row
+		Token     string
+	}{srv.sortedDocuments(), rows, formToken(w, r)})
+}
+
+func (srv *Server) uploadDocument(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxUpload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, _, err := r.FormFile("document")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer f.Close()
+	doc, err := ocil.ReadDocument(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if _, err := srv.addDocument(doc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (srv *Server) newSession(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.FormValue("target"))
+	if name == "" {
+		http.Error(w, "missing target name", http.StatusBadRequest)
+		return
+	}
+	var target ocil.Target = ocil.NewSystemTarget(name)
+	if r.FormValue("kind") == "user" {
+		target = ocil.NewUserTarget(name)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, err := srv.startSession(r.FormValue("document"), target, strings.TrimSpace(r.FormValue("assessor")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/sessions/"+s.ID, http.StatusSeeOther)
+}
+
+func (srv *Server) session(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s := srv.lookup(w, r)
+	if s == nil {
+		return
+	}
+	p, err := srv.newPage(s)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	p.setToken(formToken(w, r))
+	render(w, "session.html", p)
+}
+
+// answer records the answers and steps of a submitted session form.
+// The script of the page posts the form on every change and
+// updates the page from the JSON reply; without it, the form is
+// submitted as usual and the page reloaded.
+func (srv *Server) answer(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s := srv.lookup(w, r)
+	if s == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := srv.readForm(s, r.PostForm); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := srv.saveSession(s); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.Header.Get("Accept") != "application/json" {
+		http.Redirect(w, r, "/sessions/"+s.ID, http.StatusSeeOther)
+		return
+	}
+	p, err := srv.newPage(s)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p.status())
+}
+
+// readForm replaces the answers and step states of s by those of a
+// session form. A question whose response is ANSWERED but whose
+// value is empty is left unanswered.
+func (srv *Server) readForm(s *Session, form map[string][]string) error {
+	get := func(key string) string {
+		if v := form[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	// Every answer is parsed before any is recorded, so that a form
+	// with an invalid answer changes nothing.
+	type entry struct {
+		id     ocil.QuestionIDPattern
+		answer ocil.Answer
+		done   []bool
+	}
+	var entries []entry
+	x := srv.index(s)
+	for _, q := range x.Doc.Questions.All() {
+		id := q.QuestionID()
+		if _, ok := form["r:"+string(id)]; !ok {
+			continue
+		}
+		resp := ocil.UserResponseType(get("r:" + string(id)))
+		value := get("a:" + string(id))
+		var a ocil.Answer
+		switch {
+		case resp != ocil.ResponseAnswered:
+			a = ocil.Answer{Response: resp}
+		case strings.TrimSpace(value) != "":
+			var err error
+			if a, err = parseAnswer(x, q, value); err != nil {
+				return err
+			}
+		}
+		done := append([]bool(nil), s.stepsDone(q)...)
+		for i := range done {
+			done[i] = get(fmt.Sprintf("s:%s:%d", id, i)) != ""
+		}
+		entries = append(entries, entry{id, a, done})
+	}
+	for _, e := range entries {
+		if err := srv.setAnswer(s, e.id, e.answer, s.Assessor); err != nil {
+			return err
+		}
+		if len(e.done) > 0 {
+			s.Steps[e.id] = e.done
+		}
+	}
+	s.Updated = time.Now()
+	return nil
+}
+
+// upload records an artifact sent as a file, as text or as a
+// reference to a URL.
+func (srv *Server) upload(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxUpload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s := srv.lookup(w, r)
+	if s == nil {
+		return
+	}
+	qid := ocil.QuestionnaireIDPattern(r.FormValue("questionnaire"))
+	a := ocil.ArtifactResultType{Artifact_ref: ocil.ArtifactIDPattern(r.FormValue("artifact"))}
+	f, h, err := r.FormFile("file")
+	switch {
+	case err == nil:
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.Binary_artifact_value = &ocil.BinaryArtifactValueType{Data: data, Mime_type: mimeType(h.Header.Get("Content-Type"), data)}
+	case r.FormValue("href") != "":
+		a.Reference_artifact_value = &ocil.ReferenceArtifactValueType{Reference: ocil.Reference{Href: r.FormValue("href")}}
+	case r.FormValue("text") != "":
+		a.Text_artifact_value = &ocil.TextArtifactValueType{Data: r.FormValue("text"), Mime_type: "text/plain"}
+	default:
+		http.Error(w, "missing artifact file, text or reference", http.StatusBadRequest)
+		return
+	}
+	if err := srv.addArtifact(s, qid, a); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/sessions/"+s.ID+"#"+string(qid), http.StatusSeeOther)
+}
+
+// mimeType returns the media type declared for an upload, or the
+// one sniffed from its content.
+func mimeType(declared string, data []byte) string {
+	if t, _, err := mime.ParseMediaType(declared); err == nil && t != "application/octet-stream" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+func (srv *Server) download(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s := srv.lookup(w, r)
+	if s == nil {
+		return
+	}
+	srv.writeResults(w, s)
+}
+
+// writeResults replies with the current results document of s. The
+// results are stored by saveSession when the assessment completes.
+func (srv *Server) writeResults(w http.ResponseWriter, s *Session) {
+	ev, err := srv.evaluate(s)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := srv.resultsXML(s, ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName(s.TargetName())+".xml"))
+	w.Write(data)
+}
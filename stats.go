@@ -0,0 +1,242 @@
+package postal
+
+import (
+	"math"
+	"sort"
+)
+
+// Stats summarizes the content of a document, for planning
+// assessments and tracking content across releases.
+type Stats struct {
+	Questionnaires int `json:"questionnaires"`
+	// TopLevel counts the questionnaires that are not child only.
+	TopLevel  int `json:"top_level"`
+	ChildOnly int `json:"child_only"`
+	// Questions, TestActions and Variables count items by type:
+	// boolean, choice, numeric and string, or constant, external
+	// and local.
+	Questions   map[string]int `json:"questions"`
+	TestActions map[string]int `json:"test_actions"`
+	Variables   map[string]int `json:"variables"`
+	Artifacts   int            `json:"artifacts"`
+	// RequiredArtifacts counts the artifacts some handler requires.
+	RequiredArtifacts int `json:"required_artifacts"`
+	// MaxDepth is the largest Depth of a top-level questionnaire.
+	MaxDepth int `json:"max_depth"`
+	// Coverage gives, for each reference system, the top-level
+	// questionnaires referring to it, ordered by system.
+	Coverage []ReferenceCoverage `json:"reference_coverage"`
+	// Unreferenced counts the top-level questionnaires without
+	// references.
+	Unreferenced int `json:"unreferenced"`
+	// PerQuestionnaire holds the estimates for each top-level
+	// questionnaire, in document order.
+	PerQuestionnaire []QuestionnaireStats `json:"per_questionnaire"`
+}
+
+// ReferenceCoverage counts the top-level questionnaires with at
+// least one reference of a system, and the distinct identifiers
+// they use.
+type ReferenceCoverage struct {
+	System         string `json:"system"`
+	Questionnaires int    `json:"questionnaires"`
+	Identifiers    int    `json:"identifiers"`
+}
+
+// QuestionnaireStats estimates the effort of one questionnaire.
+type QuestionnaireStats struct {
+	ID    QuestionnaireIDPattern `json:"id"`
+	Title string                 `json:"title,omitempty"`
+	// Depth is the largest number of questions asked one after
+	// another, each depending on the answer to the previous one.
+	Depth int `json:"depth"`
+	// WorstCase counts the distinct questions evaluation may ask.
+	WorstCase int `json:"worst_case"`
+	// Typical is the number of questions asked when every answered
+	// handler of a test action is equally likely, rounded to one
+	// decimal. It ignores questions shared between paths and is
+	// at most WorstCase.
+	Typical float64 `json:"typical"`
+}
+
+// DocumentStats computes the statistics of doc. Top-level
+// questionnaires are those that are not child only, or every
+// questionnaire when all are.
+func DocumentStats(doc *OCILType) (*Stats, error) {
+	x, err := NewIndex(doc)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stats{
+		Questions:   make(map[string]int),
+		TestActions: make(map[string]int),
+		Variables: map[string]int{
+			"constant": len(doc.Variables.Constant_variable),
+			"external": len(doc.Variables.External_variable),
+			"local":    len(doc.Variables.Local_variable),
+		},
+		Artifacts: len(doc.Artifacts.Artifact),
+	}
+	qs, tas := &doc.Questions, &doc.Test_actions
+	s.Questions["boolean"] = len(qs.Boolean_question)
+	s.Questions["choice"] = len(qs.Choice_question)
+	s.Questions["numeric"] = len(qs.Numeric_question)
+	s.Questions["string"] = len(qs.String_question)
+	s.TestActions["boolean"] = len(tas.Boolean_question_test_action)
+	s.TestActions["choice"] = len(tas.Choice_question_test_action)
+	s.TestActions["numeric"] = len(tas.Numeric_question_test_action)
+	s.TestActions["string"] = len(tas.String_question_test_action)
+
+	required := make(map[ArtifactIDPattern]bool)
+	for _, ta := range tas.All() {
+		for _, h := range ta.Handlers() {
+			for _, a := range h.Artifact_refs.Artifact_ref {
+				if a.Required {
+					required[a.Idref] = true
+				}
+			}
+		}
+	}
+	s.RequiredArtifacts = len(required)
+
+	var top []*QuestionnaireType
+	for i := range doc.Questionnaires.Questionnaire {
+		q := &doc.Questionnaires.Questionnaire[i]
+		s.Questionnaires++
+		if q.Child_only {
+			s.ChildOnly++
+		} else {
+			top = append(top, q)
+		}
+	}
+	s.TopLevel = len(top)
+	if len(top) == 0 {
+		for i := range doc.Questionnaires.Questionnaire {
+			top = append(top, &doc.Questionnaires.Questionnaire[i])
+		}
+	}
+
+	e := &estimator{
+		x:        x,
+		depth:    make(map[TestActionRefValuePattern]int),
+		typical:  make(map[TestActionRefValuePattern]float64),
+		visiting: make(map[TestActionRefValuePattern]bool),
+	}
+	type coverage struct {
+		questionnaires int
+		ids            map[string]bool
+	}
+	systems := make(map[string]*coverage)
+	for _, q := range top {
+		id := TestActionRefValuePattern(q.Id)
+		st := QuestionnaireStats{
+			ID:        q.Id,
+			Title:     q.Title.Value,
+			Depth:     e.depthOf(id),
+			WorstCase: len(x.ReachableQuestions(id)),
+		}
+		st.Typical = math.Min(math.Round(e.typicalOf(id)*10)/10, float64(st.WorstCase))
+		if st.Depth > s.MaxDepth {
+			s.MaxDepth = st.Depth
+		}
+		s.PerQuestionnaire = append(s.PerQuestionnaire, st)
+
+		if len(q.References.Reference) == 0 {
+			s.Unreferenced++
+		}
+		seen := make(map[string]bool)
+		for _, r := range q.References.Reference {
+			sys := ReferenceSystem(r)
+			c, ok := systems[sys]
+			if !ok {
+				c = &coverage{ids: make(map[string]bool)}
+				systems[sys] = c
+			}
+			c.ids[referenceKey(r.Value)] = true
+			if !seen[sys] {
+				seen[sys] = true
+				c.questionnaires++
+			}
+		}
+	}
+	for sys, c := range systems {
+		s.Coverage = append(s.Coverage, ReferenceCoverage{sys, c.questionnaires, len(c.ids)})
+	}
+	sort.Slice(s.Coverage, func(i, j int) bool { return s.Coverage[i].System < s.Coverage[j].System })
+	return s, nil
+}
+
+// estimator computes the depth and typical question count of
+// questionnaires and test actions. An item on a cycle counts as
+// asking nothing more.
+type estimator struct {
+	x        *Index
+	depth    map[TestActionRefValuePattern]int
+	typical  map[TestActionRefValuePattern]float64
+	visiting map[TestActionRefValuePattern]bool
+}
+
+func (e *estimator) depthOf(id TestActionRefValuePattern) int {
+	if d, ok := e.depth[id]; ok {
+		return d
+	}
+	if e.visiting[id] {
+		return 0
+	}
+	e.visiting[id] = true
+	defer delete(e.visiting, id)
+	d := 0
+	if q, ok := e.x.Questionnaires[QuestionnaireIDPattern(id)]; ok {
+		for _, r := range q.Actions.Test_action_ref {
+			if n := e.depthOf(r.TestActionRefValuePattern); n > d {
+				d = n
+			}
+		}
+	} else if ta, ok := e.x.TestActions[QuestionTestActionIDPattern(id)]; ok {
+		for _, h := range ta.Handlers() {
+			if h.Ref() != "" {
+				if n := e.depthOf(h.Ref()); n > d {
+					d = n
+				}
+			}
+		}
+		d++
+	}
+	e.depth[id] = d
+	return d
+}
+
+func (e *estimator) typicalOf(id TestActionRefValuePattern) float64 {
+	if t, ok := e.typical[id]; ok {
+		return t
+	}
+	if e.visiting[id] {
+		return 0
+	}
+	e.visiting[id] = true
+	defer delete(e.visiting, id)
+	var t float64
+	if q, ok := e.x.Questionnaires[QuestionnaireIDPattern(id)]; ok {
+		for _, r := range q.Actions.Test_action_ref {
+			t += e.typicalOf(r.TestActionRefValuePattern)
+		}
+	} else if ta, ok := e.x.TestActions[QuestionTestActionIDPattern(id)]; ok {
+		var sum float64
+		n := 0
+		for _, h := range ta.Handlers() {
+			if h.Condition.Response != ResponseAnswered {
+				continue
+			}
+			n++
+			if h.Ref() != "" {
+				sum += e.typicalOf(h.Ref())
+			}
+		}
+		t = 1
+		if n > 0 {
+			t += sum / float64(n)
+		}
+	}
+	e.typical[id] = t
+	return t
+}
@@ -0,0 +1,53 @@
+package postal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocumentStats(t *testing.T) {
+	sample := &Stats{
+		Questionnaires:    2,
+		TopLevel:          1,
+		ChildOnly:         1,
+		Questions:         map[string]int{"boolean": 2, "choice": 1, "numeric": 1, "string": 1},
+		TestActions:       map[string]int{"boolean": 2, "choice": 1, "numeric": 1, "string": 1},
+		Variables:         map[string]int{"constant": 0, "external": 1, "local": 0},
+		Artifacts:         1,
+		RequiredArtifacts: 1,
+		MaxDepth:          2,
+		Coverage: []ReferenceCoverage{
+			{System: "CCE", Questionnaires: 1, Identifiers: 1},
+			{System: "CPE", Questionnaires: 1, Identifiers: 1},
+		},
+		PerQuestionnaire: []QuestionnaireStats{
+			// Question 1 leads to question 2 half of the time, and
+			// question 3 to questions 4 and 5 of the child
+			// questionnaire: 1.5 + 1 + 2/2.
+			{ID: "ocil:org.example:questionnaire:1", Title: "Password policy", Depth: 2, WorstCase: 5, Typical: 3.5},
+		},
+	}
+	tests := []struct {
+		file string
+		want *Stats
+	}{
+		{"sample.xml", sample},
+		// Results do not change the statistics of the content.
+		{"answers-a.xml", sample},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			doc, err := ReadFile("testdata/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := DocumentStats(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
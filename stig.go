@@ -0,0 +1,146 @@
+package postal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Reference hrefs of the identifiers carried over from a STIG.
+const (
+	stigHref = "https://public.cyber.mil/stigs/"
+	cciHref  = "http://cyber.mil/cci"
+)
+
+var (
+	findingRE        = regexp.MustCompile(`(?is)\bif\s+(.+?),?\s+(?:then\s+)?this is a finding`)
+	vulnDiscussionRE = regexp.MustCompile(`(?s)<VulnDiscussion>(.*?)</VulnDiscussion>`)
+	idNamespaceRE    = regexp.MustCompile(`[^A-Za-z0-9_\-.]+`)
+	paragraphRE      = regexp.MustCompile(`\n\s*\n`)
+)
+
+// IDNamespace turns s into a namespace usable in OCIL IDs, which
+// allow only letters, digits, '_', '-' and '.'.
+func IDNamespace(s string) string {
+	ns := strings.Trim(idNamespaceRE.ReplaceAllString(s, "_"), "_")
+	if ns == "" {
+		ns = "local"
+	}
+	return ns
+}
+
+// ImportSTIG converts the manual checks of a DISA STIG benchmark
+// into an OCIL document, with IDs in namespace ns. A rule is manual
+// when its check has free-text check content. Each becomes a
+// questionnaire titled after the rule with one boolean question:
+// whether the "If ..., this is a finding" conditions of the check
+// text hold. Yes is FAIL and No is PASS. The paragraphs of the check
+// text become instruction steps, and the questionnaire references
+// the Vuln ID, Rule ID, STIG ID and CCIs of the rule.
+func ImportSTIG(b *Benchmark, ns string) (*OCILType, error) {
+	doc := &OCILType{
+		Generator: GeneratorType{
+			Product_name:   "ocil3 import stig",
+			Schema_version: 2.0,
+			Timestamp:      time.Now().UTC().Truncate(time.Second),
+		},
+		Document: DocumentType{Title: b.Title},
+	}
+	n := 0
+	for _, r := range b.Rules {
+		var content string
+		for _, c := range r.Checks {
+			if strings.TrimSpace(c.Content) != "" {
+				content = c.Content
+				break
+			}
+		}
+		if content == "" {
+			continue
+		}
+		n++
+		qid := QuestionIDPattern(fmt.Sprintf("ocil:%s:question:%d", ns, n))
+		tid := QuestionTestActionIDPattern(fmt.Sprintf("ocil:%s:testaction:%d", ns, n))
+		q := QuestionnaireType{
+			Id:    QuestionnaireIDPattern(fmt.Sprintf("ocil:%s:questionnaire:%d", ns, n)),
+			Title: TextType{Value: r.Title},
+			Actions: OperationType{
+				Test_action_ref: []TestActionRefType{{TestActionRefValuePattern: TestActionRefValuePattern(tid)}},
+			},
+			References: ReferencesType{Reference: stigReferences(r)},
+		}
+		if m := vulnDiscussionRE.FindStringSubmatch(r.Description); m != nil {
+			q.Description.Value = strings.TrimSpace(m[1])
+		}
+		if r.Severity != "" {
+			q.Notes = append(q.Notes, "Severity: "+r.Severity)
+		}
+		doc.Questionnaires.Questionnaire = append(doc.Questionnaires.Questionnaire, q)
+
+		doc.Test_actions.Boolean_question_test_action = append(doc.Test_actions.Boolean_question_test_action,
+			BooleanQuestionTestActionType{
+				Id:           tid,
+				Question_ref: qid,
+				When_true:    TestActionConditionType{Result: ResultFail},
+				When_false:   TestActionConditionType{Result: ResultPass},
+			})
+
+		question := BooleanQuestionType{
+			Id:            qid,
+			Model:         ModelYesNo,
+			Question_text: []QuestionTextType{PlainText(stigQuestion(content))},
+			Instructions:  InstructionsType{Title: TextType{Value: "Check procedure"}},
+		}
+		for _, p := range stigParagraphs(content) {
+			question.Instructions.Step = append(question.Instructions.Step, StepType{Description: TextType{Value: p}})
+		}
+		doc.Questions.Boolean_question = append(doc.Questions.Boolean_question, question)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("benchmark %s has no manual checks", b.ID)
+	}
+	return doc, nil
+}
+
+// stigQuestion phrases the finding conditions of check text as a
+// yes/no question.
+func stigQuestion(content string) string {
+	var conds []string
+	for _, m := range findingRE.FindAllStringSubmatch(content, -1) {
+		conds = append(conds, strings.Join(strings.Fields(m[1]), " "))
+	}
+	switch len(conds) {
+	case 0:
+		return "Does the check procedure reveal a finding?"
+	case 1:
+		return "Is the following true: " + conds[0] + "?"
+	}
+	return "Is any of the following true: " + strings.Join(conds, "; or ") + "?"
+}
+
+// stigParagraphs splits check text at blank lines.
+func stigParagraphs(content string) []string {
+	var ps []string
+	for _, p := range paragraphRE.Split(strings.TrimSpace(content), -1) {
+		if p = strings.TrimSpace(p); p != "" {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+func stigReferences(r XCCDFRule) []ReferenceType {
+	var refs []ReferenceType
+	for _, id := range []string{r.Group.ID, r.ID, r.Version} {
+		if id != "" {
+			refs = append(refs, ReferenceType{Value: id, Href: stigHref})
+		}
+	}
+	for _, id := range r.Idents {
+		if strings.HasPrefix(id.Value, "CCI-") {
+			refs = append(refs, ReferenceType{Value: id.Value, Href: cciHref})
+		}
+	}
+	return refs
+}
@@ -0,0 +1,122 @@
+package postal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const stigBenchmark = `<?xml version="1.0" encoding="UTF-8"?>
+<Benchmark xmlns="http://checklists.nist.gov/xccdf/1.1" id="Sample_STIG">
+  <title>Sample STIG</title>
+  <Group id="V-1001">
+    <title>SRG-OS-000001</title>
+    <Rule id="SV-1001r1_rule" severity="medium">
+      <version>SAMP-00-000010</version>
+      <title>The system must lock accounts after failed logons.</title>
+      <description>&lt;VulnDiscussion&gt;Locking accounts slows guessing.&lt;/VulnDiscussion&gt;&lt;FalsePositives&gt;&lt;/FalsePositives&gt;</description>
+      <ident system="http://cyber.mil/cci">CCI-000044</ident>
+      <ident system="http://cyber.mil/legacy">V-1001</ident>
+      <check system="C-1001r1_chk">
+        <check-content>Open the account lockout policy.
+
+If the threshold is not set,
+this is a finding.</check-content>
+      </check>
+    </Rule>
+  </Group>
+  <Group id="V-1002">
+    <title>SRG-OS-000002</title>
+    <Rule id="SV-1002r1_rule">
+      <title>Automated rule.</title>
+      <check system="http://oval.mitre.org/XMLSchema/oval-definitions-5">
+        <check-content-ref href="oval.xml" name="oval:x:def:1"/>
+      </check>
+    </Rule>
+  </Group>
+</Benchmark>`
+
+func TestImportSTIG(t *testing.T) {
+	b, err := ReadBenchmark(strings.NewReader(stigBenchmark))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := ImportSTIG(b, IDNamespace(b.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := NewIndex(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := doc.Questionnaires.Questionnaire
+	if len(qs) != 1 {
+		t.Fatalf("%d questionnaires, want only the manual check", len(qs))
+	}
+	q := qs[0]
+	if q.Id != "ocil:Sample_STIG:questionnaire:1" {
+		t.Errorf("questionnaire ID %s", q.Id)
+	}
+	if q.Description.Value != "Locking accounts slows guessing." {
+		t.Errorf("description %q", q.Description.Value)
+	}
+	if !reflect.DeepEqual(q.Notes, []string{"Severity: medium"}) {
+		t.Errorf("notes %q", q.Notes)
+	}
+	var refs []string
+	for _, r := range q.References.Reference {
+		refs = append(refs, r.Value)
+	}
+	if want := []string{"V-1001", "SV-1001r1_rule", "SAMP-00-000010", "CCI-000044"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("references %q, want %q", refs, want)
+	}
+	bq := x.Questions["ocil:Sample_STIG:question:1"].(*BooleanQuestionType)
+	if got, want := x.QuestionText(bq, nil), "Is the following true: the threshold is not set?"; got != want {
+		t.Errorf("question %q, want %q", got, want)
+	}
+	if n := len(bq.Instructions.Step); n != 2 {
+		t.Errorf("%d steps, want 2", n)
+	}
+	for answer, want := range map[bool]ResultType{true: ResultFail, false: ResultPass} {
+		got, err := NewEvaluator(x, Answers{bq.Id: {Response: ResponseAnswered, Boolean: answer}}, nil).Questionnaire(q.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("answer %v: %s, want %s", answer, got, want)
+		}
+	}
+}
+
+func TestSTIGQuestion(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"Check the setting.", "Does the check procedure reveal a finding?"},
+		{"If the value is 0, this is a finding.", "Is the following true: the value is 0?"},
+		{"If   the value\n is 0 then this is a finding.", "Is the following true: the value is 0?"},
+		{"If A, this is a finding.\n\nIf B, this is a finding.", "Is any of the following true: A; or B?"},
+		{"if lower case, This Is A Finding.", "Is the following true: lower case?"},
+	}
+	for _, tt := range tests {
+		if got := stigQuestion(tt.content); got != tt.want {
+			t.Errorf("stigQuestion(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestIDNamespace(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"org.example", "org.example"},
+		{"Windows 10 STIG", "Windows_10_STIG"},
+		{"  (x)  ", "x"},
+		{"***", "local"},
+		{"", "local"},
+	}
+	for _, tt := range tests {
+		if got := IDNamespace(tt.in); got != tt.want {
+			t.Errorf("IDNamespace(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
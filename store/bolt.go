@@ -0,0 +1,272 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// The buckets of a Bolt database. Documents, sessions and results
+// are keyed by ID and session ID and held as JSON. The answers and
+// artifacts of a session are held in a bucket named by the session
+// ID within the answers and artifacts buckets, keyed by sequence
+// number. The content of binary artifacts is held in the blobs
+// bucket, keyed by its SHA-256 digest, so that an artifact uploaded
+// many times is stored once.
+var (
+	documentsBucket = []byte("documents")
+	sessionsBucket  = []byte("sessions")
+	answersBucket   = []byte("answers")
+	artifactsBucket = []byte("artifacts")
+	blobsBucket     = []byte("blobs")
+	resultsBucket   = []byte("results")
+)
+
+// Bolt is a Store in a bbolt database file.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens the database in the named file, creating it if
+// needed. It fails if another process has the database open.
+func OpenBolt(name string) (*Bolt, error) {
+	db, err := bolt.Open(name, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{documentsBucket, sessionsBucket, answersBucket, artifactsBucket, blobsBucket, resultsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return &Bolt{db}, nil
+}
+
+func (b *Bolt) AddDocument(name string, data []byte) (*Document, error) {
+	d := &Document{ID: DocumentID(data), Name: name, Revision: 1, Imported: time.Now(), Data: data}
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		docs := tx.Bucket(documentsBucket)
+		if v := docs.Get([]byte(d.ID)); v != nil {
+			return json.Unmarshal(v, d)
+		}
+		err := docs.ForEach(func(_, v []byte) error {
+			var old Document
+			if err := json.Unmarshal(v, &old); err != nil {
+				return err
+			}
+			if old.Name == name && old.Revision >= d.Revision {
+				d.Revision = old.Revision + 1
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return put(docs, []byte(d.ID), d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (b *Bolt) Document(id string) (*Document, error) {
+	d := new(Document)
+	if err := b.get(documentsBucket, id, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (b *Bolt) Documents() ([]*Document, error) {
+	var ds []*Document
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(documentsBucket).ForEach(func(_, v []byte) error {
+			d := new(Document)
+			ds = append(ds, d)
+			return json.Unmarshal(v, d)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ds, func(i, j int) bool { return ds[i].Imported.Before(ds[j].Imported) })
+	return ds, nil
+}
+
+func (b *Bolt) PutSession(s *Session) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(sessionsBucket), []byte(s.ID), s)
+	})
+}
+
+func (b *Bolt) Session(id string) (*Session, error) {
+	s := new(Session)
+	if err := b.get(sessionsBucket, id, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (b *Bolt) Sessions() ([]*Session, error) {
+	var ss []*Session
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(_, v []byte) error {
+			s := new(Session)
+			ss = append(ss, s)
+			return json.Unmarshal(v, s)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortSessions(ss)
+	return ss, nil
+}
+
+func (b *Bolt) AddAnswer(a *Answer) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return appendTo(tx.Bucket(answersBucket), a.Session, a)
+	})
+}
+
+func (b *Bolt) Answers(session string) ([]*Answer, error) {
+	var as []*Answer
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return forEachIn(tx.Bucket(answersBucket), session, func(v []byte) error {
+			a := new(Answer)
+			as = append(as, a)
+			return json.Unmarshal(v, a)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+// boltArtifact is an artifact as held in a Bolt database, with the
+// content of a binary value replaced by the digest of its blob.
+type boltArtifact struct {
+	Artifact
+	Blob string `json:"blob,omitempty"`
+}
+
+func (b *Bolt) AddArtifact(a *Artifact) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		ba := boltArtifact{Artifact: *a}
+		if v := a.Result.Binary_artifact_value; v != nil {
+			sum := sha256.Sum256(v.Data)
+			ba.Blob = hex.EncodeToString(sum[:])
+			if err := tx.Bucket(blobsBucket).Put([]byte(ba.Blob), v.Data); err != nil {
+				return err
+			}
+			bv := *v
+			bv.Data = nil
+			ba.Result.Binary_artifact_value = &bv
+		}
+		return appendTo(tx.Bucket(artifactsBucket), a.Session, &ba)
+	})
+}
+
+func (b *Bolt) Artifacts(session string) ([]*Artifact, error) {
+	var as []*Artifact
+	err := b.db.View(func(tx *bolt.Tx) error {
+		blobs := tx.Bucket(blobsBucket)
+		return forEachIn(tx.Bucket(artifactsBucket), session, func(v []byte) error {
+			var ba boltArtifact
+			if err := json.Unmarshal(v, &ba); err != nil {
+				return err
+			}
+			if ba.Blob != "" {
+				data := blobs.Get([]byte(ba.Blob))
+				if data == nil {
+					return fmt.Errorf("session %s: missing artifact blob %s", session, ba.Blob)
+				}
+				ba.Result.Binary_artifact_value.Data = append([]byte(nil), data...)
+			}
+			as = append(as, &ba.Artifact)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+func (b *Bolt) PutResults(r *Results) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(resultsBucket), []byte(r.Session), r)
+	})
+}
+
+func (b *Bolt) Results(session string) (*Results, error) {
+	r := new(Results)
+	if err := b.get(resultsBucket, session, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (b *Bolt) Close() error { return b.db.Close() }
+
+// get reads the record with the given key in the named bucket into
+// v.
+func (b *Bolt) get(bucket []byte, key string, v interface{}) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, v)
+	})
+}
+
+// put writes v as the record with the given key in bucket.
+func put(bucket *bolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// appendTo writes v as the next record of the bucket named session
+// within bucket.
+func appendTo(bucket *bolt.Bucket, session string, v interface{}) error {
+	b, err := bucket.CreateBucketIfNotExists([]byte(session))
+	if err != nil {
+		return err
+	}
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return put(b, key, v)
+}
+
+// forEachIn calls fn with each record of the bucket named session
+// within bucket, in sequence.
+func forEachIn(bucket *bolt.Bucket, session string, fn func([]byte) error) error {
+	b := bucket.Bucket([]byte(session))
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(_, v []byte) error { return fn(v) })
+}
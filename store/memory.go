@@ -0,0 +1,145 @@
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory is a Store keeping its records in memory only.
+type Memory struct {
+	mu        sync.Mutex
+	documents []*Document
+	sessions  map[string]*Session
+	answers   map[string][]*Answer
+	artifacts map[string][]*Artifact
+	results   map[string]*Results
+}
+
+// NewMemory returns an empty Memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		sessions:  make(map[string]*Session),
+		answers:   make(map[string][]*Answer),
+		artifacts: make(map[string][]*Artifact),
+		results:   make(map[string]*Results),
+	}
+}
+
+func (m *Memory) AddDocument(name string, data []byte) (*Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := DocumentID(data)
+	d := &Document{ID: id, Name: name, Revision: 1, Imported: time.Now(), Data: data}
+	for _, old := range m.documents {
+		if old.ID == id {
+			return old, nil
+		}
+		if old.Name == name && old.Revision >= d.Revision {
+			d.Revision = old.Revision + 1
+		}
+	}
+	m.documents = append(m.documents, d)
+	return d, nil
+}
+
+func (m *Memory) Document(id string) (*Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.documents {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) Documents() ([]*Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Document(nil), m.documents...), nil
+}
+
+func (m *Memory) PutSession(s *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *s
+	m.sessions[s.ID] = &c
+	return nil
+}
+
+func (m *Memory) Session(id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *s
+	return &c, nil
+}
+
+func (m *Memory) Sessions() ([]*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ss []*Session
+	for _, s := range m.sessions {
+		c := *s
+		ss = append(ss, &c)
+	}
+	sortSessions(ss)
+	return ss, nil
+}
+
+func (m *Memory) AddAnswer(a *Answer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *a
+	m.answers[a.Session] = append(m.answers[a.Session], &c)
+	return nil
+}
+
+func (m *Memory) Answers(session string) ([]*Answer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Answer(nil), m.answers[session]...), nil
+}
+
+func (m *Memory) AddArtifact(a *Artifact) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *a
+	m.artifacts[a.Session] = append(m.artifacts[a.Session], &c)
+	return nil
+}
+
+func (m *Memory) Artifacts(session string) ([]*Artifact, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Artifact(nil), m.artifacts[session]...), nil
+}
+
+func (m *Memory) PutResults(r *Results) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *r
+	m.results[r.Session] = &c
+	return nil
+}
+
+func (m *Memory) Results(session string) (*Results, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.results[session]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+func (m *Memory) Close() error { return nil }
+
+// sortSessions sorts sessions in the order they started.
+func sortSessions(ss []*Session) {
+	sort.Slice(ss, func(i, j int) bool { return ss[i].Started.Before(ss[j].Started) })
+}
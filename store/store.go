@@ -0,0 +1,139 @@
+// Package store keeps the records of assessments: the OCIL documents
+// imported, the assessment sessions against them, every answer given
+// with its submitter and time, the artifacts collected and the final
+// results. A Store is implemented by Memory, which keeps the records
+// for the life of the process, and by Bolt, an embedded database in a
+// single file; other backends implement the same interface.
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	ocil "github.com/redhatrises/goscap"
+)
+
+// ErrNotFound is returned for a record that is not in a store.
+var ErrNotFound = errors.New("not found")
+
+// A Store holds the records of assessments. Its methods are safe for
+// concurrent use.
+type Store interface {
+	// AddDocument imports the OCIL document data under name as its
+	// next revision. Importing the content of a stored document
+	// again returns that document.
+	AddDocument(name string, data []byte) (*Document, error)
+	// Document returns the document with the given ID.
+	Document(id string) (*Document, error)
+	// Documents returns every revision of every document, in import
+	// order.
+	Documents() ([]*Document, error)
+
+	// PutSession creates or replaces a session.
+	PutSession(s *Session) error
+	// Session returns the session with the given ID.
+	Session(id string) (*Session, error)
+	// Sessions returns the sessions in the order they started.
+	Sessions() ([]*Session, error)
+
+	// AddAnswer appends an answer to the history of its session.
+	AddAnswer(a *Answer) error
+	// Answers returns the answers given in a session, in the order
+	// they were added.
+	Answers(session string) ([]*Answer, error)
+
+	// AddArtifact appends an artifact to those of its session.
+	AddArtifact(a *Artifact) error
+	// Artifacts returns the artifacts of a session, in the order
+	// they were added.
+	Artifacts(session string) ([]*Artifact, error)
+
+	// PutResults records the results of a session, replacing any
+	// recorded before.
+	PutResults(r *Results) error
+	// Results returns the results last recorded for a session.
+	Results(session string) (*Results, error)
+
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// A Document is an imported OCIL document. OCIL gives revisions to
+// the items of a document but not to the document itself, so the
+// documents imported under the same name are numbered as revisions
+// of one another, from 1 in import order.
+type Document struct {
+	// ID is derived from the content of the document.
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Revision int       `json:"revision"`
+	Imported time.Time `json:"imported"`
+	// Data holds the document as XML.
+	Data []byte `json:"data"`
+}
+
+// DocumentID returns the ID of a document with the given content.
+func DocumentID(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// A Session is the assessment of targets against a document.
+type Session struct {
+	ID string `json:"id"`
+	// Document is the ID of the assessed document.
+	Document string           `json:"document"`
+	Targets  ocil.TargetsType `json:"targets"`
+	Assessor ocil.UserType    `json:"assessor"`
+	Started  time.Time        `json:"started"`
+	Updated  time.Time        `json:"updated"`
+	// Variables holds values of external variables for this
+	// assessment.
+	Variables ocil.Variables `json:"variables,omitempty"`
+	// Steps holds, for each question, whether each step of its
+	// instructions is done, numbering nested steps depth first.
+	Steps map[ocil.QuestionIDPattern][]bool `json:"steps"`
+}
+
+// An Answer is a response to a question given in a session. An
+// answer with an empty Response withdraws the earlier answers to the
+// question.
+type Answer struct {
+	Session   string                 `json:"session"`
+	Question  ocil.QuestionIDPattern `json:"question"`
+	Answer    ocil.Answer            `json:"answer"`
+	Submitter ocil.UserType          `json:"submitter"`
+	Time      time.Time              `json:"time"`
+}
+
+// Current returns the answers in effect after the history as, keyed
+// by question.
+func Current(as []*Answer) ocil.Answers {
+	answers := make(ocil.Answers)
+	for _, a := range as {
+		if a.Answer.Response == "" {
+			delete(answers, a.Question)
+		} else {
+			answers[a.Question] = a.Answer
+		}
+	}
+	return answers
+}
+
+// An Artifact is an artifact collected for a questionnaire in a
+// session; its submitter and time are those of the result.
+type Artifact struct {
+	Session       string                      `json:"session"`
+	Questionnaire ocil.QuestionnaireIDPattern `json:"questionnaire"`
+	Result        ocil.ArtifactResultType     `json:"result"`
+}
+
+// Results are the final results of a session.
+type Results struct {
+	Session string    `json:"session"`
+	Time    time.Time `json:"time"`
+	// Data holds the results document as XML.
+	Data []byte `json:"data"`
+}
@@ -0,0 +1,231 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	ocil "github.com/redhatrises/goscap"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestStores(t *testing.T) {
+	stores := []struct {
+		name string
+		open func(t *testing.T) Store
+	}{
+		{"memory", func(t *testing.T) Store { return NewMemory() }},
+		{"bolt", func(t *testing.T) Store {
+			b, err := OpenBolt(filepath.Join(t.TempDir(), "ocil.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return b
+		}},
+	}
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			st := s.open(t)
+			defer st.Close()
+			testStore(t, st)
+		})
+	}
+}
+
+// testStore checks the behaviour every Store shares.
+func testStore(t *testing.T, st Store) {
+	// Documents are numbered as revisions per name, and importing
+	// the same content again returns the stored document.
+	docs := []struct {
+		name, data string
+		revision   int
+	}{
+		{"a", "<ocil>1</ocil>", 1},
+		{"a", "<ocil>2</ocil>", 2},
+		{"b", "<ocil>3</ocil>", 1},
+		{"a", "<ocil>1</ocil>", 1},
+		{"a", "<ocil>4</ocil>", 3},
+	}
+	var ids []string
+	for _, d := range docs {
+		got, err := st.AddDocument(d.name, []byte(d.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Revision != d.revision || got.ID != DocumentID([]byte(d.data)) {
+			t.Errorf("AddDocument(%s, %s) = revision %d ID %s, want %d", d.name, d.data, got.Revision, got.ID, d.revision)
+		}
+		ids = append(ids, got.ID)
+	}
+	all, err := st.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Errorf("%d documents, want 4", len(all))
+	}
+	if d, err := st.Document(ids[1]); err != nil || string(d.Data) != "<ocil>2</ocil>" {
+		t.Errorf("Document(%s) = %v, %v", ids[1], d, err)
+	}
+	if _, err := st.Document("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing document: %v", err)
+	}
+
+	// Sessions are replaced by ID and listed in the order they
+	// started.
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s1 := &Session{ID: "s1", Document: ids[0], Started: start.Add(time.Hour), Steps: map[ocil.QuestionIDPattern][]bool{"ocil:a:question:1": {true, false}}}
+	s1.Targets.Add(ocil.NewSystemTarget("web1"))
+	s2 := &Session{ID: "s2", Document: ids[2], Started: start, Variables: ocil.Variables{"ocil:a:variable:1": "8"}}
+	for _, s := range []*Session{s1, s2} {
+		if err := st.PutSession(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s1.Assessor = ocil.UserType{Name: "alice"}
+	if err := st.PutSession(s1); err != nil {
+		t.Fatal(err)
+	}
+	got, err := st.Session("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Assessor.Name != "alice" || !reflect.DeepEqual(got.Targets.All(), s1.Targets.All()) || !reflect.DeepEqual(got.Steps, s1.Steps) {
+		t.Errorf("Session(s1) = %+v", got)
+	}
+	ss, err := st.Sessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 2 || ss[0].ID != "s2" || ss[1].ID != "s1" {
+		t.Errorf("Sessions() not in start order: %v", ss)
+	}
+	if _, err := st.Session("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing session: %v", err)
+	}
+
+	// Answers and artifacts keep the order they were added in.
+	answers := []*Answer{
+		{Session: "s1", Question: "ocil:a:question:1", Answer: ocil.Answer{Response: ocil.ResponseAnswered, Boolean: true}, Time: start},
+		{Session: "s2", Question: "ocil:a:question:1", Answer: ocil.Answer{Response: ocil.ResponseUnknown}, Time: start},
+		{Session: "s1", Question: "ocil:a:question:2", Answer: ocil.Answer{Response: ocil.ResponseAnswered, Numeric: 8}, Time: start},
+		{Session: "s1", Question: "ocil:a:question:1", Time: start},
+	}
+	for _, a := range answers {
+		if err := st.AddAnswer(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	as, err := st.Answers("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 3 || as[0].Question != "ocil:a:question:1" || as[1].Answer.Numeric != 8 || as[2].Answer.Response != "" {
+		t.Errorf("Answers(s1) = %v", as)
+	}
+	blob := []byte{0, 1, 2, 3}
+	for i := 0; i < 2; i++ {
+		a := &Artifact{Session: "s1", Questionnaire: "ocil:a:questionnaire:1", Result: ocil.ArtifactResultType{
+			Artifact_ref:          "ocil:a:artifact:1",
+			Binary_artifact_value: &ocil.BinaryArtifactValueType{Data: blob, Mime_type: "application/octet-stream"},
+		}}
+		if err := st.AddArtifact(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	arts, err := st.Artifacts("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arts) != 2 || !reflect.DeepEqual(arts[1].Result.Binary_artifact_value.Data, blob) {
+		t.Errorf("Artifacts(s1) = %v", arts)
+	}
+	if arts, err := st.Artifacts("s2"); err != nil || len(arts) != 0 {
+		t.Errorf("Artifacts(s2) = %v, %v", arts, err)
+	}
+
+	// Results are replaced.
+	if _, err := st.Results("s1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("results before any: %v", err)
+	}
+	for _, data := range []string{"first", "second"} {
+		if err := st.PutResults(&Results{Session: "s1", Time: start, Data: []byte(data)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if r, err := st.Results("s1"); err != nil || string(r.Data) != "second" {
+		t.Errorf("Results(s1) = %v, %v", r, err)
+	}
+}
+
+func TestBoltReopen(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "ocil.db")
+	b, err := OpenBolt(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.AddDocument("a", []byte("<ocil/>")); err != nil {
+		t.Fatal(err)
+	}
+	blob := []byte("screenshot")
+	for i := 0; i < 3; i++ {
+		a := &Artifact{Session: "s1", Result: ocil.ArtifactResultType{
+			Binary_artifact_value: &ocil.BinaryArtifactValueType{Data: blob, Mime_type: "image/png"},
+		}}
+		if err := b.AddArtifact(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The content of an artifact uploaded many times is held once.
+	err = b.db.View(func(tx *bolt.Tx) error {
+		if n := tx.Bucket(blobsBucket).Stats().KeyN; n != 1 {
+			t.Errorf("%d blobs, want 1", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenBolt(name); err == nil {
+		t.Error("database opened twice")
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = OpenBolt(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	docs, err := b.Documents()
+	if err != nil || len(docs) != 1 {
+		t.Errorf("Documents() after reopening = %v, %v", docs, err)
+	}
+	arts, err := b.Artifacts("s1")
+	if err != nil || len(arts) != 3 || string(arts[2].Result.Binary_artifact_value.Data) != "screenshot" {
+		t.Errorf("Artifacts(s1) after reopening = %v, %v", arts, err)
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	yes := ocil.Answer{Response: ocil.ResponseAnswered, Boolean: true}
+	no := ocil.Answer{Response: ocil.ResponseAnswered}
+	tests := []struct {
+		name    string
+		history []*Answer
+		want    ocil.Answers
+	}{
+		{"empty", nil, ocil.Answers{}},
+		{"latest wins", []*Answer{{Question: "q1", Answer: yes}, {Question: "q1", Answer: no}}, ocil.Answers{"q1": no}},
+		{"withdrawn", []*Answer{{Question: "q1", Answer: yes}, {Question: "q2", Answer: no}, {Question: "q1"}}, ocil.Answers{"q2": no}},
+		{"answered again", []*Answer{{Question: "q1", Answer: yes}, {Question: "q1"}, {Question: "q1", Answer: no}}, ocil.Answers{"q1": no}},
+	}
+	for _, tt := range tests {
+		if got := Current(tt.history); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package postal
+
+import (
+	"net"
+	"os"
+)
+
+// A Target is the user or system a set of results applies to:
+// either a *UserType or a *SystemTargetType.
+type Target interface {
+	TargetName() string
+}
+
+func (t *UserType) TargetName() string         { return t.Name }
+func (t *SystemTargetType) TargetName() string { return t.Name }
+
+// All returns the targets in the container, in document order.
+func (t *TargetsType) All() []Target {
+	var all []Target
+	for _, it := range t.order.items(t.kinds()) {
+		all = append(all, it.v.(Target))
+	}
+	return all
+}
+
+// Add appends a copy of target to the container.
+func (t *TargetsType) Add(target Target) {
+	switch target := target.(type) {
+	case *UserType:
+		t.User = append(t.User, *target)
+		t.order.add("user")
+	case *SystemTargetType:
+		t.System = append(t.System, *target)
+		t.order.add("system")
+	}
+}
+
+// NewSystemTarget returns a system target with the given name and
+// IP addresses.
+func NewSystemTarget(name string, addrs ...string) *SystemTargetType {
+	return &SystemTargetType{Name: name, Ipaddress: addrs}
+}
+
+// NewUserTarget returns a user target with the given name and
+// email addresses.
+func NewUserTarget(name string, email ...string) *UserType {
+	return &UserType{Name: name, Email: email}
+}
+
+// LocalSystemTarget describes the host the program runs on: its
+// host name and the addresses of its interfaces, excluding
+// loopback and link-local addresses.
+func LocalSystemTarget() (*SystemTargetType, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	t := NewSystemTarget(name)
+	for _, a := range addrs {
+		n, ok := a.(*net.IPNet)
+		if !ok || n.IP.IsLoopback() || n.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		t.Ipaddress = append(t.Ipaddress, n.IP.String())
+	}
+	return t, nil
+}
@@ -0,0 +1,102 @@
+package postal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Variables maps variable IDs to values. It implements
+// VariableSource.
+type Variables map[VariableIDPattern]string
+
+func (v Variables) Lookup(id VariableIDPattern) (string, bool) {
+	s, ok := v[id]
+	return s, ok
+}
+
+// MergeVariables combines layers of variable values. A value in a
+// later layer overrides the same variable in earlier layers.
+func MergeVariables(layers ...Variables) Variables {
+	vars := make(Variables)
+	for _, l := range layers {
+		for id, v := range l {
+			vars[id] = v
+		}
+	}
+	return vars
+}
+
+// ParseVariable parses an assignment of the form id=value.
+func ParseVariable(s string) (VariableIDPattern, string, error) {
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return "", "", fmt.Errorf("variable assignment %q is not of the form id=value", s)
+	}
+	id := strings.TrimSpace(s[:i])
+	if id == "" {
+		return "", "", fmt.Errorf("variable assignment %q has no id", s)
+	}
+	return VariableIDPattern(id), strings.TrimSpace(s[i+1:]), nil
+}
+
+// ReadVariables reads variable values, one id=value assignment per
+// line. Blank lines and lines starting with # are ignored.
+func ReadVariables(r io.Reader) (Variables, error) {
+	vars := make(Variables)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, v, err := ParseVariable(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		vars[id] = v
+	}
+	return vars, s.Err()
+}
+
+// ReadVariablesFile reads the variable values in the named file.
+func ReadVariablesFile(name string) (Variables, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	vars, err := ReadVariables(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return vars, nil
+}
+
+// CheckExternals verifies values supplied for the document's
+// external variables: every value must be for an external variable
+// and those declared NUMERIC must hold a number. Errors are
+// reported in order of variable ID.
+func (x *Index) CheckExternals(vars Variables) error {
+	ids := make([]string, 0, len(vars))
+	for id := range vars {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		v, ok := x.Externals[VariableIDPattern(id)]
+		if !ok {
+			return fmt.Errorf("%s is not an external variable of the document", id)
+		}
+		if v.Datatype == DatatypeNumeric {
+			if _, err := strconv.ParseFloat(vars[VariableIDPattern(id)], 64); err != nil {
+				return fmt.Errorf("external variable %s is NUMERIC, got %q", id, vars[VariableIDPattern(id)])
+			}
+		}
+	}
+	return nil
+}
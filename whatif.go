@@ -0,0 +1,578 @@
+package postal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// maxScenarios bounds the number of alternatives kept for a
+// single outcome of a test action. Documents with wide AND
+// operations over many-way branches would otherwise grow
+// exponentially.
+const maxScenarios = 1024
+
+// A Scenario is a conjunction of conditions that together drive a
+// test action or questionnaire to a particular result.
+type Scenario []Condition
+
+// A BlockingAnswer is a recorded answer that violates one
+// condition of a scenario.
+type BlockingAnswer struct {
+	Condition Condition
+	Answer    Answer
+}
+
+// A PassPath is one minimal way for a questionnaire to PASS,
+// checked against the answers recorded so far.
+type PassPath struct {
+	Conditions Scenario
+	// Blocking lists the recorded answers that must change for
+	// this path to be taken.
+	Blocking []BlockingAnswer
+	// Open lists the conditions on questions with no recorded
+	// answer.
+	Open []Condition
+}
+
+// Satisfied reports whether the recorded answers already take the
+// path.
+func (p PassPath) Satisfied() bool {
+	return len(p.Blocking) == 0 && len(p.Open) == 0
+}
+
+// A WhatIfReport lists the answers that would make a
+// questionnaire PASS.
+type WhatIfReport struct {
+	Questionnaire QuestionnaireIDPattern
+	// Paths is ordered by the number of blocking answers, then by
+	// the number of open conditions, so the cheapest remediation
+	// comes first.
+	Paths []PassPath
+	// Truncated is set when some alternatives were dropped to
+	// stay within maxScenarios.
+	Truncated bool
+}
+
+// WhatIf enumerates the minimal sets of answers that make the
+// questionnaire PASS, and reports for each which of the recorded
+// answers block it. Variable references in numeric and string
+// conditions are resolved through vars when checking answers.
+//
+// Only PASS and FAIL outcomes are propagated through the test
+// action graph: an AND passes when every child passes and an OR
+// fails when every child fails. Children that would evaluate to
+// NOT_APPLICABLE, which the result tables ignore, are not
+// enumerated. As in evaluation, the first handler of a test action
+// that matches an answer decides, so the condition of a handler
+// leaves out the answers of the handlers before it.
+func (x *Index) WhatIf(id QuestionnaireIDPattern, answers Answers, vars VariableSource) (*WhatIfReport, error) {
+	if _, ok := x.Questionnaires[id]; !ok {
+		return nil, fmt.Errorf("unknown questionnaire %s", id)
+	}
+	a := &whatIf{
+		x:        x,
+		memo:     make(map[TestActionRefValuePattern]outcomes),
+		visiting: make(map[TestActionRefValuePattern]bool),
+	}
+	o, err := a.item(TestActionRefValuePattern(id))
+	if err != nil {
+		return nil, err
+	}
+	rep := &WhatIfReport{Questionnaire: id, Truncated: a.truncated}
+	for _, s := range o.pass {
+		p := PassPath{Conditions: s}
+		for _, c := range s {
+			ans, ok := answers[c.Question]
+			if !ok {
+				p.Open = append(p.Open, c)
+				continue
+			}
+			match, err := c.Matches(ans, vars)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				p.Blocking = append(p.Blocking, BlockingAnswer{c, ans})
+			}
+		}
+		rep.Paths = append(rep.Paths, p)
+	}
+	sort.SliceStable(rep.Paths, func(i, j int) bool {
+		pi, pj := rep.Paths[i], rep.Paths[j]
+		if len(pi.Blocking) != len(pj.Blocking) {
+			return len(pi.Blocking) < len(pj.Blocking)
+		}
+		return len(pi.Open) < len(pj.Open)
+	})
+	return rep, nil
+}
+
+// outcomes holds, in disjunctive normal form, the scenarios that
+// lead to PASS and to FAIL.
+type outcomes struct {
+	pass, fail []Scenario
+}
+
+type whatIf struct {
+	x         *Index
+	memo      map[TestActionRefValuePattern]outcomes
+	visiting  map[TestActionRefValuePattern]bool
+	truncated bool
+}
+
+func (a *whatIf) ref(r TestActionRefType) (outcomes, error) {
+	o, err := a.item(r.TestActionRefValuePattern)
+	if r.Negate {
+		o.pass, o.fail = o.fail, o.pass
+	}
+	return o, err
+}
+
+func (a *whatIf) item(id TestActionRefValuePattern) (outcomes, error) {
+	if o, ok := a.memo[id]; ok {
+		return o, nil
+	}
+	if a.visiting[id] {
+		return outcomes{}, fmt.Errorf("test action cycle through %s", id)
+	}
+	a.visiting[id] = true
+	defer delete(a.visiting, id)
+
+	var o outcomes
+	var err error
+	if q, ok := a.x.Questionnaires[QuestionnaireIDPattern(id)]; ok {
+		o, err = a.compound(q.Actions)
+	} else if ta, ok := a.x.TestActions[QuestionTestActionIDPattern(id)]; ok {
+		o, err = a.testAction(ta)
+	} else {
+		err = fmt.Errorf("unknown test action %s", id)
+	}
+	if err != nil {
+		return outcomes{}, err
+	}
+	a.memo[id] = o
+	return o, nil
+}
+
+func (a *whatIf) compound(op OperationType) (outcomes, error) {
+	var o outcomes
+	for i, r := range op.Test_action_ref {
+		c, err := a.ref(r)
+		if err != nil {
+			return outcomes{}, err
+		}
+		if i == 0 {
+			o = c
+			continue
+		}
+		if op.Operation == OperatorOr {
+			o.pass = a.union(o.pass, c.pass)
+			o.fail = a.product(o.fail, c.fail)
+		} else {
+			o.pass = a.product(o.pass, c.pass)
+			o.fail = a.union(o.fail, c.fail)
+		}
+	}
+	if op.Negate {
+		o.pass, o.fail = o.fail, o.pass
+	}
+	return o, nil
+}
+
+func (a *whatIf) testAction(ta TestAction) (outcomes, error) {
+	var o outcomes
+	var earlier []Condition
+	for _, h := range ta.Handlers() {
+		c, ok := h.Condition.exclude(earlier)
+		earlier = append(earlier, h.Condition)
+		if !ok {
+			continue
+		}
+		s := []Scenario{{c}}
+		switch {
+		case h.Result == ResultPass:
+			o.pass = a.union(o.pass, s)
+		case h.Result == ResultFail:
+			o.fail = a.union(o.fail, s)
+		case h.Ref() != "":
+			sub, err := a.ref(h.Test_action_ref)
+			if err != nil {
+				return outcomes{}, err
+			}
+			o.pass = a.union(o.pass, a.product(s, sub.pass))
+			o.fail = a.union(o.fail, a.product(s, sub.fail))
+		}
+	}
+	return o, nil
+}
+
+// exclude narrows c to the answers that satisfy none of the earlier
+// conditions. It reports false if no answer is left.
+func (c Condition) exclude(earlier []Condition) (Condition, bool) {
+	for _, d := range earlier {
+		var ok bool
+		if c, ok = c.minus(d); !ok {
+			return Condition{}, false
+		}
+	}
+	return c, true
+}
+
+// minus returns the condition satisfied by the answers that satisfy
+// c but not d, reporting false if there are none. Differences that
+// cannot be computed here, because a bound or pattern refers to a
+// variable or d is a general pattern, are kept in Unless.
+func (c Condition) minus(d Condition) (Condition, bool) {
+	if c.Question != d.Question || c.Response != d.Response {
+		return c, true
+	}
+	if c.Response != ResponseAnswered {
+		return Condition{}, false
+	}
+	switch {
+	case c.Boolean != nil && d.Boolean != nil:
+		return c, *c.Boolean != *d.Boolean
+	case c.Choices != nil && d.Choices != nil:
+		var rest []ChoiceIDPattern
+	choices:
+		for _, x := range c.Choices {
+			for _, y := range d.Choices {
+				if x == y {
+					continue choices
+				}
+			}
+			rest = append(rest, x)
+		}
+		c.Choices = rest
+		return c, len(rest) > 0
+	case !c.hasVarRef() && !d.hasVarRef():
+		if m, ok, done := minusLiteral(c, d); done {
+			return m, ok
+		}
+	}
+	c.Unless = append(c.Unless[:len(c.Unless):len(c.Unless)], d)
+	return c, true
+}
+
+// minusLiteral computes c minus d for numeric conditions and for
+// patterns admitting only exact strings, with literal values. It
+// reports done false for other conditions.
+func minusLiteral(c, d Condition) (m Condition, ok, done bool) {
+	takes := func(a Answer) bool {
+		match, err := d.Matches(a, nil)
+		return match && err == nil
+	}
+	switch {
+	case c.Equals != nil && (d.Equals != nil || d.Range != nil):
+		eq := *c.Equals
+		eq.Value = nil
+		for _, v := range c.Equals.Value {
+			if !takes(Answer{Response: ResponseAnswered, Numeric: v}) {
+				eq.Value = append(eq.Value, v)
+			}
+		}
+		c.Equals = &eq
+		return c, len(eq.Value) > 0, true
+	case c.Range != nil && (d.Equals != nil || d.Range != nil):
+		holes := []RangeType(nil)
+		if d.Equals != nil {
+			for _, v := range d.Equals.Value {
+				holes = append(holes, RangeType{Min: &RangeValueType{Value: v, Inclusive: true}, Max: &RangeValueType{Value: v, Inclusive: true}})
+			}
+		} else {
+			holes = d.Range.Range
+		}
+		rs := c.Range.Range
+		for _, h := range holes {
+			var next []RangeType
+			for _, r := range rs {
+				next = append(next, r.minus(h)...)
+			}
+			rs = next
+		}
+		rc := *c.Range
+		rc.Range = rs
+		c.Range = &rc
+		return c, len(rs) > 0, true
+	case c.Pattern != nil && d.Pattern != nil:
+		strs, exact := exactStrings(c.Pattern.Pattern)
+		if !exact {
+			return c, true, false
+		}
+		pc := *c.Pattern
+		pc.Pattern = nil
+		for i, s := range strs {
+			if !takes(Answer{Response: ResponseAnswered, String: s}) {
+				pc.Pattern = append(pc.Pattern, c.Pattern.Pattern[i])
+			}
+		}
+		c.Pattern = &pc
+		return c, len(pc.Pattern) > 0, true
+	}
+	return c, true, false
+}
+
+// minus returns the parts of r, at most two, that lie outside s.
+// Both must have literal bounds.
+func (r RangeType) minus(s RangeType) []RangeType {
+	var out []RangeType
+	if s.Min != nil {
+		below := RangeType{Max: &RangeValueType{Value: s.Min.Value, Inclusive: !s.Min.Inclusive}}
+		if lo, ok := r.intersect(below); ok {
+			out = append(out, lo)
+		}
+	}
+	if s.Max != nil {
+		above := RangeType{Min: &RangeValueType{Value: s.Max.Value, Inclusive: !s.Max.Inclusive}}
+		if hi, ok := r.intersect(above); ok {
+			out = append(out, hi)
+		}
+	}
+	return out
+}
+
+// union returns the scenarios of both sets with any scenario that
+// is implied by a smaller one removed.
+func (a *whatIf) union(x, y []Scenario) []Scenario {
+	all := append(append([]Scenario(nil), x...), y...)
+	sort.SliceStable(all, func(i, j int) bool { return len(all[i]) < len(all[j]) })
+	var out []Scenario
+	for _, s := range all {
+		redundant := false
+		for _, t := range out {
+			if t.subsetOf(s) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			out = append(out, s)
+		}
+	}
+	if len(out) > maxScenarios {
+		out = out[:maxScenarios]
+		a.truncated = true
+	}
+	return out
+}
+
+// product returns every consistent conjunction of one scenario
+// from each set.
+func (a *whatIf) product(x, y []Scenario) []Scenario {
+	var out []Scenario
+	for _, s := range x {
+		for _, t := range y {
+			if c, ok := s.and(t); ok {
+				out = append(out, c)
+			}
+		}
+	}
+	return a.union(out, nil)
+}
+
+// and conjoins two scenarios, merging conditions on the same
+// question. It reports false if the result can never hold.
+func (s Scenario) and(t Scenario) (Scenario, bool) {
+	out := append(Scenario(nil), s...)
+next:
+	for _, c := range t {
+		for i, d := range out {
+			if d.Question != c.Question {
+				continue
+			}
+			if d.Response != c.Response {
+				return nil, false
+			}
+			if c.Response != ResponseAnswered {
+				continue next
+			}
+			m, merged, ok := conjoin(d, c)
+			if !ok {
+				return nil, false
+			}
+			if merged {
+				m.Unless = d.Unless
+				for _, u := range c.Unless {
+					if !(Scenario{u}).subsetOf(m.Unless) {
+						m.Unless = append(m.Unless[:len(m.Unless):len(m.Unless)], u)
+					}
+				}
+				out[i] = m
+				continue next
+			}
+		}
+		out = append(out, c)
+	}
+	return out, true
+}
+
+// conjoin merges two conditions on the answer to the same question.
+// It reports ok false when no answer satisfies both, and merged
+// false when they cannot be expressed as one condition, as when a
+// bound or pattern refers to a variable, whose value is unknown
+// here; such conditions are kept side by side.
+func conjoin(d, c Condition) (m Condition, merged, ok bool) {
+	switch {
+	case c.String() == d.String():
+		return d, true, true
+	case c.Boolean != nil && d.Boolean != nil:
+		return d, true, *c.Boolean == *d.Boolean
+	case c.Choices != nil && d.Choices != nil:
+		var both []ChoiceIDPattern
+		for _, x := range d.Choices {
+			for _, y := range c.Choices {
+				if x == y {
+					both = append(both, x)
+				}
+			}
+		}
+		d.Choices = both
+		return d, true, len(both) > 0
+	case c.Pattern != nil && d.Pattern != nil:
+		return conjoinPatterns(d, c)
+	case (c.Equals != nil || c.Range != nil) && (d.Equals != nil || d.Range != nil):
+		return conjoinNumeric(d, c)
+	}
+	return Condition{}, false, true
+}
+
+// conjoinNumeric merges equality and range conditions with literal
+// values: the values of an equality that lie in the other condition
+// remain, and ranges are intersected.
+func conjoinNumeric(d, c Condition) (Condition, bool, bool) {
+	if d.hasVarRef() || c.hasVarRef() {
+		return Condition{}, false, true
+	}
+	if c.Equals != nil && d.Equals == nil {
+		c, d = d, c
+	}
+	if d.Equals != nil {
+		eq := *d.Equals
+		eq.Value = nil
+		for _, v := range d.Equals.Value {
+			if match, _ := c.Matches(Answer{Response: ResponseAnswered, Numeric: v}, nil); match {
+				eq.Value = append(eq.Value, v)
+			}
+		}
+		d.Equals = &eq
+		return d, true, len(eq.Value) > 0
+	}
+	rc := *d.Range
+	rc.Range = nil
+	for _, x := range d.Range.Range {
+		for _, y := range c.Range.Range {
+			if r, ok := x.intersect(y); ok {
+				rc.Range = append(rc.Range, r)
+			}
+		}
+	}
+	d.Range = &rc
+	return d, true, len(rc.Range) > 0
+}
+
+// intersect returns the range of values in both r and s, reporting
+// false if there are none. Both must have literal bounds.
+func (r RangeType) intersect(s RangeType) (RangeType, bool) {
+	out := RangeType{Min: r.Min, Max: r.Max}
+	if s.Min != nil && (out.Min == nil || s.Min.Value > out.Min.Value || s.Min.Value == out.Min.Value && !s.Min.Inclusive) {
+		out.Min = s.Min
+	}
+	if s.Max != nil && (out.Max == nil || s.Max.Value < out.Max.Value || s.Max.Value == out.Max.Value && !s.Max.Inclusive) {
+		out.Max = s.Max
+	}
+	if out.Min != nil && out.Max != nil {
+		if out.Min.Value > out.Max.Value || out.Min.Value == out.Max.Value && !(out.Min.Inclusive && out.Max.Inclusive) {
+			return RangeType{}, false
+		}
+	}
+	return out, true
+}
+
+// conjoinPatterns merges pattern conditions when one of them admits
+// only exact strings, such as ^root$: those strings the other
+// condition matches remain. Other patterns are kept side by side,
+// as the intersection of regular expressions is not computed.
+func conjoinPatterns(d, c Condition) (Condition, bool, bool) {
+	if d.hasVarRef() || c.hasVarRef() {
+		return Condition{}, false, true
+	}
+	if _, ok := exactStrings(d.Pattern.Pattern); !ok {
+		c, d = d, c
+	}
+	strs, ok := exactStrings(d.Pattern.Pattern)
+	if !ok {
+		return Condition{}, false, true
+	}
+	pc := *d.Pattern
+	pc.Pattern = nil
+	for i, s := range strs {
+		if match, err := c.Matches(Answer{Response: ResponseAnswered, String: s}, nil); match && err == nil {
+			pc.Pattern = append(pc.Pattern, d.Pattern.Pattern[i])
+		}
+	}
+	d.Pattern = &pc
+	return d, true, len(pc.Pattern) > 0
+}
+
+// exactStrings returns the strings matched by each pattern if every
+// pattern is anchored at both ends around a literal.
+func exactStrings(ps []PatternType) ([]string, bool) {
+	var strs []string
+	for _, p := range ps {
+		expr := p.Value
+		if len(expr) < 2 || expr[0] != '^' || expr[len(expr)-1] != '$' {
+			return nil, false
+		}
+		re, err := regexp.Compile(expr[1 : len(expr)-1])
+		if err != nil {
+			return nil, false
+		}
+		lit, complete := re.LiteralPrefix()
+		if !complete {
+			return nil, false
+		}
+		strs = append(strs, lit)
+	}
+	return strs, true
+}
+
+// hasVarRef reports whether a numeric or string condition refers to
+// a variable.
+func (c Condition) hasVarRef() bool {
+	if c.Equals != nil && c.Equals.Var_ref != "" {
+		return true
+	}
+	if c.Range != nil {
+		for _, r := range c.Range.Range {
+			if r.Min != nil && r.Min.Var_ref != "" || r.Max != nil && r.Max.Var_ref != "" {
+				return true
+			}
+		}
+	}
+	if c.Pattern != nil {
+		for _, p := range c.Pattern.Pattern {
+			if p.Var_ref != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// subsetOf reports whether every condition of s also appears in t.
+func (s Scenario) subsetOf(t Scenario) bool {
+	for _, c := range s {
+		found := false
+		for _, d := range t {
+			if c.Question == d.Question && c.String() == d.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package postal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWhatIf(t *testing.T) {
+	x := sampleIndex(t)
+	tests := []struct {
+		name    string
+		answers Answers
+		want    []string
+	}{
+		{
+			name: "no answers",
+			want: []string{
+				"open: 1 = true; 2 in [$ocil:org.example:variable:1, +inf); 3 one of ocil:org.example:choice:1, ocil:org.example:choice:2",
+				"open: 1 = true; 2 in [$ocil:org.example:variable:1, +inf); 3 one of ocil:org.example:choice:3; 4 matches /.*/ unless matches /^admin/",
+				"open: 1 = true; 2 in [$ocil:org.example:variable:1, +inf); 3 one of ocil:org.example:choice:3; 5 = false",
+			},
+		},
+		{
+			name: "blocked",
+			answers: Answers{
+				"ocil:org.example:question:1": {Response: ResponseAnswered, Boolean: false},
+				"ocil:org.example:question:2": {Response: ResponseAnswered, Numeric: 12},
+				"ocil:org.example:question:3": {Response: ResponseAnswered, Choice: "ocil:org.example:choice:3"},
+				"ocil:org.example:question:5": {Response: ResponseAnswered, Boolean: false},
+			},
+			want: []string{
+				"blocking: 1 = true",
+				"blocking: 1 = true; open: 4 matches /.*/ unless matches /^admin/",
+				"blocking: 1 = true; 3 one of ocil:org.example:choice:1, ocil:org.example:choice:2",
+			},
+		},
+	}
+	vars := Variables{"ocil:org.example:variable:1": "8"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep, err := x.WhatIf("ocil:org.example:questionnaire:1", tt.answers, vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range rep.Paths {
+				got = append(got, describePath(p))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// describePath lists the blocking and open conditions of p, naming
+// questions by the number at the end of their IDs.
+func describePath(p PassPath) string {
+	cond := func(c Condition) string {
+		id := string(c.Question)
+		return id[strings.LastIndex(id, ":")+1:] + " " + c.String()
+	}
+	var parts []string
+	if len(p.Blocking) > 0 {
+		var bs []string
+		for _, b := range p.Blocking {
+			bs = append(bs, cond(b.Condition))
+		}
+		parts = append(parts, "blocking: "+strings.Join(bs, "; "))
+	}
+	if len(p.Open) > 0 {
+		var os []string
+		for _, c := range p.Open {
+			os = append(os, cond(c))
+		}
+		parts = append(parts, "open: "+strings.Join(os, "; "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestScenarioAnd(t *testing.T) {
+	const q = "ocil:t:question:1"
+	answered := func(c Condition) Condition {
+		c.Question = q
+		c.Response = ResponseAnswered
+		return c
+	}
+	yes, no := true, false
+	bound := func(v float64, inclusive bool) *RangeValueType {
+		return &RangeValueType{Value: v, Inclusive: inclusive}
+	}
+	ranges := func(rs ...RangeType) Condition {
+		return answered(Condition{Range: &RangeTestActionConditionType{Range: rs}})
+	}
+	equals := func(vs ...float64) Condition {
+		return answered(Condition{Equals: &EqualsTestActionConditionType{Value: vs}})
+	}
+	patterns := func(ps ...string) Condition {
+		c := answered(Condition{Pattern: &PatternTestActionConditionType{}})
+		for _, p := range ps {
+			c.Pattern.Pattern = append(c.Pattern.Pattern, PatternType{Value: p})
+		}
+		return c
+	}
+	tests := []struct {
+		name string
+		s, t Condition
+		// want describes the conditions of the conjunction, or is
+		// empty if it can never hold.
+		want []string
+	}{
+		{"same boolean", answered(Condition{Boolean: &yes}), answered(Condition{Boolean: &yes}), []string{"= true"}},
+		{"opposite booleans", answered(Condition{Boolean: &yes}), answered(Condition{Boolean: &no}), nil},
+		{"other response", answered(Condition{Boolean: &yes}), Condition{Question: q, Response: ResponseNotApplicable}, nil},
+		{"common choice", answered(Condition{Choices: []ChoiceIDPattern{"a", "b"}}), answered(Condition{Choices: []ChoiceIDPattern{"b", "c"}}), []string{"one of b"}},
+		{"disjoint choices", answered(Condition{Choices: []ChoiceIDPattern{"a"}}), answered(Condition{Choices: []ChoiceIDPattern{"b"}}), nil},
+		{"overlapping ranges", ranges(RangeType{Min: bound(0, true), Max: bound(10, true)}), ranges(RangeType{Min: bound(5, false)}), []string{"in (5, 10]"}},
+		{"disjoint ranges", ranges(RangeType{Max: bound(0, true)}), ranges(RangeType{Min: bound(1, true)}), nil},
+		{"ranges meeting at an exclusive bound", ranges(RangeType{Max: bound(5, false)}), ranges(RangeType{Min: bound(5, true)}), nil},
+		{"ranges meeting at inclusive bounds", ranges(RangeType{Max: bound(5, true)}), ranges(RangeType{Min: bound(5, true)}), []string{"in [5, 5]"}},
+		{"unions of ranges", ranges(RangeType{Max: bound(0, true)}, RangeType{Min: bound(10, true)}), ranges(RangeType{Min: bound(-5, true), Max: bound(20, true)}), []string{"in [-5, 0] or [10, 20]"}},
+		{"common value", equals(1, 2), equals(2, 3), []string{"= 2"}},
+		{"different values", equals(1), equals(2), nil},
+		{"value in range", equals(1, 7), ranges(RangeType{Min: bound(5, true)}), []string{"= 7"}},
+		{"value outside range", ranges(RangeType{Min: bound(5, true)}), equals(1), nil},
+		{"variable bound", ranges(RangeType{Min: &RangeValueType{Var_ref: "ocil:t:variable:1", Inclusive: true}}), ranges(RangeType{Max: bound(0, true)}), []string{"in [$ocil:t:variable:1, +inf)", "in (-inf, 0]"}},
+		{"exact string matched", patterns("^root$", "^admin$"), patterns("^ad"), []string{"matches /^admin$/"}},
+		{"exact string not matched", patterns("^ad"), patterns("^root$"), nil},
+		{"different exact strings", patterns("^root$"), patterns("^admin$"), nil},
+		{"general patterns", patterns("^ad"), patterns("in$"), []string{"matches /^ad/", "matches /in$/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Scenario{tt.s}.and(Scenario{tt.t})
+			var conds []string
+			for _, c := range got {
+				conds = append(conds, c.String())
+			}
+			if ok != (tt.want != nil) || !reflect.DeepEqual(conds, tt.want) {
+				t.Errorf("got %q (%v), want %q", conds, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionExclude(t *testing.T) {
+	const q = "ocil:t:question:1"
+	answered := func(c Condition) Condition {
+		c.Question = q
+		c.Response = ResponseAnswered
+		return c
+	}
+	bound := func(v float64, inclusive bool) *RangeValueType {
+		return &RangeValueType{Value: v, Inclusive: inclusive}
+	}
+	ranges := func(rs ...RangeType) Condition {
+		return answered(Condition{Range: &RangeTestActionConditionType{Range: rs}})
+	}
+	equals := func(vs ...float64) Condition {
+		return answered(Condition{Equals: &EqualsTestActionConditionType{Value: vs}})
+	}
+	patterns := func(ps ...string) Condition {
+		c := answered(Condition{Pattern: &PatternTestActionConditionType{}})
+		for _, p := range ps {
+			c.Pattern.Pattern = append(c.Pattern.Pattern, PatternType{Value: p})
+		}
+		return c
+	}
+	choices := func(cs ...ChoiceIDPattern) Condition {
+		return answered(Condition{Choices: cs})
+	}
+	yes := true
+	tests := []struct {
+		name    string
+		c       Condition
+		earlier []Condition
+		// want describes what is left of c, or is empty if nothing
+		// is.
+		want string
+	}{
+		{"overlapping ranges", ranges(RangeType{Min: bound(0, true), Max: bound(10, true)}), []Condition{ranges(RangeType{Min: bound(0, true), Max: bound(5, true)})}, "in (5, 10]"},
+		{"range split by a value", ranges(RangeType{Min: bound(0, true), Max: bound(10, true)}), []Condition{equals(5)}, "in [0, 5) or (5, 10]"},
+		{"range covered", ranges(RangeType{Min: bound(1, true), Max: bound(2, true)}), []Condition{ranges(RangeType{Min: bound(0, true)})}, ""},
+		{"values in an earlier range", equals(1, 7), []Condition{ranges(RangeType{Min: bound(5, true)})}, "= 1"},
+		{"variable bound", ranges(RangeType{Min: bound(0, true)}), []Condition{ranges(RangeType{Min: &RangeValueType{Var_ref: "ocil:t:variable:1", Inclusive: true}})}, "in [0, +inf) unless in [$ocil:t:variable:1, +inf)"},
+		{"choices", choices("a", "b", "c"), []Condition{choices("a"), choices("c")}, "one of b"},
+		{"choices taken", choices("a"), []Condition{choices("a", "b")}, ""},
+		{"exact strings", patterns("^root$", "^admin$"), []Condition{patterns("^ad")}, "matches /^root$/"},
+		{"general pattern", patterns(".*"), []Condition{patterns("^admin")}, "matches /.*/ unless matches /^admin/"},
+		{"same boolean", answered(Condition{Boolean: &yes}), []Condition{answered(Condition{Boolean: &yes})}, ""},
+		{"other response", answered(Condition{Boolean: &yes}), []Condition{{Question: q, Response: ResponseNotApplicable}}, "= true"},
+		{"same response", Condition{Question: q, Response: ResponseUnknown}, []Condition{{Question: q, Response: ResponseUnknown}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.c.exclude(tt.earlier)
+			var s string
+			if ok {
+				s = got.String()
+			}
+			if ok != (tt.want != "") || s != tt.want {
+				t.Errorf("got %q (%v), want %q", s, ok, tt.want)
+			}
+		})
+	}
+}
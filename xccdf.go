@@ -0,0 +1,589 @@
+package postal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+const (
+	// XCCDFNamespace is the namespace of XCCDF 1.2 benchmarks.
+	XCCDFNamespace = "http://checklists.nist.gov/xccdf/1.2"
+	// XCCDF11Namespace is the namespace of XCCDF 1.1 benchmarks,
+	// still used by DISA STIGs.
+	XCCDF11Namespace = "http://checklists.nist.gov/xccdf/1.1"
+	// CheckSystem identifies OCIL as the check system of an XCCDF
+	// check.
+	CheckSystem = "http://scap.nist.gov/schema/ocil/2"
+)
+
+// A Benchmark holds the parts of an XCCDF 1.2 benchmark needed to
+// run its OCIL checks. Rules and values nested in groups are
+// flattened into document order.
+type Benchmark struct {
+	ID       string
+	Title    string
+	Rules    []XCCDFRule
+	Values   []XCCDFValue
+	Profiles []XCCDFProfile
+
+	// selected holds the values chosen by UseProfile, and selects
+	// its rule and group selections, in the order they apply.
+	selected map[string]string
+	selects  []XCCDFSelect
+}
+
+// An XCCDFRule is a benchmark rule and its checks. Group is the
+// innermost group containing the rule, if any, and Groups all of
+// them, outermost first.
+type XCCDFRule struct {
+	ID          string       `xml:"id,attr"`
+	Selected    string       `xml:"selected,attr"`
+	ClusterID   string       `xml:"cluster-id,attr"`
+	Severity    string       `xml:"severity,attr"`
+	Version     string       `xml:"version"`
+	Title       string       `xml:"title"`
+	Description string       `xml:"description"`
+	Idents      []XCCDFIdent `xml:"ident"`
+	Checks      []XCCDFCheck `xml:"check"`
+	Group       XCCDFGroup   `xml:"-"`
+	Groups      []XCCDFGroup `xml:"-"`
+}
+
+// An XCCDFGroup identifies a benchmark group.
+type XCCDFGroup struct {
+	ID        string `xml:"id,attr"`
+	Selected  string `xml:"selected,attr"`
+	ClusterID string `xml:"cluster-id,attr"`
+	Title     string `xml:"title"`
+}
+
+// An XCCDFIdent is an identifier of a rule in some system, such as
+// a CCE or CCI.
+type XCCDFIdent struct {
+	System string `xml:"system,attr"`
+	Value  string `xml:",chardata"`
+}
+
+// An XCCDFCheck is a check element of a rule.
+type XCCDFCheck struct {
+	System      string                 `xml:"system,attr"`
+	Negate      bool                   `xml:"negate,attr"`
+	Exports     []XCCDFCheckExport     `xml:"check-export"`
+	ContentRefs []XCCDFCheckContentRef `xml:"check-content-ref"`
+	Content     string                 `xml:"check-content"`
+}
+
+// An XCCDFCheckExport passes the value of an XCCDF Value to the
+// check system variable named by Name.
+type XCCDFCheckExport struct {
+	ValueID string `xml:"value-id,attr"`
+	Name    string `xml:"export-name,attr"`
+}
+
+// An XCCDFCheckContentRef points to the check content: for OCIL, a
+// document and optionally a questionnaire within it.
+type XCCDFCheckContentRef struct {
+	Href string `xml:"href,attr"`
+	Name string `xml:"name,attr"`
+}
+
+// An XCCDFValue is a benchmark value with its selectable
+// alternatives. The alternative with an empty Selector is the
+// default.
+type XCCDFValue struct {
+	ID     string               `xml:"id,attr"`
+	Type   string               `xml:"type,attr"`
+	Values []XCCDFValueSelector `xml:"value"`
+}
+
+// An XCCDFValueSelector is one value alternative.
+type XCCDFValueSelector struct {
+	Selector string `xml:"selector,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// Select returns the alternative with the given selector.
+func (v *XCCDFValue) Select(selector string) (string, bool) {
+	for _, s := range v.Values {
+		if s.Selector == selector {
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+// Default returns the value used when no selector is chosen.
+func (v *XCCDFValue) Default() (string, bool) {
+	for _, s := range v.Values {
+		if s.Selector == "" {
+			return s.Value, true
+		}
+	}
+	if len(v.Values) > 0 {
+		return v.Values[0].Value, true
+	}
+	return "", false
+}
+
+// An XCCDFProfile tailors a benchmark. Of its settings the rule
+// and value selections are kept: select turns rules and groups on
+// or off, set-value gives a value directly and refine-value chooses
+// one of a Value's alternatives.
+type XCCDFProfile struct {
+	ID           string             `xml:"id,attr"`
+	Extends      string             `xml:"extends,attr"`
+	Title        string             `xml:"title"`
+	Selects      []XCCDFSelect      `xml:"select"`
+	SetValues    []XCCDFSetValue    `xml:"set-value"`
+	RefineValues []XCCDFRefineValue `xml:"refine-value"`
+}
+
+// An XCCDFSelect selects or deselects the rule or group with ID
+// Idref, or every rule and group with that cluster-id.
+type XCCDFSelect struct {
+	Idref    string `xml:"idref,attr"`
+	Selected bool   `xml:"selected,attr"`
+}
+
+// An XCCDFSetValue sets a benchmark value.
+type XCCDFSetValue struct {
+	Idref string `xml:"idref,attr"`
+	Value string `xml:",chardata"`
+}
+
+// An XCCDFRefineValue selects an alternative of a benchmark value.
+type XCCDFRefineValue struct {
+	Idref    string `xml:"idref,attr"`
+	Selector string `xml:"selector,attr"`
+}
+
+// ReadTailoring reads the profiles of an XCCDF 1.2 tailoring
+// document.
+func ReadTailoring(r io.Reader) ([]XCCDFProfile, error) {
+	var in struct {
+		XMLName  xml.Name
+		Profiles []XCCDFProfile `xml:"Profile"`
+	}
+	if err := xml.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	if in.XMLName.Space != XCCDFNamespace || in.XMLName.Local != "Tailoring" {
+		return nil, fmt.Errorf("not an XCCDF 1.2 tailoring: root element is {%s}%s", in.XMLName.Space, in.XMLName.Local)
+	}
+	return in.Profiles, nil
+}
+
+// ReadTailoringFile reads the XCCDF tailoring in the named file.
+func ReadTailoringFile(name string) ([]XCCDFProfile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ps, err := ReadTailoring(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return ps, nil
+}
+
+// UseProfile selects the rules and values of the profile with the
+// given ID, searched for first among tailoring and then among the
+// benchmark's own profiles. Settings of extended profiles apply
+// unless the extending profile overrides them.
+func (b *Benchmark) UseProfile(id string, tailoring []XCCDFProfile) error {
+	find := func(id string) *XCCDFProfile {
+		for _, ps := range [][]XCCDFProfile{tailoring, b.Profiles} {
+			for i := range ps {
+				if ps[i].ID == id {
+					return &ps[i]
+				}
+			}
+		}
+		return nil
+	}
+	var chain []*XCCDFProfile
+	for id != "" {
+		p := find(id)
+		if p == nil {
+			return fmt.Errorf("unknown profile %s", id)
+		}
+		for _, q := range chain {
+			if q == p {
+				return fmt.Errorf("profile %s extends itself", id)
+			}
+		}
+		chain = append(chain, p)
+		id = p.Extends
+	}
+	b.selected = make(map[string]string)
+	b.selects = nil
+	for i := len(chain) - 1; i >= 0; i-- {
+		p := chain[i]
+		b.selects = append(b.selects, p.Selects...)
+		for _, rv := range p.RefineValues {
+			v := b.value(rv.Idref)
+			if v == nil {
+				return fmt.Errorf("profile %s refines unknown value %s", p.ID, rv.Idref)
+			}
+			s, ok := v.Select(rv.Selector)
+			if !ok {
+				return fmt.Errorf("profile %s: value %s has no selector %q", p.ID, rv.Idref, rv.Selector)
+			}
+			b.selected[rv.Idref] = s
+		}
+		for _, sv := range p.SetValues {
+			b.selected[sv.Idref] = sv.Value
+		}
+	}
+	return nil
+}
+
+// Selected reports whether r is selected: whether it and every
+// group containing it are, after the selections of the profile in
+// use, in order, or else by their selected attributes.
+func (b *Benchmark) Selected(r *XCCDFRule) bool {
+	for _, g := range r.Groups {
+		if !b.itemSelected(g.ID, g.ClusterID, g.Selected) {
+			return false
+		}
+	}
+	return b.itemSelected(r.ID, r.ClusterID, r.Selected)
+}
+
+// itemSelected reports whether the item with the given ID and
+// cluster-id is selected, given its selected attribute.
+func (b *Benchmark) itemSelected(id, cluster, attr string) bool {
+	selected := attr != "false" && attr != "0"
+	for _, s := range b.selects {
+		if s.Idref == id || cluster != "" && s.Idref == cluster {
+			selected = s.Selected
+		}
+	}
+	return selected
+}
+
+func (b *Benchmark) value(id string) *XCCDFValue {
+	for i := range b.Values {
+		if b.Values[i].ID == id {
+			return &b.Values[i]
+		}
+	}
+	return nil
+}
+
+// An xccdfGroup is a benchmark or group as read: its rules and
+// subgroups, each in an xccdfItem in document order, its values
+// and, for a benchmark, its profiles.
+type xccdfGroup struct {
+	XCCDFGroup
+	Items    []xccdfItem
+	Values   []XCCDFValue
+	Profiles []XCCDFProfile
+}
+
+// An xccdfItem is either a rule or a group.
+type xccdfItem struct {
+	Rule  *XCCDFRule
+	Group *xccdfGroup
+}
+
+func (g *xccdfGroup) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "id":
+			g.ID = a.Value
+		case "selected":
+			g.Selected = a.Value
+		case "cluster-id":
+			g.ClusterID = a.Value
+		}
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "title":
+				var title string
+				err = d.DecodeElement(&title, &tok)
+				if g.Title == "" {
+					g.Title = title
+				}
+			case "Rule":
+				r := new(XCCDFRule)
+				err = d.DecodeElement(r, &tok)
+				g.Items = append(g.Items, xccdfItem{Rule: r})
+			case "Group":
+				sub := new(xccdfGroup)
+				err = d.DecodeElement(sub, &tok)
+				g.Items = append(g.Items, xccdfItem{Group: sub})
+			case "Value":
+				var v XCCDFValue
+				err = d.DecodeElement(&v, &tok)
+				g.Values = append(g.Values, v)
+			case "Profile":
+				var p XCCDFProfile
+				err = d.DecodeElement(&p, &tok)
+				g.Profiles = append(g.Profiles, p)
+			default:
+				err = d.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flatten adds the rules and values of the groups of g and their
+// subgroups to b, in document order. The groups containing them,
+// outermost first, are outer.
+func (g *xccdfGroup) flatten(b *Benchmark, outer []XCCDFGroup) {
+	b.Values = append(b.Values, g.Values...)
+	for _, it := range g.Items {
+		if it.Rule != nil {
+			r := *it.Rule
+			if len(outer) > 0 {
+				r.Group = outer[len(outer)-1]
+			}
+			r.Groups = outer
+			b.Rules = append(b.Rules, r)
+			continue
+		}
+		it.Group.flatten(b, append(outer[:len(outer):len(outer)], it.Group.XCCDFGroup))
+	}
+}
+
+// ReadBenchmark reads an XCCDF 1.2 or 1.1 benchmark.
+func ReadBenchmark(r io.Reader) (*Benchmark, error) {
+	d := xml.NewDecoder(r)
+	var start xml.StartElement
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			start = t
+			break
+		}
+	}
+	if (start.Name.Space != XCCDFNamespace && start.Name.Space != XCCDF11Namespace) || start.Name.Local != "Benchmark" {
+		return nil, fmt.Errorf("not an XCCDF benchmark: root element is {%s}%s", start.Name.Space, start.Name.Local)
+	}
+	var in xccdfGroup
+	if err := d.DecodeElement(&in, &start); err != nil {
+		return nil, err
+	}
+	b := &Benchmark{ID: in.ID, Title: in.Title, Profiles: in.Profiles}
+	// Rules directly in the benchmark belong to no group.
+	in.flatten(b, nil)
+	return b, nil
+}
+
+// ReadBenchmarkFile reads the XCCDF benchmark in the named file.
+func ReadBenchmarkFile(name string) (*Benchmark, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBenchmark(f)
+}
+
+// OCILCheck returns the first check of the rule handled by OCIL.
+func (r *XCCDFRule) OCILCheck() (*XCCDFCheck, bool) {
+	for i := range r.Checks {
+		if r.Checks[i].System == CheckSystem {
+			return &r.Checks[i], true
+		}
+	}
+	return nil, false
+}
+
+// Exports binds the check's exports to OCIL external variables:
+// each export name is a variable ID, given the value selected by
+// UseProfile or else the default of the benchmark value it
+// exports.
+func (b *Benchmark) Exports(c *XCCDFCheck) (Variables, error) {
+	vars := make(Variables)
+	for _, e := range c.Exports {
+		if s, ok := b.selected[e.ValueID]; ok {
+			vars[VariableIDPattern(e.Name)] = s
+			continue
+		}
+		v := b.value(e.ValueID)
+		if v == nil {
+			return nil, fmt.Errorf("check-export of unknown value %s", e.ValueID)
+		}
+		s, ok := v.Default()
+		if !ok {
+			return nil, fmt.Errorf("value %s has no value to export", e.ValueID)
+		}
+		vars[VariableIDPattern(e.Name)] = s
+	}
+	return vars, nil
+}
+
+// Refs returns the check-content-refs of the check that point into
+// the document indexed by x, found at href. A ref whose href has a
+// different base name points elsewhere. When either href is
+// unknown, a ref points into the document if it names one of its
+// questionnaires or names none.
+func (c *XCCDFCheck) Refs(x *Index, href string) []XCCDFCheckContentRef {
+	var refs []XCCDFCheckContentRef
+	for _, ref := range c.ContentRefs {
+		if href != "" && ref.Href != "" {
+			if path.Base(filepath.ToSlash(ref.Href)) != filepath.Base(href) {
+				continue
+			}
+		} else if ref.Name != "" {
+			if _, ok := x.Questionnaires[QuestionnaireIDPattern(ref.Name)]; !ok {
+				continue
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// ExternalVariables binds the exports of the OCIL checks of every
+// selected rule that refer to the document indexed by x, found at
+// href, giving one set of values for the document as a whole. It is
+// an error for two checks to export different values to the same
+// variable.
+func (b *Benchmark) ExternalVariables(x *Index, href string) (Variables, error) {
+	vars := make(Variables)
+	for i := range b.Rules {
+		r := &b.Rules[i]
+		c, ok := r.OCILCheck()
+		if !ok || !b.Selected(r) || len(c.Refs(x, href)) == 0 {
+			continue
+		}
+		vs, err := b.Exports(c)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %v", r.ID, err)
+		}
+		for id, v := range vs {
+			if old, ok := vars[id]; ok && old != v {
+				return nil, fmt.Errorf("rule %s exports %q to %s, another rule %q", r.ID, v, id, old)
+			}
+			vars[id] = v
+		}
+	}
+	return vars, nil
+}
+
+// XCCDF rule-result values for OCIL results.
+var xccdfResults = map[ResultType]string{
+	ResultPass:          "pass",
+	ResultFail:          "fail",
+	ResultError:         "error",
+	ResultUnknown:       "unknown",
+	ResultNotTested:     "notchecked",
+	ResultNotApplicable: "notapplicable",
+}
+
+// XCCDFResult returns the XCCDF rule-result value for r.
+func XCCDFResult(r ResultType) string {
+	if s, ok := xccdfResults[r]; ok {
+		return s
+	}
+	return "error"
+}
+
+// A RuleResult is the outcome of a rule's OCIL check, in the shape
+// of an XCCDF rule-result element.
+type RuleResult struct {
+	XMLName       xml.Name               `xml:"rule-result"`
+	RuleID        string                 `xml:"idref,attr"`
+	Result        string                 `xml:"result"`
+	Check         XCCDFCheckContentRef   `xml:"check>check-content-ref"`
+	Questionnaire QuestionnaireIDPattern `xml:"-"`
+	Err           error                  `xml:"-"`
+}
+
+// EvaluateRules evaluates the OCIL check of every selected rule of
+// the benchmark that refers to the document indexed by x, found at
+// href, as Refs finds. Each check names a questionnaire in its
+// check-content-ref; a check without a name combines all top-level
+// questionnaires of the document with AND. The check's exports are
+// bound to the document's external variables for that rule only. A
+// rule that cannot be evaluated has the result error and Err set.
+func (b *Benchmark) EvaluateRules(x *Index, href string, src AnswerSource) []RuleResult {
+	var out []RuleResult
+	for i := range b.Rules {
+		r := &b.Rules[i]
+		if !b.Selected(r) {
+			continue
+		}
+		c, ok := r.OCILCheck()
+		if !ok {
+			continue
+		}
+		refs := c.Refs(x, href)
+		if len(refs) == 0 {
+			continue
+		}
+		rr := RuleResult{RuleID: r.ID}
+		res, err := b.evaluateCheck(x, src, c, refs, &rr)
+		if err != nil {
+			rr.Err = fmt.Errorf("rule %s: %v", r.ID, err)
+			res = ResultError
+		}
+		if c.Negate {
+			res = res.Negate()
+		}
+		rr.Result = XCCDFResult(res)
+		out = append(out, rr)
+	}
+	return out
+}
+
+func (b *Benchmark) evaluateCheck(x *Index, src AnswerSource, c *XCCDFCheck, refs []XCCDFCheckContentRef, rr *RuleResult) (ResultType, error) {
+	vars, err := b.Exports(c)
+	if err != nil {
+		return "", err
+	}
+	for id := range vars {
+		if _, ok := x.Externals[id]; !ok {
+			return "", fmt.Errorf("check-export to unknown external variable %s", id)
+		}
+	}
+	e := NewEvaluator(x, src, vars)
+	for _, ref := range refs {
+		if ref.Name == "" {
+			rr.Check = ref
+			var rs []ResultType
+			for _, q := range x.Doc.Questionnaires.Questionnaire {
+				if q.Child_only {
+					continue
+				}
+				res, err := e.Questionnaire(q.Id)
+				if err != nil {
+					return "", err
+				}
+				rs = append(rs, res)
+			}
+			return Combine(OperatorAnd, rs), nil
+		}
+		id := QuestionnaireIDPattern(ref.Name)
+		if _, ok := x.Questionnaires[id]; !ok {
+			continue
+		}
+		rr.Check = ref
+		rr.Questionnaire = id
+		return e.Questionnaire(id)
+	}
+	return "", fmt.Errorf("no check-content-ref names a questionnaire of the document")
+}
@@ -0,0 +1,139 @@
+package postal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBenchmarkSelected(t *testing.T) {
+	tests := []struct {
+		profile string
+		want    []string
+	}{
+		{"", []string{"xccdf_org.example_rule_on", "xccdf_org.example_rule_other"}},
+		{"xccdf_org.example_profile_base", []string{"xccdf_org.example_rule_on", "xccdf_org.example_rule_off", "xccdf_org.example_rule_nested", "xccdf_org.example_rule_other"}},
+		{"xccdf_org.example_profile_derived", []string{"xccdf_org.example_rule_off", "xccdf_org.example_rule_other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			b, err := ReadBenchmarkFile("testdata/benchmark.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.profile != "" {
+				if err := b.UseProfile(tt.profile, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got []string
+			for i := range b.Rules {
+				if b.Selected(&b.Rules[i]) {
+					got = append(got, b.Rules[i].ID)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected rules %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBenchmarkGroups(t *testing.T) {
+	b, err := ReadBenchmarkFile("testdata/benchmark.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range b.Rules {
+		if r.ID != "xccdf_org.example_rule_nested" {
+			if len(r.Groups) != 0 {
+				t.Errorf("rule %s: groups %v, want none", r.ID, r.Groups)
+			}
+			continue
+		}
+		var ids []string
+		for _, g := range r.Groups {
+			ids = append(ids, g.ID)
+		}
+		if want := []string{"xccdf_org.example_group_off", "xccdf_org.example_group_inner"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("rule %s: groups %q, want %q", r.ID, ids, want)
+		}
+		if r.Group.ID != "xccdf_org.example_group_inner" {
+			t.Errorf("rule %s: group %s, want the innermost", r.ID, r.Group.ID)
+		}
+	}
+}
+
+func TestEvaluateRulesProfile(t *testing.T) {
+	x := sampleIndex(t)
+	answers, err := ReadFile("testdata/answers-a.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the rules whose checks refer to sample.xml are evaluated,
+	// whether or not the location of the document is known.
+	for _, href := range []string{"testdata/sample.xml", ""} {
+		b, err := ReadBenchmarkFile("testdata/benchmark.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.UseProfile("xccdf_org.example_profile_derived", nil); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range b.EvaluateRules(x, href, answers.Results.Answers()) {
+			got = append(got, r.RuleID)
+		}
+		if want := []string{"xccdf_org.example_rule_off"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("href %q: evaluated rules %q, want %q", href, got, want)
+		}
+	}
+}
+
+func TestExternalVariables(t *testing.T) {
+	tests := []struct {
+		profile string
+		href    string
+		want    Variables
+	}{
+		{"", "testdata/sample.xml", Variables{"ocil:org.example:variable:1": "8"}},
+		{"", "", Variables{"ocil:org.example:variable:1": "8"}},
+		{"", "other.xml", Variables{"ocil:org.example.other:variable:1": "8"}},
+		{"xccdf_org.example_profile_base", "sample.xml", Variables{"ocil:org.example:variable:1": "14"}},
+		// The derived profile deselects the rule exporting to sample.xml.
+		{"xccdf_org.example_profile_derived", "sample.xml", Variables{}},
+	}
+	x := sampleIndex(t)
+	for _, tt := range tests {
+		t.Run(tt.profile+"/"+tt.href, func(t *testing.T) {
+			b, err := ReadBenchmarkFile("testdata/benchmark.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.profile != "" {
+				if err := b.UseProfile(tt.profile, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := b.ExternalVariables(x, tt.href)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func sampleIndex(t *testing.T) *Index {
+	t.Helper()
+	doc, err := ReadFile("testdata/sample.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := NewIndex(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
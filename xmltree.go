@@ -0,0 +1,393 @@
+package postal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Namespace is the XML namespace of OCIL 2.0 documents.
+const Namespace = "http://scap.nist.gov/schema/ocil/2.0"
+
+const (
+	xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+)
+
+// A node is an element, or when name is empty, a run of
+// character data or a comment. It is used to post-process the
+// output of encoding/xml, which cannot omit empty struct-typed
+// elements or share a default namespace between parent and child.
+type node struct {
+	name    xml.Name
+	attr    []xml.Attr
+	kids    []*node
+	text    string
+	comment bool
+	// xsiType is the resolved value of an xsi:type attribute, which
+	// is not kept in attr as its prefix may change.
+	xsiType *xml.Name
+	// prolog holds the comments before the root element.
+	prolog []string
+}
+
+// parseTree reads the next element from d, including all of its
+// descendants and the comments before it. Namespace declarations
+// are dropped; names, and the QName values of xsi:type attributes,
+// keep their resolved namespace URI.
+func parseTree(d *xml.Decoder) (*node, error) {
+	var stack []*node
+	var prolog []string
+	// scopes holds the namespace declarations in scope at each
+	// element of stack.
+	var scopes []map[string]string
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name}
+			if len(stack) == 0 {
+				n.prolog = prolog
+			}
+			scope := make(map[string]string)
+			if len(scopes) > 0 {
+				for prefix, space := range scopes[len(scopes)-1] {
+					scope[prefix] = space
+				}
+			}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					scope[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					scope[""] = a.Value
+				}
+			}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns"):
+				case a.Name.Space == xsiNamespace && a.Name.Local == "type":
+					prefix, local := "", a.Value
+					if i := strings.Index(local, ":"); i >= 0 {
+						prefix, local = local[:i], local[i+1:]
+					}
+					n.xsiType = &xml.Name{Space: scope[prefix], Local: local}
+				default:
+					n.attr = append(n.attr, a)
+				}
+			}
+			if len(stack) > 0 {
+				p := stack[len(stack)-1]
+				p.kids = append(p.kids, n)
+			}
+			stack = append(stack, n)
+			scopes = append(scopes, scope)
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			scopes = scopes[:len(scopes)-1]
+			if len(stack) == 0 {
+				return n, nil
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				p := stack[len(stack)-1]
+				p.kids = append(p.kids, &node{text: string(t)})
+			}
+		case xml.Comment:
+			if len(stack) == 0 {
+				prolog = append(prolog, string(t))
+				continue
+			}
+			p := stack[len(stack)-1]
+			p.kids = append(p.kids, &node{text: string(t), comment: true})
+		}
+	}
+}
+
+// mixed reports whether n has non-whitespace character data
+// among its children.
+func (n *node) mixed() bool {
+	for _, k := range n.kids {
+		if k.name.Local == "" && !k.comment && strings.TrimSpace(k.text) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// prune removes, bottom-up, the child elements of n that are empty
+// and that m, the content model of n, marks optional. An element is
+// empty when it has no attributes, no character data and no child
+// elements other than empty ones, so an optional element goes with
+// the empty required elements it holds. prune reports whether n is
+// empty.
+func (n *node) prune(m *contentModel) bool {
+	empty := len(n.attr) == 0 && n.xsiType == nil && !n.mixed()
+	kids := n.kids[:0]
+	for _, k := range n.kids {
+		if k.name.Local == "" {
+			kids = append(kids, k)
+			continue
+		}
+		var km *contentModel
+		ocil := m != nil && k.name.Space == Namespace
+		if ocil {
+			km = schema.model(m.types[k.name.Local])
+		}
+		if k.prune(km) {
+			if ocil && m.optional[k.name.Local] {
+				continue
+			}
+		} else {
+			empty = false
+		}
+		kids = append(kids, k)
+	}
+	n.kids = kids
+	return empty
+}
+
+// treePrinter writes a tree with the OCIL namespace as the default
+// namespace and attributes sorted by name, indenting element-only
+// content and leaving mixed content untouched. When wrap is set, the
+// text of prose elements is filled to wrapWidth columns instead.
+type treePrinter struct {
+	w        *bufio.Writer
+	indent   string
+	wrap     bool
+	prefixes map[string]string
+	// values lists namespaces used only in QName attribute
+	// values, which must be declared although no name uses them.
+	values []string
+}
+
+func newTreePrinter(w io.Writer, indent string) *treePrinter {
+	return &treePrinter{
+		w:      bufio.NewWriter(w),
+		indent: indent,
+		prefixes: map[string]string{
+			Namespace:    "",
+			xsiNamespace: "xsi",
+			xmlNamespace: "xml",
+		},
+	}
+}
+
+// declare assigns prefixes to every namespace used in the tree
+// and returns the declarations to put on the root element.
+func (p *treePrinter) declare(root *node) []string {
+	used := make(map[string]bool)
+	var walk func(*node)
+	walk = func(n *node) {
+		if n.name.Local == "" {
+			return
+		}
+		used[n.name.Space] = true
+		if n.xsiType != nil {
+			used[xsiNamespace] = true
+			used[n.xsiType.Space] = true
+		}
+		for _, a := range n.attr {
+			if a.Name.Space != "" {
+				used[a.Name.Space] = true
+			}
+		}
+		for _, k := range n.kids {
+			walk(k)
+		}
+	}
+	walk(root)
+	for _, s := range p.values {
+		used[s] = true
+	}
+	var spaces []string
+	for s := range used {
+		if s != "" && s != xmlNamespace {
+			spaces = append(spaces, s)
+		}
+	}
+	sort.Strings(spaces)
+	var decls []string
+	for _, s := range spaces {
+		prefix, ok := p.prefixes[s]
+		if !ok {
+			prefix = fmt.Sprintf("ns%d", len(p.prefixes))
+			p.prefixes[s] = prefix
+		}
+		if prefix == "" {
+			decls = append(decls, fmt.Sprintf(`xmlns="%s"`, s))
+		} else {
+			decls = append(decls, fmt.Sprintf(`xmlns:%s="%s"`, prefix, s))
+		}
+	}
+	return decls
+}
+
+func (p *treePrinter) qname(n xml.Name) string {
+	if prefix := p.prefixes[n.Space]; prefix != "" {
+		return prefix + ":" + n.Local
+	}
+	return n.Local
+}
+
+func (p *treePrinter) print(root *node) error {
+	p.w.WriteString(xml.Header)
+	for _, c := range root.prolog {
+		p.w.WriteString("<!--" + c + "-->\n")
+	}
+	p.element(root, 0, p.declare(root))
+	p.w.WriteString("\n")
+	return p.w.Flush()
+}
+
+// wrapWidth is the column at which the text of prose elements is
+// wrapped.
+const wrapWidth = 80
+
+// proseElements are the OCIL elements holding free text, which
+// readers treat as a sequence of words and paragraphs.
+var proseElements = map[string]bool{
+	"description":   true,
+	"notice":        true,
+	"notes":         true,
+	"question_text": true,
+}
+
+// sortedAttr returns attr sorted by name, with the attributes in no
+// namespace first.
+func (p *treePrinter) sortedAttr(attr []xml.Attr) []xml.Attr {
+	s := append([]xml.Attr(nil), attr...)
+	sort.SliceStable(s, func(i, j int) bool {
+		if (s[i].Name.Space == "") != (s[j].Name.Space == "") {
+			return s[i].Name.Space == ""
+		}
+		return p.qname(s[i].Name) < p.qname(s[j].Name)
+	})
+	return s
+}
+
+func (p *treePrinter) element(n *node, depth int, decls []string) {
+	name := p.qname(n.name)
+	var start bytes.Buffer
+	start.WriteString("<" + name)
+	for _, d := range decls {
+		start.WriteString(" " + d)
+	}
+	attr := n.attr
+	if n.xsiType != nil {
+		attr = append(attr[:len(attr):len(attr)], xml.Attr{Name: xml.Name{Space: xsiNamespace, Local: "type"}, Value: p.qname(*n.xsiType)})
+	}
+	for _, a := range p.sortedAttr(attr) {
+		start.WriteString(" " + p.qname(a.Name) + `="`)
+		xml.EscapeText(&start, []byte(a.Value))
+		start.WriteString(`"`)
+	}
+	p.w.Write(start.Bytes())
+	if len(n.kids) == 0 {
+		p.w.WriteString("/>")
+		return
+	}
+	p.w.WriteString(">")
+	if text, ok := n.prose(); ok && p.wrap {
+		p.fill(text, depth, depth*len(p.indent)+start.Len()+1, len(name)+3)
+	} else if n.mixed() {
+		for _, k := range n.kids {
+			switch {
+			case k.comment:
+				p.w.WriteString("<!--" + k.text + "-->")
+			case k.name.Local == "":
+				textEscaper.WriteString(p.w, k.text)
+			default:
+				p.element(k, depth+1, nil)
+			}
+		}
+	} else {
+		wrote := false
+		for _, k := range n.kids {
+			if k.name.Local == "" && !k.comment {
+				continue
+			}
+			p.w.WriteString("\n" + strings.Repeat(p.indent, depth+1))
+			if k.comment {
+				p.w.WriteString("<!--" + k.text + "-->")
+			} else {
+				p.element(k, depth+1, nil)
+			}
+			wrote = true
+		}
+		if wrote {
+			p.w.WriteString("\n" + strings.Repeat(p.indent, depth))
+		}
+	}
+	p.w.WriteString("</" + name + ">")
+}
+
+// prose returns the text of a prose element holding nothing but
+// non-blank character data.
+func (n *node) prose() (string, bool) {
+	if n.name.Space != Namespace || !proseElements[n.name.Local] {
+		return "", false
+	}
+	var b strings.Builder
+	for _, k := range n.kids {
+		if k.name.Local != "" || k.comment {
+			return "", false
+		}
+		b.WriteString(k.text)
+	}
+	if strings.TrimSpace(b.String()) == "" {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// textEscaper escapes character data, leaving quotes and white
+// space as they are.
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+// fill writes the text of a prose element whose start tag ends at
+// column col and whose end tag is endLen long. Text that fits on
+// one line and has no line breaks is written as is. Otherwise its
+// words are filled into lines indented one level below the element,
+// keeping blank lines between paragraphs, and the end tag goes on a
+// line of its own.
+func (p *treePrinter) fill(text string, depth, col, endLen int) {
+	if esc := textEscaper.Replace(text); !strings.Contains(text, "\n") && col+len(esc)+endLen <= wrapWidth {
+		p.w.WriteString(esc)
+		return
+	}
+	indent := strings.Repeat(p.indent, depth+1)
+	for i, para := range paragraphRE.Split(strings.TrimSpace(text), -1) {
+		if i > 0 {
+			p.w.WriteString("\n")
+		}
+		line := 0
+		for _, word := range strings.Fields(para) {
+			esc := textEscaper.Replace(word)
+			if line > 0 && line+1+len(esc) > wrapWidth {
+				line = 0
+			}
+			if line == 0 {
+				p.w.WriteString("\n" + indent)
+				line = len(indent)
+			} else {
+				p.w.WriteString(" ")
+				line++
+			}
+			p.w.WriteString(esc)
+			line += len(esc)
+		}
+	}
+	p.w.WriteString("\n" + strings.Repeat(p.indent, depth))
+}